@@ -284,6 +284,18 @@ func (c *controlServer) Run(args []string) int {
 	hubSecret := os.Getenv("HUB_SECRET_KEY")
 	hubTag := os.Getenv("HUB_IMAGE_TAG")
 
+	tcpPortMin, tcpPortMax := control.DefaultTCPPortMin, control.DefaultTCPPortMax
+
+	// The hubs must be given the same range.
+	if str := os.Getenv("TCP_PORT_RANGE"); str != "" {
+		first, last, err := control.ParseTCPPortRange(str)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		tcpPortMin, tcpPortMax = first, last
+	}
+
 	port := os.Getenv("PORT")
 
 	go StartHealthz(L)
@@ -315,6 +327,9 @@ func (c *controlServer) Run(args []string) int {
 		HubAccessKey: hubAccess,
 		HubSecretKey: hubSecret,
 		HubImageTag:  hubTag,
+
+		TCPPortMin: tcpPortMin,
+		TCPPortMax: tcpPortMax,
 	})
 	if err != nil {
 		log.Fatal(err)
@@ -436,6 +451,33 @@ func (h *hubRunner) Run(args []string) int {
 
 	httpPort := os.Getenv("HTTP_PORT")
 
+	// The address to listen on for the TCP ports control allocates to
+	// accounts, such as 0.0.0.0. TCP ingress is disabled if it's not set.
+	tcpIngressHost := os.Getenv("TCP_INGRESS_HOST")
+
+	tcpPortMin, tcpPortMax := control.DefaultTCPPortMin, control.DefaultTCPPortMax
+
+	// Must match the range given to control.
+	if str := os.Getenv("TCP_PORT_RANGE"); str != "" {
+		first, last, err := control.ParseTCPPortRange(str)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		tcpPortMin, tcpPortMax = first, last
+	}
+
+	drainTimeout := hub.DefaultDrainTimeout
+
+	if str := os.Getenv("DRAIN_TIMEOUT"); str != "" {
+		dur, err := time.ParseDuration(str)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		drainTimeout = dur
+	}
+
 	ctx := hclog.WithContext(context.Background(), L)
 
 	ctx, cancel := context.WithCancel(ctx)
@@ -444,11 +486,39 @@ func (h *hubRunner) Run(args []string) int {
 	sigs := make(chan os.Signal, 1)
 	signal.Notify(sigs, os.Interrupt, syscall.SIGQUIT)
 
+	hubReady := make(chan *hub.Hub, 1)
+
+	// Once the hub is running, the first signal drains it so agents can move
+	// to other hubs. Any other signal closes down right away.
 	go func() {
+		var hb *hub.Hub
+
 		for {
-			s := <-sigs
-			L.Info("signal received, closing down", "signal", s)
-			cancel()
+			select {
+			case hb = <-hubReady:
+			case s := <-sigs:
+				if hb == nil {
+					L.Info("signal received, closing down", "signal", s)
+					cancel()
+					continue
+				}
+
+				L.Info("signal received, draining hub", "signal", s, "timeout", drainTimeout)
+
+				go func(hb *hub.Hub) {
+					dctx, dcancel := context.WithTimeout(ctx, drainTimeout)
+					defer dcancel()
+
+					err := hb.Drain(dctx)
+					if err != nil {
+						L.Warn("hub did not drain cleanly", "error", err)
+					}
+
+					cancel()
+				}(hb)
+
+				hb = nil
+			}
 		}
 	}()
 
@@ -548,6 +618,8 @@ func (h *hubRunner) Run(args []string) int {
 		log.Fatal(err)
 	}
 
+	hubReady <- hb
+
 	for _, loc := range locs {
 		L.Info("learned network location", "labels", loc.Labels, "addresses", loc.Addresses)
 	}
@@ -557,6 +629,11 @@ func (h *hubRunner) Run(args []string) int {
 		go hb.ListenHTTP(":" + httpPort)
 	}
 
+	if tcpIngressHost != "" {
+		L.Info("listen for tcp ingress", "host", tcpIngressHost, "ports", fmt.Sprintf("%d-%d", tcpPortMin, tcpPortMax))
+		go hb.RunTCPIngress(ctx, tcpIngressHost, tcpPortMin, tcpPortMax)
+	}
+
 	go StartHealthz(L)
 
 	err = hb.Run(ctx, ln)
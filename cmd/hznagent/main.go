@@ -2,15 +2,18 @@ package main
 
 import (
 	"context"
+	"crypto/x509"
 	"fmt"
-	"io"
 	"io/ioutil"
 	"log"
 	"net"
 	"net/http"
 	"os"
+	"os/signal"
 	"strconv"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/horizon/pkg/agent"
@@ -60,6 +63,30 @@ func main() {
 
 			return r, nil
 		},
+		"status": func() (cli.Command, error) {
+			r := &statusRunner{}
+			if err := r.init(); err != nil {
+				return nil, err
+			}
+
+			return r, nil
+		},
+		"send": func() (cli.Command, error) {
+			r := &sendRunner{}
+			if err := r.init(); err != nil {
+				return nil, err
+			}
+
+			return r, nil
+		},
+		"receive": func() (cli.Command, error) {
+			r := &receiveRunner{}
+			if err := r.init(); err != nil {
+				return nil, err
+			}
+
+			return r, nil
+		},
 		"pipe": func() (cli.Command, error) {
 			r := &pipeRunner{}
 			if err := r.init(); err != nil {
@@ -101,12 +128,38 @@ func Token(flag *string) string {
 	return token
 }
 
+// RootCAs loads the PEM encoded certificates in path to use when verifying
+// hubs and the discovery server. Nil, meaning the system roots, is returned
+// if path is empty.
+func RootCAs(path string) *x509.CertPool {
+	if path == "" {
+		return nil
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		log.Fatalf("error reading CA file: %s", err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		log.Fatalf("no certificates found in CA file: %s", path)
+	}
+
+	return pool
+}
+
 type proxyRunner struct {
 	flags    *pflag.FlagSet
 	fControl *string
 	fToken   *string
 	fLabels  *string
 	fListen  *string
+	fUDP     *bool
+	fSOCKS   *bool
+	fConnect *bool
+	fRule    *string
+	fCAFile  *string
 	fVerbose *int
 }
 
@@ -116,6 +169,11 @@ func (c *proxyRunner) init() error {
 	c.fToken = c.flags.String("token", "", "authentication token")
 	c.fLabels = c.flags.StringP("labels", "l", "", "labels to associate with service")
 	c.fListen = c.flags.StringP("listen", "p", "", "address to listen on which will be bridge to the given service")
+	c.fUDP = c.flags.Bool("udp", false, "listen for UDP datagrams rather than TCP connections")
+	c.fSOCKS = c.flags.Bool("socks", false, "run a SOCKS5 proxy, connecting to the service the requested host maps to")
+	c.fConnect = c.flags.Bool("http-connect", false, "run an HTTP CONNECT proxy, connecting to the service the requested host maps to")
+	c.fRule = c.flags.String("host-rule", agent.DefaultHostRule, "how requested hosts map to labels in --socks and --http-connect modes, combined with --labels")
+	c.fCAFile = c.flags.String("ca-file", "", "PEM file of CA certificates used to verify hubs, rather than the system roots")
 	c.fVerbose = c.flags.CountP("verbose", "v", "increase verbosity of output")
 	return nil
 }
@@ -154,16 +212,44 @@ func (c *proxyRunner) Run(args []string) int {
 		}
 	}
 
-	labels := pb.ParseLabelSet(*c.fLabels)
-	L.Info("starting tcp listener", "addr", target, "labels", labels)
+	var (
+		labels *pb.LabelSet
+		hr     *agent.HostRule
+	)
+
+	switch {
+	case *c.fSOCKS && *c.fConnect:
+		fmt.Fprintf(os.Stderr, "Only one of --socks and --http-connect can be used\n")
+		return 1
+	case *c.fSOCKS || *c.fConnect:
+		var err error
+
+		hr, err = agent.ParseHostRule(*c.fRule, *c.fLabels)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid host rule: %s\n", err)
+			return 1
+		}
+
+		L.Info("starting proxy listener", "addr", target, "host-rule", *c.fRule, "socks", *c.fSOCKS)
+	case *c.fUDP:
+		labels = pb.ParseLabelSet(*c.fLabels)
+		L.Info("starting udp listener", "addr", target, "labels", labels)
+	default:
+		labels = pb.ParseLabelSet(*c.fLabels)
+		L.Info("starting tcp listener", "addr", target, "labels", labels)
+	}
 
 	L.Debug("discovering hubs")
 
+	roots := RootCAs(*c.fCAFile)
+
 	dc, err := discovery.NewClient(*c.fControl)
 	if err != nil {
 		log.Fatal(err)
 	}
 
+	dc.RootCAs = roots
+
 	L.Debug("refreshing data")
 
 	ctx := context.Background()
@@ -181,17 +267,67 @@ func (c *proxyRunner) Run(args []string) int {
 	}
 
 	g.Token = Token(c.fToken)
+	g.RootCAs = roots
 
 	err = g.Start(ctx, dc)
 	if err != nil {
 		log.Fatal(err)
 	}
 
+	if *c.fUDP {
+		pc, err := net.ListenPacket("udp", target)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		go func() {
+			err := proxyUDP(L, g, pc, labels)
+			if err != nil {
+				L.Error("error reading datagrams", "error", err)
+				os.Exit(1)
+			}
+		}()
+
+		L.Info("agent running")
+		err = g.Wait(ctx)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		return 0
+	}
+
 	l, err := net.Listen("tcp", target)
 	if err != nil {
 		log.Fatal(err)
 	}
 
+	if hr != nil {
+		handle := func(lc net.Conn) error {
+			return handleHTTPConnect(L, g, hr, lc)
+		}
+
+		if *c.fSOCKS {
+			handle = func(lc net.Conn) error {
+				return handleSOCKS(L, g, hr, lc)
+			}
+		}
+
+		go func() {
+			err := serveProxy(L, l, handle)
+			L.Error("error accepting new connections", "error", err)
+			os.Exit(1)
+		}()
+
+		L.Info("agent running")
+		err = g.Wait(ctx)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		return 0
+	}
+
 	go func() {
 		for {
 			lc, err := l.Accept()
@@ -207,17 +343,7 @@ func (c *proxyRunner) Run(args []string) int {
 				continue
 			}
 
-			go func() {
-				defer lc.Close()
-				defer rc.Close()
-
-				go func() {
-					defer rc.Close()
-					io.Copy(rc, lc)
-				}()
-
-				io.Copy(lc, rc)
-			}()
+			go bridge(lc, rc)
 		}
 	}()
 
@@ -231,7 +357,7 @@ func (c *proxyRunner) Run(args []string) int {
 }
 
 func (c *proxyRunner) Synopsis() string {
-	return "proxy to a TCP server provided by a horizon service"
+	return "proxy to a TCP or UDP server provided by a horizon service, or run a SOCKS5 or HTTP CONNECT proxy to many services"
 }
 
 type testRunner struct {
@@ -279,7 +405,30 @@ type agentRunner struct {
 	fHTTP    *string
 	fLabels  *string
 	fTCP     *string
+	fUDP     *string
+	fHealth  *string
+	fDrain   *time.Duration
+	fConfig  *string
+	fAdmin   *string
+	fMetrics *string
+	fCAFile  *string
+	fHubs    *int
 	fVerbose *int
+
+	fMaxConcurrent *int
+	fMaxQueue      *int
+	fQueueTimeout  *time.Duration
+
+	fHostHeader   *string
+	fPathPrefix   *string
+	fUpstreamCA   *string
+	fUpstreamName *string
+	fUpstreamCert *string
+	fUpstreamKey  *string
+
+	fInspect     *string
+	fInspectSize *int
+	fInspectCred *bool
 }
 
 func (a *agentRunner) init() error {
@@ -287,8 +436,28 @@ func (a *agentRunner) init() error {
 	a.fControl = a.flags.String("control", "control.alpha.hzn.network", "address of control plane")
 	a.fToken = a.flags.String("token", "", "authentication token")
 	a.fLabels = a.flags.StringP("labels", "l", "", "labels to associate with service")
-	a.fTCP = a.flags.String("tcp", "", "address of tcp server to advertise")
-	a.fHTTP = a.flags.String("http", "", "address to forward http traffic to")
+	a.fTCP = a.flags.String("tcp", "", "address of tcp server to advertise, host:port or unix:///path")
+	a.fHTTP = a.flags.String("http", "", "address to forward http traffic to, host:port or an http://, https://, h2c://, or unix:/// url")
+	a.fHostHeader = a.flags.String("http-host", "", "rewrite the Host header of http requests to this value")
+	a.fPathPrefix = a.flags.String("http-path-prefix", "", "prefix to add to the path of http requests")
+	a.fUpstreamCA = a.flags.String("http-ca-file", "", "PEM file of CA certificates used to verify an https upstream")
+	a.fUpstreamName = a.flags.String("http-server-name", "", "name to verify an https upstream's certificate against")
+	a.fUpstreamCert = a.flags.String("http-cert", "", "client certificate to present to an https upstream")
+	a.fUpstreamKey = a.flags.String("http-key", "", "key for the client certificate given by --http-cert")
+	a.fInspect = a.flags.String("inspect", "", "address to serve the request inspector on, recording requests to http services")
+	a.fInspectSize = a.flags.Int("inspect-size", agent.DefaultInspectorSize, "how many requests the inspector keeps")
+	a.fInspectCred = a.flags.Bool("inspect-credentials", false, "record Authorization and Cookie headers in the inspector instead of redacting them")
+	a.fUDP = a.flags.String("udp", "", "address of udp server to advertise")
+	a.fMaxConcurrent = a.flags.Int("max-concurrent", 0, "the most streams to send to the service at once, 0 for no limit")
+	a.fMaxQueue = a.flags.Int("max-queue", 0, "how many streams may wait once --max-concurrent is reached, the rest are refused")
+	a.fQueueTimeout = a.flags.Duration("queue-timeout", agent.DefaultQueueTimeout, "how long a stream waits in the queue before being refused")
+	a.fConfig = a.flags.StringP("config", "c", "", "path to a JSON configuration file declaring the agent's services")
+	a.fAdmin = a.flags.String("admin", "", "address to serve the admin API on, host:port or unix:///path")
+	a.fMetrics = a.flags.String("metrics", "", "address to serve prometheus metrics on at /metrics, host:port or unix:///path")
+	a.fCAFile = a.flags.String("ca-file", "", "PEM file of CA certificates used to verify hubs, rather than the system roots")
+	a.fHubs = a.flags.Int("hub-sessions", agent.DefaultTargetSessions, "how many hubs to keep connected to")
+	a.fDrain = a.flags.Duration("drain-timeout", 30*time.Second, "how long to wait for active requests to finish when shutting down")
+	a.fHealth = a.flags.String("health-check", "", "path to GET on the http server, or 'tcp' to connect to the tcp server, to check it's health. A tcp service only gets the 'tcp' check")
 	a.fVerbose = a.flags.CountP("verbose", "v", "increase verbosity of output")
 
 	return nil
@@ -330,26 +499,129 @@ func (a *agentRunner) Run(args []string) int {
 		log.Fatal(err)
 	}
 
+	if *a.fInspect != "" {
+		g.Inspector = agent.NewInspector(*a.fInspectSize, 0)
+		g.Inspector.KeepCredentials = *a.fInspectCred
+	}
+
+	var (
+		setup bool
+		cfg   *agent.Config
+	)
+
+	if *a.fConfig != "" {
+		cfg, err = agent.LoadConfig(*a.fConfig)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if cfg.Control != "" && !a.flags.Changed("control") {
+			*a.fControl = cfg.Control
+		}
+
+		if *a.fToken == "" {
+			*a.fToken, err = cfg.ReadToken()
+			if err != nil {
+				log.Fatal(err)
+			}
+		}
+
+		g.Labels = cfg.Labels
+	}
+
 	g.Token = Token(a.fToken)
+	g.RootCAs = RootCAs(*a.fCAFile)
+	g.TargetSessions = *a.fHubs
 
-	var setup bool
+	if cfg != nil {
+		err = g.SyncServices(cfg.Services)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		setup = len(cfg.Services) > 0
+	}
+
+	// A tcp service can only be checked by connecting to it, so a path would
+	// be ignored unless there's an http service to use it with.
+	if *a.fTCP != "" && *a.fHTTP == "" && *a.fHealth != "" && *a.fHealth != "tcp" {
+		fmt.Fprintf(os.Stderr, "A tcp service can only use --health-check tcp\n")
+		return 1
+	}
 
 	if *a.fHTTP != "" {
-		target := *a.fHTTP
-		if strings.IndexByte(target, ':') == -1 {
+		sc := agent.ServiceConfig{
+			Type:       "http",
+			Target:     *a.fHTTP,
+			Labels:     *a.fLabels,
+			Host:       *a.fHostHeader,
+			PathPrefix: *a.fPathPrefix,
+			Inspect:    g.Inspector != nil,
+
+			MaxConcurrent: *a.fMaxConcurrent,
+			MaxQueue:      *a.fMaxQueue,
+			QueueTimeout:  a.fQueueTimeout.String(),
+		}
+
+		if *a.fUpstreamCA != "" || *a.fUpstreamName != "" || *a.fUpstreamCert != "" {
+			sc.TLS = &agent.UpstreamTLS{
+				CAFile:     *a.fUpstreamCA,
+				ServerName: *a.fUpstreamName,
+				CertFile:   *a.fUpstreamCert,
+				KeyFile:    *a.fUpstreamKey,
+			}
+		}
+
+		switch *a.fHealth {
+		case "":
+		case "tcp":
+			sc.HealthCheck = &agent.HealthCheckConfig{TCP: "true"}
+		default:
+			sc.HealthCheck = &agent.HealthCheckConfig{HTTP: *a.fHealth}
+		}
+
+		serv, err := g.ServiceFromConfig(&sc)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to configure http service: %s\n", err)
+			return 1
+		}
+
+		L.Info("registered http service", "address", *a.fHTTP)
+		_, err = g.AddService(serv)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		setup = true
+	}
+
+	if *a.fTCP != "" {
+		target := *a.fTCP
+		if !strings.HasPrefix(target, "unix://") && strings.IndexByte(target, ':') == -1 {
 			_, err := strconv.Atoi(target)
 			if err == nil {
 				target = "127.0.0.1:" + target
 			} else {
-				target = target + ":80"
+				fmt.Fprintf(os.Stderr, "Unable to interpret '%s' as TCP target address", target)
+				return 1
 			}
 		}
 
-		L.Info("registered http service", "address", target)
+		var hc agent.HealthChecker
+		if *a.fHealth == "tcp" {
+			hc = agent.TCPHealthCheck(target)
+		}
+
+		L.Info("registered tcp service", "address", target)
 		_, err = g.AddService(&agent.Service{
-			Type:    "http",
-			Labels:  pb.ParseLabelSet(*a.fLabels),
-			Handler: agent.HTTPHandler("http://" + target),
+			Type:        "tcp",
+			Labels:      pb.ParseLabelSet(*a.fLabels),
+			Handler:     agent.TCPHandler(target),
+			HealthCheck: hc,
+
+			MaxConcurrent: *a.fMaxConcurrent,
+			MaxQueue:      *a.fMaxQueue,
+			QueueTimeout:  *a.fQueueTimeout,
 		})
 
 		if err != nil {
@@ -359,23 +631,27 @@ func (a *agentRunner) Run(args []string) int {
 		setup = true
 	}
 
-	if *a.fTCP != "" {
-		target := *a.fTCP
+	if *a.fUDP != "" {
+		target := *a.fUDP
 		if strings.IndexByte(target, ':') == -1 {
 			_, err := strconv.Atoi(target)
 			if err == nil {
 				target = "127.0.0.1:" + target
 			} else {
-				fmt.Fprintf(os.Stderr, "Unable to interpret '%s' as TCP target address", target)
+				fmt.Fprintf(os.Stderr, "Unable to interpret '%s' as UDP target address", target)
 				return 1
 			}
 		}
 
-		L.Info("registered tcp service", "address", target)
+		L.Info("registered udp service", "address", target)
 		_, err = g.AddService(&agent.Service{
-			Type:    "tcp",
+			Type:    "udp",
 			Labels:  pb.ParseLabelSet(*a.fLabels),
-			Handler: agent.TCPHandler(target),
+			Handler: agent.UDPHandler(target),
+
+			MaxConcurrent: *a.fMaxConcurrent,
+			MaxQueue:      *a.fMaxQueue,
+			QueueTimeout:  *a.fQueueTimeout,
 		})
 
 		if err != nil {
@@ -390,6 +666,14 @@ func (a *agentRunner) Run(args []string) int {
 		return 1
 	}
 
+	// Set up before starting so the initial hub connections are recorded.
+	if *a.fMetrics != "" {
+		err = serveMetrics(L, *a.fMetrics)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
 	L.Debug("discovering hubs")
 
 	dc, err := discovery.NewClient(*a.fControl)
@@ -397,6 +681,8 @@ func (a *agentRunner) Run(args []string) int {
 		log.Fatal(err)
 	}
 
+	dc.RootCAs = g.RootCAs
+
 	L.Debug("refreshing data")
 
 	err = dc.Refresh(ctx)
@@ -409,6 +695,58 @@ func (a *agentRunner) Run(args []string) int {
 		log.Fatal(err)
 	}
 
+	if cfg != nil {
+		go a.reloadOnHUP(L, g)
+	}
+
+	// The admin API is only for inspecting the agent, so the agent keeps
+	// running without it if the address can't be used.
+	if *a.fAdmin != "" {
+		l, err := listenAdmin(*a.fAdmin)
+		if err != nil {
+			L.Error("unable to serve admin api", "addr", *a.fAdmin, "error", err)
+		} else {
+			L.Info("serving admin api", "addr", *a.fAdmin)
+
+			go http.Serve(l, g.AdminHandler())
+		}
+	}
+
+	if g.Inspector != nil {
+		l, err := listenAdmin(*a.fInspect)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		L.Info("serving request inspector", "addr", *a.fInspect)
+
+		go http.Serve(l, g.Inspector.Handler())
+	}
+
+	go func() {
+		sigs := make(chan os.Signal, 1)
+		signal.Notify(sigs, syscall.SIGTERM, os.Interrupt)
+
+		sig := <-sigs
+
+		L.Info("received signal, draining agent", "signal", sig, "timeout", *a.fDrain)
+
+		// A second signal skips the drain.
+		go func() {
+			<-sigs
+			L.Warn("received second signal, exiting immediately")
+			os.Exit(1)
+		}()
+
+		sctx, cancel := context.WithTimeout(context.Background(), *a.fDrain)
+		defer cancel()
+
+		err := g.Shutdown(sctx)
+		if err != nil {
+			L.Warn("agent did not drain cleanly", "error", err)
+		}
+	}()
+
 	L.Info("agent running")
 
 	err = g.Wait(ctx)
@@ -418,3 +756,28 @@ func (a *agentRunner) Run(args []string) int {
 
 	return 0
 }
+
+// Reloads the configuration file whenever SIGHUP is received and updates the
+// agent's services to match.
+func (a *agentRunner) reloadOnHUP(L hclog.Logger, g *agent.Agent) {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGHUP)
+
+	for range sigs {
+		L.Info("reloading configuration", "path", *a.fConfig)
+
+		cfg, err := agent.LoadConfig(*a.fConfig)
+		if err != nil {
+			L.Error("error loading configuration, keeping current services", "error", err)
+			continue
+		}
+
+		err = g.SyncServices(cfg.Services)
+		if err != nil {
+			L.Error("error updating services", "error", err)
+			continue
+		}
+
+		L.Info("configuration reloaded", "services", len(cfg.Services))
+	}
+}
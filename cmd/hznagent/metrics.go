@@ -0,0 +1,54 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/armon/go-metrics"
+	"github.com/armon/go-metrics/prometheus"
+	"github.com/hashicorp/go-hclog"
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Routes the agent's metrics into prometheus and serves them on /metrics at
+// addr, which is host:port or unix:///path.
+func serveMetrics(L hclog.Logger, addr string) error {
+	// The sink keeps a series until it goes unreported for this long, so
+	// series for removed services and old hubs eventually go away.
+	psink, err := prometheus.NewPrometheusSinkFrom(prometheus.PrometheusOpts{
+		Expiration: time.Hour,
+	})
+	if err != nil {
+		return err
+	}
+
+	mcfg := metrics.DefaultConfig("hznagent")
+	mcfg.EnableHostname = false
+	mcfg.EnableRuntimeMetrics = false
+
+	_, err = metrics.NewGlobal(mcfg, psink)
+	if err != nil {
+		return err
+	}
+
+	l, err := listenAdmin(addr)
+	if err != nil {
+		return err
+	}
+
+	handlerOptions := promhttp.HandlerOpts{
+		ErrorLog:           L.Named("prometheus_handler").StandardLogger(nil),
+		ErrorHandling:      promhttp.ContinueOnError,
+		DisableCompression: true,
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(prom.DefaultGatherer, handlerOptions))
+
+	L.Info("serving metrics", "addr", addr)
+
+	go http.Serve(l, mux)
+
+	return nil
+}
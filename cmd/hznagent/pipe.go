@@ -20,6 +20,7 @@ type pipeRunner struct {
 	fToken   *string
 	fId      *string
 	fListen  *bool
+	fCAFile  *string
 	fVerbose *int
 }
 
@@ -29,6 +30,7 @@ func (c *pipeRunner) init() error {
 	c.fToken = c.flags.String("token", "", "authentication token")
 	c.fId = c.flags.String("id", "", "pipe identifier")
 	c.fListen = c.flags.Bool("listen", false, "listen for a pipe connection")
+	c.fCAFile = c.flags.String("ca-file", "", "PEM file of CA certificates used to verify hubs, rather than the system roots")
 	c.fVerbose = c.flags.CountP("verbose", "v", "increase verbosity of output")
 	return nil
 }
@@ -83,11 +85,15 @@ func (c *pipeRunner) Run(args []string) int {
 
 	L.Debug("discovering hubs")
 
+	roots := RootCAs(*c.fCAFile)
+
 	dc, err := discovery.NewClient(*c.fControl)
 	if err != nil {
 		log.Fatal(err)
 	}
 
+	dc.RootCAs = roots
+
 	L.Debug("refreshing data")
 
 	ctx := context.Background()
@@ -105,6 +111,7 @@ func (c *pipeRunner) Run(args []string) int {
 	}
 
 	g.Token = Token(c.fToken)
+	g.RootCAs = roots
 
 	if *c.fId == "" {
 		*c.fId = pb.NewULID().SpecString()
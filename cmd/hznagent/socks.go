@@ -0,0 +1,248 @@
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/horizon/pkg/agent"
+	"github.com/pkg/errors"
+)
+
+const (
+	socksVersion = 5
+
+	socksNoAuth       = 0
+	socksNoAcceptable = 0xff
+
+	socksConnect = 1
+
+	socksAddrIPv4   = 1
+	socksAddrDomain = 3
+	socksAddrIPv6   = 4
+
+	socksSucceeded          = 0
+	socksHostUnreachable    = 4
+	socksCommandUnsupported = 7
+	socksAddrUnsupported    = 8
+)
+
+// Bridges lc with the service rc until either side closes.
+func bridge(lc io.ReadWriteCloser, rc io.ReadWriteCloser) {
+	defer lc.Close()
+	defer rc.Close()
+
+	go func() {
+		defer rc.Close()
+		io.Copy(rc, lc)
+	}()
+
+	io.Copy(lc, rc)
+}
+
+// Accepts connections on l, passing each to handle in it's own goroutine.
+func serveProxy(L hclog.Logger, l net.Listener, handle func(net.Conn) error) error {
+	for {
+		lc, err := l.Accept()
+		if err != nil {
+			return err
+		}
+
+		go func() {
+			err := handle(lc)
+			if err != nil {
+				L.Error("error handling proxy connection", "error", err, "remote", lc.RemoteAddr())
+				lc.Close()
+			}
+		}()
+	}
+}
+
+// Handles a single SOCKS5 client. Only the CONNECT command without
+// authentication is supported, which is all that's needed for a local proxy.
+// The requested host is mapped to the labels of the service using hr.
+func handleSOCKS(L hclog.Logger, g *agent.Agent, hr *agent.HostRule, lc net.Conn) error {
+	br := bufio.NewReader(lc)
+
+	var hdr [2]byte
+
+	_, err := io.ReadFull(br, hdr[:])
+	if err != nil {
+		return err
+	}
+
+	if hdr[0] != socksVersion {
+		return fmt.Errorf("unsupported socks version: %d", hdr[0])
+	}
+
+	methods := make([]byte, hdr[1])
+
+	_, err = io.ReadFull(br, methods)
+	if err != nil {
+		return err
+	}
+
+	method := byte(socksNoAcceptable)
+
+	for _, m := range methods {
+		if m == socksNoAuth {
+			method = socksNoAuth
+		}
+	}
+
+	_, err = lc.Write([]byte{socksVersion, method})
+	if err != nil {
+		return err
+	}
+
+	if method == socksNoAcceptable {
+		return errors.New("socks client requires authentication")
+	}
+
+	var req [4]byte
+
+	_, err = io.ReadFull(br, req[:])
+	if err != nil {
+		return err
+	}
+
+	if req[1] != socksConnect {
+		socksReply(lc, socksCommandUnsupported)
+		return fmt.Errorf("unsupported socks command: %d", req[1])
+	}
+
+	var host string
+
+	switch req[3] {
+	case socksAddrDomain:
+		l, err := br.ReadByte()
+		if err != nil {
+			return err
+		}
+
+		name := make([]byte, l)
+
+		_, err = io.ReadFull(br, name)
+		if err != nil {
+			return err
+		}
+
+		host = string(name)
+	case socksAddrIPv4, socksAddrIPv6:
+		ip := make(net.IP, net.IPv4len)
+		if req[3] == socksAddrIPv6 {
+			ip = make(net.IP, net.IPv6len)
+		}
+
+		_, err = io.ReadFull(br, ip)
+		if err != nil {
+			return err
+		}
+
+		host = ip.String()
+	default:
+		socksReply(lc, socksAddrUnsupported)
+		return fmt.Errorf("unsupported socks address type: %d", req[3])
+	}
+
+	var port [2]byte
+
+	_, err = io.ReadFull(br, port[:])
+	if err != nil {
+		return err
+	}
+
+	labels, err := hr.Labels(host)
+	if err != nil {
+		socksReply(lc, socksHostUnreachable)
+		return err
+	}
+
+	L.Debug("socks connect", "host", host, "port", binary.BigEndian.Uint16(port[:]), "labels", labels)
+
+	rc, err := g.Connect(labels)
+	if err != nil {
+		socksReply(lc, socksHostUnreachable)
+		return errors.Wrapf(err, "error connecting to %s", labels)
+	}
+
+	err = socksReply(lc, socksSucceeded)
+	if err != nil {
+		rc.Close()
+		return err
+	}
+
+	// Any data the client sent after the request is buffered in br, so
+	// read from it rather than lc.
+	bridge(&bufferedConn{Conn: lc, r: br}, rc)
+
+	return nil
+}
+
+// Writes a reply with an unspecified bind address, since the client can't
+// use it to reach the service anyway.
+func socksReply(w io.Writer, code byte) error {
+	_, err := w.Write([]byte{socksVersion, code, 0, socksAddrIPv4, 0, 0, 0, 0, 0, 0})
+	return err
+}
+
+// Handles a single HTTP CONNECT request, mapping the requested host to the
+// labels of the service using hr.
+func handleHTTPConnect(L hclog.Logger, g *agent.Agent, hr *agent.HostRule, lc net.Conn) error {
+	br := bufio.NewReader(lc)
+
+	req, err := http.ReadRequest(br)
+	if err != nil {
+		return err
+	}
+
+	if req.Method != "CONNECT" {
+		httpReply(lc, http.StatusMethodNotAllowed)
+		return fmt.Errorf("unsupported method: %s", req.Method)
+	}
+
+	labels, err := hr.Labels(req.Host)
+	if err != nil {
+		httpReply(lc, http.StatusBadGateway)
+		return err
+	}
+
+	L.Debug("http connect", "host", req.Host, "labels", labels)
+
+	rc, err := g.Connect(labels)
+	if err != nil {
+		httpReply(lc, http.StatusBadGateway)
+		return errors.Wrapf(err, "error connecting to %s", labels)
+	}
+
+	err = httpReply(lc, http.StatusOK)
+	if err != nil {
+		rc.Close()
+		return err
+	}
+
+	bridge(&bufferedConn{Conn: lc, r: br}, rc)
+
+	return nil
+}
+
+func httpReply(w io.Writer, code int) error {
+	_, err := io.WriteString(w, "HTTP/1.1 "+strconv.Itoa(code)+" "+http.StatusText(code)+"\r\n\r\n")
+	return err
+}
+
+// A net.Conn that reads through a buffered reader which may hold data
+// already read from the connection.
+type bufferedConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (b *bufferedConn) Read(p []byte) (int, error) {
+	return b.r.Read(p)
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/hashicorp/horizon/pkg/agent"
+	"github.com/spf13/pflag"
+)
+
+// Where status looks for the admin API unless told otherwise. Agents only
+// serve it when given --admin, so start them with --admin 127.0.0.1:9418 to
+// use the default.
+const defaultAdminAddr = "127.0.0.1:9418"
+
+// Listens on addr for the admin API. addr is either a host:port or a path to
+// a unix socket prefixed with unix://.
+func listenAdmin(addr string) (net.Listener, error) {
+	if path := strings.TrimPrefix(addr, "unix://"); path != addr {
+		// Clean up a socket left behind by a previous run.
+		os.Remove(path)
+		return net.Listen("unix", path)
+	}
+
+	return net.Listen("tcp", addr)
+}
+
+// Returns an http.Client and base URL to talk to the admin API at addr.
+func adminClient(addr string) (*http.Client, string) {
+	if path := strings.TrimPrefix(addr, "unix://"); path != addr {
+		client := &http.Client{
+			Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					var dialer net.Dialer
+					return dialer.DialContext(ctx, "unix", path)
+				},
+			},
+		}
+
+		return client, "http://agent"
+	}
+
+	return http.DefaultClient, "http://" + addr
+}
+
+type statusRunner struct {
+	flags  *pflag.FlagSet
+	fAdmin *string
+	fJSON  *bool
+}
+
+func (s *statusRunner) init() error {
+	s.flags = pflag.NewFlagSet("status", pflag.ExitOnError)
+	s.fAdmin = s.flags.String("admin", defaultAdminAddr, "address of the agent admin API, host:port or unix:///path")
+	s.fJSON = s.flags.Bool("json", false, "output the raw status as JSON")
+	return nil
+}
+
+func (s *statusRunner) Help() string {
+	str := "horizon status:"
+	str += s.flags.FlagUsagesWrapped(4)
+	return str
+}
+
+func (s *statusRunner) Synopsis() string {
+	return "show the status of a running agent"
+}
+
+func (s *statusRunner) Run(args []string) int {
+	s.flags.Parse(args)
+
+	client, base := adminClient(*s.fAdmin)
+
+	resp, err := client.Get(base + "/status")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error contacting agent: %s\n", err)
+		return 1
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		fmt.Fprintf(os.Stderr, "Unexpected response from agent: %s\n", resp.Status)
+		return 1
+	}
+
+	var st agent.Status
+
+	err = json.NewDecoder(resp.Body).Decode(&st)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error decoding status: %s\n", err)
+		return 1
+	}
+
+	if *s.fJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(&st)
+		return 0
+	}
+
+	fmt.Printf("Streams: %d active, %d total, %d errors\n", st.ActiveStreams, st.TotalStreams, st.StreamErrors)
+
+	if st.Draining {
+		fmt.Printf("Agent is draining\n")
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "\nHUB\tNAME\tCONNECTED\tLATENCY\tSKEW\n")
+
+	for _, h := range st.Hubs {
+		connected := h.ConnectedAt.Format("2006-01-02 15:04:05")
+		if h.ClosingAt != nil {
+			connected += " (draining until " + h.ClosingAt.Format("15:04:05") + ")"
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n",
+			h.Addr, h.Name, connected, h.Latency, h.Skew)
+	}
+
+	fmt.Fprintf(tw, "\nSERVICE\tTYPE\tLABELS\tADVERTISED\tHEALTH\tACTIVE\tTOTAL\tERRORS\n")
+
+	for _, serv := range st.Services {
+		health := "-"
+		if serv.Health != nil {
+			if serv.Health.Healthy {
+				health = "passing"
+			} else {
+				health = "failing: " + serv.Health.LastError
+			}
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%t\t%s\t%d\t%d\t%d\n",
+			serv.Id, serv.Type, serv.Labels, serv.Advertised, health,
+			serv.ActiveStreams, serv.TotalStreams, serv.StreamErrors)
+	}
+
+	tw.Flush()
+
+	return 0
+}
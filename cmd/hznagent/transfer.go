@@ -0,0 +1,331 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/horizon/pkg/agent"
+	"github.com/hashicorp/horizon/pkg/discovery"
+	"github.com/hashicorp/horizon/pkg/pb"
+	"github.com/hashicorp/horizon/pkg/transfer"
+	"github.com/spf13/pflag"
+)
+
+// The flags shared by send and receive.
+type transferFlags struct {
+	flags    *pflag.FlagSet
+	fControl *string
+	fToken   *string
+	fCAFile  *string
+	fVerbose *int
+}
+
+func (t *transferFlags) init(name string) {
+	t.flags = pflag.NewFlagSet(name, pflag.ExitOnError)
+	t.fControl = t.flags.String("control", "control.alpha.hzn.network", "address of control plane")
+	t.fToken = t.flags.String("token", "", "authentication token")
+	t.fCAFile = t.flags.String("ca-file", "", "PEM file of CA certificates used to verify hubs, rather than the system roots")
+	t.fVerbose = t.flags.CountP("verbose", "v", "increase verbosity of output")
+}
+
+func (t *transferFlags) logger() hclog.Logger {
+	level := hclog.Warn
+
+	switch *t.fVerbose {
+	case 1:
+		level = hclog.Info
+	case 2:
+		level = hclog.Debug
+	case 3:
+		level = hclog.Trace
+	}
+
+	return hclog.New(&hclog.LoggerOptions{
+		Name:  "hznagent",
+		Level: level,
+	})
+}
+
+// Creates an agent, letting setup add services before it connects to the
+// hubs.
+func (t *transferFlags) start(ctx context.Context, L hclog.Logger, setup func(g *agent.Agent) error) *agent.Agent {
+	roots := RootCAs(*t.fCAFile)
+
+	dc, err := discovery.NewClient(*t.fControl)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	dc.RootCAs = roots
+
+	err = dc.Refresh(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	g, err := agent.NewAgent(L.Named("agent"))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	g.Token = Token(t.fToken)
+	g.RootCAs = roots
+
+	if setup != nil {
+		err = setup(g)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	err = g.Start(ctx, dc)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return g
+}
+
+func transferLabels(nameplate string) *pb.LabelSet {
+	return pb.MakeLabels("type", "transfer", "transfer-id", nameplate)
+}
+
+// Prints the progress of each file on a single, updating line.
+type progressPrinter struct {
+	w    io.Writer
+	last time.Time
+}
+
+func (p *progressPrinter) update(f *transfer.File, done int64) {
+	now := time.Now()
+
+	if done != 0 && done < f.Size && now.Sub(p.last) < 250*time.Millisecond {
+		return
+	}
+
+	p.last = now
+
+	pct := int64(100)
+	if f.Size > 0 {
+		pct = done * 100 / f.Size
+	}
+
+	fmt.Fprintf(p.w, "\r%s  %s / %s  %3d%%", f.Name, formatBytes(done), formatBytes(f.Size), pct)
+
+	if done == f.Size {
+		fmt.Fprintln(p.w)
+	}
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// Adapts the service context of the first connection to the transfer. Any
+// further connections are rejected, since the code is only good for one
+// attempt.
+type sendHandler struct {
+	once   sync.Once
+	result chan error
+	run    func(rw io.ReadWriter) error
+}
+
+func (s *sendHandler) HandleRequest(ctx context.Context, L hclog.Logger, sctx agent.ServiceContext) error {
+	defer sctx.Close()
+
+	first := false
+	s.once.Do(func() { first = true })
+
+	if !first {
+		return fmt.Errorf("transfer already attempted")
+	}
+
+	w := sctx.Writer()
+	defer w.Close()
+
+	err := s.run(struct {
+		io.Reader
+		io.Writer
+	}{sctx.Reader(), w})
+
+	s.result <- err
+
+	return err
+}
+
+type sendRunner struct {
+	transferFlags
+}
+
+func (s *sendRunner) init() error {
+	s.transferFlags.init("send")
+	return nil
+}
+
+func (s *sendRunner) Help() string {
+	str := "horizon send [options] FILE...:\n"
+	str += s.flags.FlagUsagesWrapped(4)
+	return str
+}
+
+func (s *sendRunner) Synopsis() string {
+	return "send files, encrypted end to end, to a matching receive"
+}
+
+func (s *sendRunner) Run(args []string) int {
+	s.flags.Parse(args)
+
+	paths := s.flags.Args()
+	if len(paths) == 0 {
+		fmt.Fprintf(os.Stderr, "No files specified\n")
+		return 1
+	}
+
+	for _, path := range paths {
+		fi, err := os.Stat(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s\n", err)
+			return 1
+		}
+
+		if !fi.Mode().IsRegular() {
+			fmt.Fprintf(os.Stderr, "Not a regular file: %s\n", path)
+			return 1
+		}
+	}
+
+	L := s.logger()
+
+	code, err := transfer.GenerateCode()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	_, nameplate, _ := transfer.ParseCode(code)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	pp := &progressPrinter{w: os.Stderr}
+
+	sh := &sendHandler{
+		result: make(chan error, 1),
+		run: func(rw io.ReadWriter) error {
+			return transfer.Send(rw, code, paths, pp.update)
+		},
+	}
+
+	g := s.start(ctx, L, func(g *agent.Agent) error {
+		_, err := g.AddService(&agent.Service{
+			Type:    "transfer",
+			Labels:  transferLabels(nameplate),
+			Handler: sh,
+		})
+
+		return err
+	})
+
+	go g.Wait(ctx)
+
+	fmt.Fprintf(os.Stderr, "Transfer code: %s\n", code)
+	fmt.Fprintf(os.Stderr, "On the other machine, run: hznagent receive %s\n", code)
+
+	err = <-sh.result
+
+	shutdownCtx, done := context.WithTimeout(ctx, 5*time.Second)
+	defer done()
+
+	g.Shutdown(shutdownCtx)
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Transfer failed: %s\n", err)
+		return 1
+	}
+
+	fmt.Fprintf(os.Stderr, "Transfer complete\n")
+
+	return 0
+}
+
+type receiveRunner struct {
+	transferFlags
+	fDir *string
+}
+
+func (r *receiveRunner) init() error {
+	r.transferFlags.init("receive")
+	r.fDir = r.flags.String("dir", ".", "directory to write received files to")
+	return nil
+}
+
+func (r *receiveRunner) Help() string {
+	str := "horizon receive [options] CODE:\n"
+	str += r.flags.FlagUsagesWrapped(4)
+	return str
+}
+
+func (r *receiveRunner) Synopsis() string {
+	return "receive files sent with send"
+}
+
+func (r *receiveRunner) Run(args []string) int {
+	r.flags.Parse(args)
+
+	if r.flags.NArg() != 1 {
+		fmt.Fprintf(os.Stderr, "Expected a transfer code\n")
+		return 1
+	}
+
+	code, nameplate, err := transfer.ParseCode(r.flags.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		return 1
+	}
+
+	L := r.logger()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	g := r.start(ctx, L, nil)
+
+	go g.Wait(ctx)
+
+	rc, err := g.Connect(transferLabels(nameplate))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to find the sender: %s\n", err)
+		return 1
+	}
+
+	defer rc.Close()
+
+	pp := &progressPrinter{w: os.Stderr}
+
+	files, err := transfer.Receive(rc, code, *r.fDir, pp.update)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Transfer failed: %s\n", err)
+		return 1
+	}
+
+	fmt.Fprintf(os.Stderr, "Received %d files\n", len(files))
+
+	return 0
+}
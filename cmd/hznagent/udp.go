@@ -0,0 +1,119 @@
+package main
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/horizon/pkg/agent"
+	"github.com/hashicorp/horizon/pkg/pb"
+)
+
+// How long a UDP client can be quiet before it's session to the
+// service is closed.
+const udpIdleTimeout = 2 * time.Minute
+
+type udpSession struct {
+	dc       *agent.DatagramConn
+	lastSeen time.Time
+}
+
+// Runs a UDP listener on pc. Each remote address gets it's own connection to
+// the service so that replies can be routed back to the right client.
+func proxyUDP(L hclog.Logger, g *agent.Agent, pc net.PacketConn, labels *pb.LabelSet) error {
+	var (
+		mu       sync.Mutex
+		sessions = map[string]*udpSession{}
+	)
+
+	closeSession := func(key string, sess *udpSession) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if sessions[key] == sess {
+			delete(sessions, key)
+		}
+
+		sess.dc.Close()
+	}
+
+	go func() {
+		ticker := time.NewTicker(udpIdleTimeout / 4)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			var idle []*udpSession
+
+			mu.Lock()
+			for key, sess := range sessions {
+				if time.Since(sess.lastSeen) > udpIdleTimeout {
+					delete(sessions, key)
+					idle = append(idle, sess)
+				}
+			}
+			mu.Unlock()
+
+			for _, sess := range idle {
+				sess.dc.Close()
+			}
+		}
+	}()
+
+	buf := make([]byte, 64*1024)
+
+	for {
+		n, addr, err := pc.ReadFrom(buf)
+		if err != nil {
+			return err
+		}
+
+		key := addr.String()
+
+		mu.Lock()
+		sess, ok := sessions[key]
+		if ok {
+			sess.lastSeen = time.Now()
+		}
+		mu.Unlock()
+
+		if !ok {
+			dc, err := g.ConnectUDP(labels)
+			if err != nil {
+				L.Error("error connecting to service", "error", err)
+				continue
+			}
+
+			L.Debug("new udp client", "addr", key)
+
+			sess = &udpSession{dc: dc, lastSeen: time.Now()}
+
+			mu.Lock()
+			sessions[key] = sess
+			mu.Unlock()
+
+			go func() {
+				defer closeSession(key, sess)
+
+				for {
+					data, err := sess.dc.Recv()
+					if err != nil {
+						return
+					}
+
+					_, err = pc.WriteTo(data, addr)
+					if err != nil {
+						L.Error("error writing datagram to client", "error", err, "addr", key)
+						return
+					}
+				}
+			}()
+		}
+
+		err = sess.dc.Send(buf[:n])
+		if err != nil {
+			L.Error("error sending datagram to service", "error", err, "addr", key)
+			closeSession(key, sess)
+		}
+	}
+}
@@ -45,6 +45,12 @@ func main() {
 		"create-agent-token": func() (cli.Command, error) {
 			return &agentTokenCreate{}, nil
 		},
+		"allocate-tcp-port": func() (cli.Command, error) {
+			return &tcpPortAllocate{}, nil
+		},
+		"set-account-quotas": func() (cli.Command, error) {
+			return &quotasSet{}, nil
+		},
 	}
 
 	exitStatus, err := c.Run()
@@ -260,6 +266,164 @@ func (h *llCreate) Run(args []string) int {
 	return 0
 }
 
+type tcpPortAllocate struct{}
+
+func (h *tcpPortAllocate) Help() string {
+	return "Allocate a public tcp port for an account"
+}
+
+func (h *tcpPortAllocate) Synopsis() string {
+	return "Allocate a public tcp port for an account"
+}
+
+func (h *tcpPortAllocate) Run(args []string) int {
+	fs := pflag.NewFlagSet("hznctl", pflag.ExitOnError)
+
+	addr := fs.String("control-addr", "127.0.0.1:24001", "Address of control server")
+	insecure := fs.Bool("insecure", false, "Whether or not to secure the grpc connection")
+	token := fs.String("token", "", "Token to authenticate with control server")
+	acc := fs.String("account", "", "account for the port")
+	namespace := fs.String("namespace", "/waypoint", "namespace to assign to this managament client")
+	tLabel := fs.String("target", "", "target label")
+
+	err := fs.Parse(args)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *acc == "" || *tLabel == "" {
+		log.Fatalln("target and account must be provided")
+	}
+
+	opts := []grpc.DialOption{
+		grpc.WithPerRPCCredentials(grpctoken.Token(*token)),
+		grpc.WithDefaultCallOptions(grpc.UseCompressor(lz4.Name)),
+	}
+
+	if *insecure {
+		opts = append(opts, grpc.WithInsecure())
+	} else {
+		creds := credentials.NewTLS(&tls.Config{
+			InsecureSkipVerify: true,
+		})
+
+		opts = append(opts, grpc.WithTransportCredentials(creds))
+	}
+
+	gcc, err := grpc.Dial(*addr, opts...)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	s := pb.NewControlManagementClient(gcc)
+
+	accId, err := pb.ParseULID(*acc)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	tls := pb.ParseLabelSet(*tLabel)
+
+	resp, err := s.AllocateTCPPort(ctx, &pb.AllocateTCPPortRequest{
+		Account: &pb.Account{
+			AccountId: accId,
+			Namespace: *namespace,
+		},
+		Target: tls,
+	})
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("Allocated port %d => %s::%s\n", resp.Port, accId, tls)
+
+	return 0
+}
+
+type quotasSet struct{}
+
+func (h *quotasSet) Help() string {
+	return "Set the quotas of an account on each hub"
+}
+
+func (h *quotasSet) Synopsis() string {
+	return "Set the quotas of an account on each hub"
+}
+
+func (h *quotasSet) Run(args []string) int {
+	fs := pflag.NewFlagSet("hznctl", pflag.ExitOnError)
+
+	addr := fs.String("control-addr", "127.0.0.1:24001", "Address of control server")
+	insecure := fs.Bool("insecure", false, "Whether or not to secure the grpc connection")
+	token := fs.String("token", "", "Token to authenticate with control server")
+	acc := fs.String("account", "", "account to set the quotas of")
+	namespace := fs.String("namespace", "/waypoint", "namespace to assign to this managament client")
+	agents := fs.Int64("agents", 0, "concurrent agent sessions, 0 for no limit")
+	services := fs.Int64("services", 0, "advertised services, 0 for no limit")
+	streams := fs.Int64("streams", 0, "concurrent streams, 0 for no limit")
+
+	err := fs.Parse(args)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *acc == "" {
+		log.Fatalln("account must be provided")
+	}
+
+	opts := []grpc.DialOption{
+		grpc.WithPerRPCCredentials(grpctoken.Token(*token)),
+		grpc.WithDefaultCallOptions(grpc.UseCompressor(lz4.Name)),
+	}
+
+	if *insecure {
+		opts = append(opts, grpc.WithInsecure())
+	} else {
+		creds := credentials.NewTLS(&tls.Config{
+			InsecureSkipVerify: true,
+		})
+
+		opts = append(opts, grpc.WithTransportCredentials(creds))
+	}
+
+	gcc, err := grpc.Dial(*addr, opts...)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	s := pb.NewControlManagementClient(gcc)
+
+	accId, err := pb.ParseULID(*acc)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	_, err = s.SetAccountQuotas(ctx, &pb.AccountQuotas{
+		Account: &pb.Account{
+			AccountId: accId,
+			Namespace: *namespace,
+		},
+		Agents:   *agents,
+		Services: *services,
+		Streams:  *streams,
+	})
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("Set quotas of %s: agents=%d services=%d streams=%d\n", accId, *agents, *services, *streams)
+
+	return 0
+}
+
 type agentTokenCreate struct{}
 
 func (h *agentTokenCreate) Help() string {
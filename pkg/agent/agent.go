@@ -8,11 +8,15 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	mrand "math/rand"
 	"net"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/armon/go-metrics"
 	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/go-multierror"
 	"github.com/hashicorp/horizon/pkg/discovery"
 	"github.com/hashicorp/horizon/pkg/pb"
 	"github.com/hashicorp/horizon/pkg/wire"
@@ -107,6 +111,32 @@ type Service struct {
 
 	// The handler to invoke when the service is called.
 	Handler ServiceHandler
+
+	stats *serviceStats
+
+	// An optional check of the service's local backend. The service isn't
+	// advertised until the check first passes, and while the check is
+	// failing, the service is withdrawn from all hubs.
+	HealthCheck HealthChecker
+
+	// How often to run HealthCheck. Defaults to DefaultHealthInterval.
+	HealthInterval time.Duration
+
+	// The most streams Handler handles at once. Zero means no limit.
+	MaxConcurrent int
+
+	// Once MaxConcurrent is reached, up to MaxQueue streams wait for up to
+	// QueueTimeout (DefaultQueueTimeout if not set) for one of the running
+	// streams to finish. Streams beyond that are refused.
+	MaxQueue     int
+	QueueTimeout time.Duration
+
+	limit *limiter
+
+	// Held while deciding on and sending an update about the service to the
+	// hubs, so that updates reach them in the order they were decided.
+	// Always taken before Agent.mu.
+	updates sync.Mutex
 }
 
 type Agent struct {
@@ -123,20 +153,75 @@ type Agent struct {
 	// is not set, the defaults are used.
 	RootCAs *x509.CertPool
 
+	// Records requests to the HTTP services created by SyncServices that
+	// have inspection enabled.
+	Inspector *Inspector
+
+	// How many hub sessions to keep open. Defaults to DefaultTargetSessions.
+	TargetSessions int
+
+	// How hubs that can't be connected to are retried.
+	Backoff Backoff
+
 	mu         sync.RWMutex
 	services   map[string]*Service
 	sessions   []*yamux.Session
 	activeHubs map[string]discovery.HubConfig
 	hcp        discovery.HubConfigProvider
 
+	// The context passed to Start, used to run health checks.
+	runCtx context.Context
+	checks map[string]context.CancelFunc
+	health map[string]*HealthStatus
+
+	// Maps service configurations passed to SyncServices to the ids of the
+	// services created for them.
+	configured map[string]*pb.ULID
+
+	// Used to rotate through sessions when opening new streams.
+	nextSession *uint64
+
+	// The number of handleStream calls currently running.
+	activeStreams *int64
+
+	// Totals across all streams handled, for the status API.
+	totalStreams *int64
+	streamErrors *int64
+
+	// Details about each connected hub, for the status API.
+	hubs map[*yamux.Session]*hubSession
+
+	// The backoff state of hubs that have failed or disconnected, by address.
+	retries map[string]*hubRetry
+	rng     *mrand.Rand
+
+	// Set by Shutdown. No new hub sessions are made while draining and
+	// shutdown is closed once the drain is complete.
+	draining bool
+	shutdown chan struct{}
+
 	statuses chan hubStatus
 	active   int
 }
 
+type hubSession struct {
+	cfg         discovery.HubConfig
+	connectedAt time.Time
+	latency     time.Duration
+	skew        time.Duration
+
+	// Set when the hub sends a GOAWAY, to when it will close the session.
+	closingAt time.Time
+}
+
 type hubStatus struct {
 	cfg       discovery.HubConfig
 	connected bool
 	err       error
+
+	// Set when a session ends after a GOAWAY, which already started a
+	// replacement session.
+	replaced bool
 }
 
 func NewAgent(L hclog.Logger) (*Agent, error) {
@@ -149,11 +234,22 @@ func NewAgent(L hclog.Logger) (*Agent, error) {
 	cfg.LogOutput = nil
 
 	agent := &Agent{
-		L:          L,
-		cfg:        cfg,
-		services:   make(map[string]*Service),
-		statuses:   make(chan hubStatus),
-		activeHubs: make(map[string]discovery.HubConfig),
+		L:           L,
+		cfg:         cfg,
+		services:    make(map[string]*Service),
+		statuses:    make(chan hubStatus),
+		activeHubs:  make(map[string]discovery.HubConfig),
+		checks:      make(map[string]context.CancelFunc),
+		health:      make(map[string]*HealthStatus),
+		nextSession: new(uint64),
+
+		activeStreams: new(int64),
+		totalStreams:  new(int64),
+		streamErrors:  new(int64),
+		hubs:          make(map[*yamux.Session]*hubSession),
+		retries:       make(map[string]*hubRetry),
+		rng:           mrand.New(mrand.NewSource(time.Now().UnixNano())),
+		shutdown:      make(chan struct{}),
 	}
 
 	return agent, nil
@@ -161,16 +257,184 @@ func NewAgent(L hclog.Logger) (*Agent, error) {
 
 var mread = ulid.Monotonic(rand.Reader, 1)
 
+func (s *Service) info() *pb.ServiceInfo {
+	var md []*pb.KVPair
+
+	for k, v := range s.Metadata {
+		md = append(md, &pb.KVPair{Key: k, Value: v})
+	}
+
+	return &pb.ServiceInfo{
+		ServiceId: s.Id,
+		Type:      s.Type,
+		Metadata:  md,
+		Labels:    s.Labels,
+	}
+}
+
+// AddService registers serv with the agent. If the agent is already connected
+// to hubs, the service is advertised to each of them immediately, or once
+// it's health check first passes if it has one.
 func (a *Agent) AddService(serv *Service) (*pb.ULID, error) {
+	serv.updates.Lock()
+	defer serv.updates.Unlock()
+
 	a.mu.Lock()
-	defer a.mu.Unlock()
 
 	serv.Id = pb.NewULID()
+	serv.stats = &serviceStats{}
+	serv.limit = newLimiter(serv)
 
 	a.services[serv.Id.SpecString()] = serv
+
+	// A service with a health check starts out unhealthy and is advertised
+	// once it's first check passes, so hubs don't route to a backend that
+	// isn't up yet.
+	if serv.HealthCheck != nil {
+		a.health[serv.Id.SpecString()] = &HealthStatus{}
+		a.startHealthCheck(serv)
+
+		a.mu.Unlock()
+
+		return serv.Id, nil
+	}
+
+	sessions := a.liveSessions()
+
+	a.mu.Unlock()
+
+	err := a.updateHubs(sessions, wire.TagServiceAdd, serv.info())
+	if err != nil {
+		return serv.Id, err
+	}
+
 	return serv.Id, nil
 }
 
+var ErrUnknownService = errors.New("unknown service")
+
+// RemoveService stops advertising the service with the given id on all
+// connected hubs and removes it from the agent.
+func (a *Agent) RemoveService(id *pb.ULID) error {
+	a.mu.RLock()
+	serv, ok := a.services[id.SpecString()]
+	a.mu.RUnlock()
+
+	if !ok {
+		return ErrUnknownService
+	}
+
+	serv.updates.Lock()
+	defer serv.updates.Unlock()
+
+	a.mu.Lock()
+
+	// Check again in case the service was removed while we waited.
+	if a.services[id.SpecString()] != serv {
+		a.mu.Unlock()
+		return ErrUnknownService
+	}
+
+	delete(a.services, id.SpecString())
+
+	// An unhealthy service has already been withdrawn from the hubs.
+	advertised := a.isAdvertised(id.SpecString())
+
+	a.stopHealthCheck(id.SpecString())
+
+	sessions := a.liveSessions()
+
+	a.mu.Unlock()
+
+	if !advertised {
+		return nil
+	}
+
+	return a.updateHubs(sessions, wire.TagServiceRemove, serv.info())
+}
+
+// Updates a single hub session that was sent the service in it's preamble,
+// or not, to match whether the service should be advertised now.
+func (a *Agent) syncService(session *yamux.Session, serv *Service, sent bool) {
+	serv.updates.Lock()
+	defer serv.updates.Unlock()
+
+	key := serv.Id.SpecString()
+
+	a.mu.RLock()
+	_, ok := a.services[key]
+	want := ok && a.isAdvertised(key)
+	a.mu.RUnlock()
+
+	switch {
+	case want && !sent:
+		a.updateHubs([]*yamux.Session{session}, wire.TagServiceAdd, serv.info())
+	case !want && sent:
+		a.updateHubs([]*yamux.Session{session}, wire.TagServiceRemove, serv.info())
+	}
+}
+
+// Returns a copy of the current hub sessions. Must be called with a.mu held.
+func (a *Agent) liveSessions() []*yamux.Session {
+	return append([]*yamux.Session(nil), a.sessions...)
+}
+
+// Send a service update to each session, returning any errors that occur.
+func (a *Agent) updateHubs(sessions []*yamux.Session, tag byte, info *pb.ServiceInfo) error {
+	var err error
+
+	for _, sess := range sessions {
+		serr := a.sendServiceUpdate(sess, tag, info)
+		if serr != nil {
+			a.L.Error("error updating service on hub", "error", serr, "service", info.ServiceId, "tag", tag)
+			err = multierror.Append(err, serr)
+		}
+	}
+
+	return err
+}
+
+func (a *Agent) sendServiceUpdate(session *yamux.Session, tag byte, info *pb.ServiceInfo) error {
+	stream, err := session.OpenStream()
+	if err != nil {
+		return err
+	}
+
+	defer stream.Close()
+
+	fw, err := wire.NewFramingWriter(lz4.NewWriter(stream))
+	if err != nil {
+		return err
+	}
+
+	defer fw.Recycle()
+
+	fr, err := wire.NewFramingReader(lz4.NewReader(stream))
+	if err != nil {
+		return err
+	}
+
+	defer fr.Recycle()
+
+	_, err = fw.WriteMarshal(tag, info)
+	if err != nil {
+		return err
+	}
+
+	var resp pb.Response
+
+	rtag, _, err := fr.ReadMarshal(&resp)
+	if err != nil {
+		return err
+	}
+
+	if rtag != 1 {
+		return ErrProtocolError
+	}
+
+	return nil
+}
+
 func (a *Agent) Run(ctx context.Context, hcp discovery.HubConfigProvider) error {
 	err := a.Start(ctx, hcp)
 	if err != nil {
@@ -183,10 +447,23 @@ func (a *Agent) Run(ctx context.Context, hcp discovery.HubConfigProvider) error
 func (a *Agent) Start(ctx context.Context, hcp discovery.HubConfigProvider) error {
 	a.hcp = hcp
 
-	for i := 0; i < 5; i++ {
+	a.mu.Lock()
+	a.runCtx = ctx
+	for _, serv := range a.services {
+		a.startHealthCheck(serv)
+	}
+	a.mu.Unlock()
+
+	for i := 0; i < a.targetSessions(); i++ {
 		cfg, ok := hcp.Take(ctx)
 		if ok {
 			go a.connectToHub(ctx, cfg, a.statuses)
+		} else {
+			a.mu.Lock()
+			delay := a.jitter(a.Backoff.max())
+			a.mu.Unlock()
+
+			a.scheduleConnect(ctx, delay)
 		}
 	}
 
@@ -209,35 +486,38 @@ func (a *Agent) processStatus(ctx context.Context) (bool, error) {
 	select {
 	case <-ctx.Done():
 		return false, ctx.Err()
+	case <-a.shutdown:
+		return false, ErrShutdown
 	case stat := <-a.statuses:
-		if !stat.connected {
-			a.active--
-			a.L.Warn("disconnected from hub", "error", stat.err, "addr", stat.cfg.Addr)
-
-			a.hcp.Return(stat.cfg)
-
-			newcfg, ok := a.hcp.Take(ctx)
-			if ok {
-				// If we returned the config and got the same one, don't spam
-				// it.
-				if newcfg.Addr == stat.cfg.Addr {
-					a.L.Debug("delaying before reconnecting to same hub", "addr", stat.cfg.Addr)
-					time.AfterFunc(10*time.Second, func() {
-						go a.connectToHub(ctx, newcfg, a.statuses)
-					})
-				} else {
-					a.L.Debug("connecting to hub", "addr", newcfg.Addr)
-					go a.connectToHub(ctx, newcfg, a.statuses)
-				}
-			} else {
-				a.L.Warn("hub config provider failed to return new config")
-			}
-			return false, nil
-		} else {
+		if stat.connected {
 			a.active++
 			a.L.Debug("connected to hub", "addr", stat.cfg.Addr)
+			a.hubConnected(stat.cfg)
 			return true, nil
 		}
+
+		if stat.err == nil {
+			a.active--
+			a.L.Warn("disconnected from hub", "addr", stat.cfg.Addr)
+		} else {
+			a.L.Warn("unable to connect to hub", "error", stat.err, "addr", stat.cfg.Addr)
+		}
+
+		a.releaseHub(stat.cfg, stat.err)
+
+		if a.isDraining() || stat.replaced {
+			return false, nil
+		}
+
+		// Take the next hub after a short random delay, so that agents
+		// disconnected by the same hub outage spread out their reconnects.
+		a.mu.Lock()
+		delay := a.jitter(a.Backoff.min())
+		a.mu.Unlock()
+
+		a.scheduleConnect(ctx, delay)
+
+		return false, nil
 	}
 }
 
@@ -257,10 +537,10 @@ func (a *Agent) Wait(ctx context.Context) error {
 		case <-timer.C:
 			return nil
 		case stat := <-a.statuses:
-			if !stat.connected {
-				a.active--
-			} else {
+			if stat.connected {
 				a.active++
+			} else if stat.err == nil {
+				a.active--
 			}
 		}
 	}
@@ -287,12 +567,14 @@ func (a *Agent) connectToHub(ctx context.Context, hub discovery.HubConfig, statu
 
 	conn, err := tls.Dial("tcp", hub.Addr, &clientTlsConfig)
 	if err != nil {
+		metrics.IncrCounterWithLabels([]string{"hub", "connect_errors"}, 1, hubLabels(hub))
 		status <- hubStatus{cfg: hub, err: err}
 		return
 	}
 
 	err = a.Nego(ctx, a.L, conn, hub, status)
 	if err != nil {
+		metrics.IncrCounterWithLabels([]string{"hub", "connect_errors"}, 1, hubLabels(hub))
 		status <- hubStatus{cfg: hub, err: err}
 		return
 	}
@@ -323,20 +605,18 @@ func (a *Agent) Nego(ctx context.Context, L hclog.Logger, conn net.Conn, hubCfg
 	preamble.Labels = a.Labels
 	preamble.Compression = "lz4"
 
-	for _, serv := range a.services {
-		var md []*pb.KVPair
+	advertised := map[string]struct{}{}
 
-		for k, v := range serv.Metadata {
-			md = append(md, &pb.KVPair{Key: k, Value: v})
+	a.mu.RLock()
+	for key, serv := range a.services {
+		if !a.isAdvertised(key) {
+			continue
 		}
 
-		preamble.Services = append(preamble.Services, &pb.ServiceInfo{
-			ServiceId: serv.Id,
-			Type:      serv.Type,
-			Metadata:  md,
-			Labels:    serv.Labels,
-		})
+		advertised[key] = struct{}{}
+		preamble.Services = append(preamble.Services, serv.info())
 	}
+	a.mu.RUnlock()
 
 	_, err = fw.WriteMarshal(1, &preamble)
 	if err != nil {
@@ -383,7 +663,53 @@ func (a *Agent) Nego(ctx context.Context, L hclog.Logger, conn net.Conn, hubCfg
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
+	if a.draining {
+		session.Close()
+		return ErrShutdown
+	}
+
 	a.sessions = append(a.sessions, session)
+	a.hubs[session] = &hubSession{
+		cfg:         hubCfg,
+		connectedAt: time.Now(),
+		latency:     latency,
+		skew:        skew,
+	}
+
+	a.hubConnectedMetrics(hubCfg, latency, skew)
+
+	// Services that were added, removed or changed health while we were
+	// negotiating weren't captured in the preamble, so bring the hub up to
+	// date with them now.
+	var (
+		changed []*Service
+		removes []*pb.ServiceInfo
+	)
+
+	for key, serv := range a.services {
+		if _, ok := advertised[key]; ok != a.isAdvertised(key) {
+			changed = append(changed, serv)
+		}
+	}
+
+	for _, info := range preamble.Services {
+		if _, ok := a.services[info.ServiceId.SpecString()]; !ok {
+			removes = append(removes, info)
+		}
+	}
+
+	if len(changed) > 0 || len(removes) > 0 {
+		go func() {
+			for _, serv := range changed {
+				_, sent := advertised[serv.Id.SpecString()]
+				a.syncService(session, serv, sent)
+			}
+
+			for _, info := range removes {
+				a.updateHubs([]*yamux.Session{session}, wire.TagServiceRemove, info)
+			}
+		}()
+	}
 
 	L.Debug("connected successfully", "status", wc.Status, "latency", latency, "skew", skew)
 
@@ -395,18 +721,21 @@ func (a *Agent) Nego(ctx context.Context, L hclog.Logger, conn net.Conn, hubCfg
 func (a *Agent) watchSession(ctx context.Context, L hclog.Logger, session *yamux.Session, fr *wire.FramingReader, hubCfg discovery.HubConfig, status chan hubStatus, useLZ4 bool) {
 	defer fr.Recycle()
 	defer func() {
-		status <- hubStatus{
-			cfg: hubCfg,
-		}
-
 		a.mu.Lock()
-		defer a.mu.Unlock()
 
-		for i, sess := range a.sessions {
-			if sess == session {
-				a.sessions = append(a.sessions[:i], a.sessions[i+1:]...)
-				return
-			}
+		hs, ok := a.hubs[session]
+		replaced := ok && !hs.closingAt.IsZero()
+
+		delete(a.hubs, session)
+		a.removeSession(session)
+
+		a.hubDisconnectedMetrics(hubCfg)
+
+		a.mu.Unlock()
+
+		status <- hubStatus{
+			cfg:      hubCfg,
+			replaced: replaced,
 		}
 	}()
 
@@ -441,16 +770,24 @@ func (a *Agent) watchSession(ctx context.Context, L hclog.Logger, session *yamux
 func (a *Agent) handleStream(ctx context.Context, L hclog.Logger, session *yamux.Session, stream *yamux.Stream, useLZ4 bool) {
 	defer stream.Close()
 
+	atomic.AddInt64(a.activeStreams, 1)
+	defer atomic.AddInt64(a.activeStreams, -1)
+
+	atomic.AddInt64(a.totalStreams, 1)
+
 	L.Trace("stream accepted", "id", stream.StreamID(), "lz4", useLZ4)
 
 	var (
-		r io.Reader = stream
-		w io.Writer = stream
+		cr = &countingReader{Reader: stream}
+		cw = &countingWriter{Writer: stream}
+
+		r io.Reader = cr
+		w io.Writer = cw
 	)
 
 	if useLZ4 {
-		r = lz4.NewReader(stream)
-		w = lz4.NewWriter(stream)
+		r = lz4.NewReader(cr)
+		w = lz4.NewWriter(cw)
 	}
 
 	fr, err := wire.NewFramingReader(r)
@@ -469,19 +806,35 @@ func (a *Agent) handleStream(ctx context.Context, L hclog.Logger, session *yamux
 
 	defer fw.Recycle()
 
-	var req pb.SessionIdentification
+	// The first message decides what the stream is for, so read it raw and
+	// decode it once we know the tag.
+	var msg wire.MarshalBytes
 
-	tag, _, err := fr.ReadMarshal(&req)
+	tag, _, err := fr.ReadMarshal(&msg)
 	if err != nil {
 		L.Error("error decoding request", "error", err)
 		return
 	}
 
-	if tag != 11 {
+	switch tag {
+	case 11:
+		// session identification, handled below
+	case wire.TagGoAway:
+		a.handleGoAway(ctx, L, session, msg)
+		return
+	default:
 		L.Error("incorrect message tag", "tag", tag)
 		return
 	}
 
+	var req pb.SessionIdentification
+
+	err = req.Unmarshal(msg)
+	if err != nil {
+		L.Error("error decoding request", "error", err)
+		return
+	}
+
 	targetService := req.ServiceId.SpecString()
 
 	a.mu.RLock()
@@ -492,6 +845,8 @@ func (a *Agent) handleStream(ctx context.Context, L hclog.Logger, session *yamux
 
 	if !ok {
 		L.Error("request received for unknown service", "service", targetService)
+		atomic.AddInt64(a.streamErrors, 1)
+		metrics.IncrCounter([]string{"streams", "unknown_service"}, 1)
 
 		var resp pb.Response
 		resp.Error = fmt.Sprintf("unknown service: %s", targetService)
@@ -512,8 +867,42 @@ func (a *Agent) handleStream(ctx context.Context, L hclog.Logger, session *yamux
 		stream:     w,
 	}
 
+	labels := serv.metricLabels()
+
+	if serv.limit != nil {
+		err = serv.limit.acquire(ctx)
+		if err != nil {
+			L.Warn("refusing stream", "service", targetService, "error", err)
+			metrics.IncrCounterWithLabels([]string{"service", "streams", "refused"}, 1, labels)
+
+			err = refuseStream(sctx, err)
+			if err != nil {
+				L.Error("error refusing stream", "error", err)
+			}
+
+			return
+		}
+
+		defer serv.limit.release()
+	}
+
+	serv.stats.begin()
+	serv.stats.emitActive(labels)
+	metrics.IncrCounterWithLabels([]string{"service", "streams", "total"}, 1, labels)
+
+	defer func() {
+		serv.stats.end()
+		serv.stats.emitActive(labels)
+
+		metrics.IncrCounterWithLabels([]string{"service", "bytes_in"}, float32(atomic.LoadInt64(&cr.n)), labels)
+		metrics.IncrCounterWithLabels([]string{"service", "bytes_out"}, float32(atomic.LoadInt64(&cw.n)), labels)
+	}()
+
 	err = serv.Handler.HandleRequest(ctx, L, sctx)
 	if err != nil {
 		L.Error("error in service handler", "error", err)
+		atomic.AddInt64(a.streamErrors, 1)
+		serv.stats.failed()
+		metrics.IncrCounterWithLabels([]string{"service", "errors"}, 1, labels)
 	}
 }
@@ -3,6 +3,8 @@ package agent
 import (
 	"context"
 	"crypto/tls"
+	"io"
+	"net"
 	"testing"
 	"time"
 
@@ -15,6 +17,7 @@ import (
 	"github.com/hashicorp/horizon/pkg/testutils/central"
 	"github.com/hashicorp/horizon/pkg/wire"
 	"github.com/hashicorp/yamux"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -161,4 +164,333 @@ func TestAgent(t *testing.T) {
 			assert.Equal(t, []byte("hello hzn"), []byte(mb2))
 		})
 	})
+
+	t.Run("preserves datagram boundaries for udp services", func(t *testing.T) {
+		central.Dev(t, func(setup *central.DevSetup) {
+			L := hclog.New(&hclog.LoggerOptions{
+				Name:  "dev",
+				Level: hclog.Trace,
+			})
+
+			h, err := hub.NewHub(L.Named("hub"), setup.ControlClient, setup.HubServToken)
+			require.NoError(t, err)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			go h.Run(ctx, setup.ClientListener)
+
+			time.Sleep(time.Second)
+
+			pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+			require.NoError(t, err)
+
+			defer pc.Close()
+
+			go func() {
+				buf := make([]byte, 1024)
+
+				for {
+					n, addr, err := pc.ReadFrom(buf)
+					if err != nil {
+						return
+					}
+
+					pc.WriteTo(buf[:n], addr)
+				}
+			}()
+
+			agent, err := NewAgent(L.Named("agent"))
+			require.NoError(t, err)
+
+			agent.Token = setup.AgentToken
+
+			_, err = agent.AddService(&Service{
+				Type:    "udp",
+				Labels:  pb.ParseLabelSet("env=test,service=dns"),
+				Handler: UDPHandler(pc.LocalAddr().String()),
+			})
+
+			require.NoError(t, err)
+
+			err = agent.Start(ctx, discovery.HubConfigs(discovery.HubConfig{
+				Addr:     setup.HubAddr,
+				Insecure: true,
+			}))
+			require.NoError(t, err)
+
+			go agent.Wait(ctx)
+
+			time.Sleep(time.Second)
+
+			dc, err := agent.ConnectUDP(pb.ParseLabelSet("service=dns"))
+			require.NoError(t, err)
+
+			defer dc.Close()
+
+			require.NoError(t, dc.Send([]byte("one")))
+			require.NoError(t, dc.Send([]byte("two")))
+
+			data, err := dc.Recv()
+			require.NoError(t, err)
+
+			assert.Equal(t, "one", string(data))
+
+			data, err = dc.Recv()
+			require.NoError(t, err)
+
+			assert.Equal(t, "two", string(data))
+
+			msgs, _ := dc.Accounting()
+			assert.Equal(t, int64(2), msgs)
+		})
+	})
+
+	t.Run("can add and remove services on a live session", func(t *testing.T) {
+		central.Dev(t, func(setup *central.DevSetup) {
+			L := hclog.New(&hclog.LoggerOptions{
+				Name:  "dev",
+				Level: hclog.Trace,
+			})
+
+			h, err := hub.NewHub(L.Named("hub"), setup.ControlClient, setup.HubServToken)
+			require.NoError(t, err)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			go h.Run(ctx, setup.ClientListener)
+
+			time.Sleep(time.Second)
+
+			agent, err := NewAgent(L.Named("agent"))
+			require.NoError(t, err)
+
+			agent.Token = setup.AgentToken
+
+			err = agent.Start(ctx, discovery.HubConfigs(discovery.HubConfig{
+				Addr:     setup.HubAddr,
+				Insecure: true,
+			}))
+			require.NoError(t, err)
+
+			go agent.Wait(ctx)
+
+			serviceId, err := agent.AddService(&Service{
+				Type:    "test",
+				Labels:  pb.ParseLabelSet("env=test,service=echo"),
+				Handler: EchoHandler(),
+			})
+
+			require.NoError(t, err)
+
+			var so control.Service
+			err = dbx.Check(setup.DB.First(&so))
+			require.NoError(t, err)
+
+			assert.Equal(t, serviceId.Bytes(), so.ServiceId)
+
+			conn, err := agent.Connect(pb.ParseLabelSet("service=echo"))
+			require.NoError(t, err)
+
+			conn.Close()
+
+			err = agent.RemoveService(serviceId)
+			require.NoError(t, err)
+
+			var count int
+			err = dbx.Check(setup.DB.Model(&control.Service{}).Count(&count))
+			require.NoError(t, err)
+
+			assert.Equal(t, 0, count)
+		})
+	})
+
+	t.Run("can query and connect to peer services", func(t *testing.T) {
+		central.Dev(t, func(setup *central.DevSetup) {
+			L := hclog.New(&hclog.LoggerOptions{
+				Name:  "dev",
+				Level: hclog.Trace,
+			})
+
+			h, err := hub.NewHub(L.Named("hub"), setup.ControlClient, setup.HubServToken)
+			require.NoError(t, err)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			go h.Run(ctx, setup.ClientListener)
+
+			time.Sleep(time.Second)
+
+			agent, err := NewAgent(L.Named("agent"))
+			require.NoError(t, err)
+
+			agent.Token = setup.AgentToken
+
+			serviceId, err := agent.AddService(&Service{
+				Type:     "test",
+				Labels:   pb.ParseLabelSet("env=test,service=echo"),
+				Metadata: map[string]string{"version": "1"},
+				Handler:  EchoHandler(),
+			})
+			require.NoError(t, err)
+
+			_, err = agent.AddService(&Service{
+				Type:    "test",
+				Labels:  pb.ParseLabelSet("env=test,service=other"),
+				Handler: EchoHandler(),
+			})
+			require.NoError(t, err)
+
+			err = agent.Start(ctx, discovery.HubConfigs(discovery.HubConfig{
+				Addr:     setup.HubAddr,
+				Insecure: true,
+			}))
+			require.NoError(t, err)
+
+			go agent.Wait(ctx)
+
+			services, err := agent.QueryPeerService(pb.ParseLabelSet("env=test"), "")
+			require.NoError(t, err)
+
+			assert.Equal(t, 2, len(services))
+
+			services, err = agent.QueryPeerService(pb.ParseLabelSet("service=echo"), "test")
+			require.NoError(t, err)
+
+			require.Equal(t, 1, len(services))
+
+			serv := services[0]
+			assert.Equal(t, serviceId, serv.Id)
+			assert.Equal(t, "test", serv.Type)
+			assert.Equal(t, "env=test, service=echo", serv.Labels.String())
+
+			require.Equal(t, 1, len(serv.Metadata))
+			assert.Equal(t, "version", serv.Metadata[0].Key)
+			assert.Equal(t, "1", serv.Metadata[0].Value)
+
+			services, err = agent.QueryPeerService(pb.ParseLabelSet("service=echo"), "http")
+			require.NoError(t, err)
+
+			assert.Equal(t, 0, len(services))
+
+			conn, err := agent.ConnectToPeer(serv)
+			require.NoError(t, err)
+
+			defer conn.Close()
+
+			_, err = conn.Write([]byte("hello hzn"))
+			require.NoError(t, err)
+
+			buf := make([]byte, 9)
+
+			_, err = io.ReadFull(conn, buf)
+			require.NoError(t, err)
+
+			assert.Equal(t, "hello hzn", string(buf))
+
+			_, err = agent.ConnectToPeer(&pb.Service{Id: pb.NewULID()})
+			require.Error(t, err)
+
+			assert.True(t, errors.Is(err, wire.ErrRemoteError))
+			assert.Contains(t, err.Error(), "no such service")
+		})
+	})
+
+	t.Run("returns an error when connecting without hub sessions", func(t *testing.T) {
+		agent, err := NewAgent(hclog.NewNullLogger())
+		require.NoError(t, err)
+
+		_, err = agent.Connect(pb.ParseLabelSet("service=echo"))
+		assert.Equal(t, ErrNoHubSessions, err)
+
+		_, err = agent.RPCClient()
+		assert.Equal(t, ErrNoHubSessions, err)
+	})
+
+	t.Run("drains active streams when shutting down", func(t *testing.T) {
+		central.Dev(t, func(setup *central.DevSetup) {
+			L := hclog.New(&hclog.LoggerOptions{
+				Name:  "dev",
+				Level: hclog.Trace,
+			})
+
+			h, err := hub.NewHub(L.Named("hub"), setup.ControlClient, setup.HubServToken)
+			require.NoError(t, err)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			go h.Run(ctx, setup.ClientListener)
+
+			time.Sleep(time.Second)
+
+			agent, err := NewAgent(L.Named("agent"))
+			require.NoError(t, err)
+
+			agent.Token = setup.AgentToken
+
+			_, err = agent.AddService(&Service{
+				Type:    "test",
+				Labels:  pb.ParseLabelSet("env=test,service=echo"),
+				Handler: EchoHandler(),
+			})
+
+			require.NoError(t, err)
+
+			err = agent.Start(ctx, discovery.HubConfigs(discovery.HubConfig{
+				Addr:     setup.HubAddr,
+				Insecure: true,
+			}))
+			require.NoError(t, err)
+
+			waitErr := make(chan error, 1)
+			go func() {
+				waitErr <- agent.Wait(ctx)
+			}()
+
+			time.Sleep(time.Second)
+
+			conn, err := agent.Connect(pb.ParseLabelSet("service=echo"))
+			require.NoError(t, err)
+
+			// Wait for the echo handler to be running.
+			time.Sleep(500 * time.Millisecond)
+
+			shutdownErr := make(chan error, 1)
+			go func() {
+				shutdownErr <- agent.Shutdown(ctx)
+			}()
+
+			time.Sleep(500 * time.Millisecond)
+
+			select {
+			case <-shutdownErr:
+				t.Fatal("shutdown returned with an active stream")
+			default:
+			}
+
+			var count int
+			err = dbx.Check(setup.DB.Model(&control.Service{}).Count(&count))
+			require.NoError(t, err)
+
+			assert.Equal(t, 0, count)
+
+			conn.Close()
+
+			select {
+			case err := <-shutdownErr:
+				assert.NoError(t, err)
+			case <-ctx.Done():
+				t.Fatal("shutdown did not finish after the stream closed")
+			}
+
+			select {
+			case <-waitErr:
+			case <-ctx.Done():
+				t.Fatal("wait did not return after shutdown")
+			}
+		})
+	})
 }
@@ -0,0 +1,209 @@
+package agent
+
+import (
+	"context"
+	"time"
+
+	"github.com/armon/go-metrics"
+	"github.com/hashicorp/horizon/pkg/discovery"
+)
+
+const (
+	DefaultTargetSessions  = 5
+	DefaultMinBackoff      = time.Second
+	DefaultMaxBackoff      = 2 * time.Minute
+	DefaultQuarantineAfter = 5
+	DefaultQuarantine      = 5 * time.Minute
+)
+
+// Backoff controls how the agent retries hubs it can't connect to. Zero
+// fields use the defaults.
+type Backoff struct {
+	// The delay after the first failure, doubled with each further failure
+	// up to Max. The actual delay is randomized between half and all of this
+	// so that agents disconnected at the same time don't all retry together.
+	Min time.Duration
+	Max time.Duration
+
+	// After this many consecutive failures, the hub is not used again until
+	// Quarantine has passed. Set QuarantineAfter to -1 to never quarantine.
+	QuarantineAfter int
+	Quarantine      time.Duration
+}
+
+func (b Backoff) min() time.Duration {
+	if b.Min > 0 {
+		return b.Min
+	}
+
+	return DefaultMinBackoff
+}
+
+func (b Backoff) max() time.Duration {
+	if b.Max > 0 {
+		return b.Max
+	}
+
+	return DefaultMaxBackoff
+}
+
+func (b Backoff) quarantineAfter() int {
+	if b.QuarantineAfter != 0 {
+		return b.QuarantineAfter
+	}
+
+	return DefaultQuarantineAfter
+}
+
+func (b Backoff) quarantine() time.Duration {
+	if b.Quarantine > 0 {
+		return b.Quarantine
+	}
+
+	return DefaultQuarantine
+}
+
+// Tracks the connection attempts to a single hub.
+type hubRetry struct {
+	failures int
+	retryAt  time.Time
+}
+
+func (a *Agent) targetSessions() int {
+	if a.TargetSessions > 0 {
+		return a.TargetSessions
+	}
+
+	return DefaultTargetSessions
+}
+
+// Returns a random duration between half of d and d. Must be called with a.mu
+// held.
+func (a *Agent) jitter(d time.Duration) time.Duration {
+	half := int64(d / 2)
+	return time.Duration(half + a.rng.Int63n(half+1))
+}
+
+// Returns how long to wait before retrying a hub that has failed the given
+// number of times in a row. Must be called with a.mu held.
+func (a *Agent) backoffDelay(failures int) time.Duration {
+	d := a.Backoff.min()
+	max := a.Backoff.max()
+
+	for i := 1; i < failures && d < max; i++ {
+		d *= 2
+	}
+
+	if d > max {
+		d = max
+	}
+
+	return a.jitter(d)
+}
+
+// Records that a session with a hub ended, or that an attempt to connect to
+// it failed if err is set, and hands the hub back to the provider once it may
+// be used again.
+func (a *Agent) releaseHub(cfg discovery.HubConfig, err error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	r, ok := a.retries[cfg.Addr]
+	if !ok {
+		r = &hubRetry{}
+		a.retries[cfg.Addr] = r
+	}
+
+	// A session that ended doesn't mean the hub is broken, but it could be
+	// restarting so still wait a little before using it again.
+	if err == nil {
+		r.failures = 0
+	} else {
+		r.failures++
+	}
+
+	limit := a.Backoff.quarantineAfter()
+
+	if limit > 0 && r.failures >= limit {
+		quarantine := a.Backoff.quarantine()
+
+		a.L.Warn("quarantining hub after repeated failures",
+			"addr", cfg.Addr, "failures", r.failures, "duration", quarantine)
+
+		metrics.IncrCounterWithLabels([]string{"hub", "quarantined"}, 1, hubLabels(cfg))
+
+		delete(a.retries, cfg.Addr)
+
+		time.AfterFunc(quarantine, func() {
+			a.L.Info("hub released from quarantine", "addr", cfg.Addr)
+			a.hcp.Return(cfg)
+		})
+
+		return
+	}
+
+	r.retryAt = time.Now().Add(a.backoffDelay(r.failures))
+
+	a.hcp.Return(cfg)
+}
+
+// Returns how much longer to wait before connecting to the hub.
+func (a *Agent) retryDelay(cfg discovery.HubConfig) time.Duration {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	r, ok := a.retries[cfg.Addr]
+	if !ok {
+		return 0
+	}
+
+	return time.Until(r.retryAt)
+}
+
+// Records a successful connection, resetting the hub's backoff.
+func (a *Agent) hubConnected(cfg discovery.HubConfig) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	delete(a.retries, cfg.Addr)
+}
+
+// Fills a hub session slot after delay by taking a hub from the provider and
+// connecting to it, once the hub's own backoff has passed. If the provider
+// has no hubs to give, the slot is tried again later.
+func (a *Agent) scheduleConnect(ctx context.Context, delay time.Duration) {
+	time.AfterFunc(delay, func() {
+		if ctx.Err() != nil || a.isDraining() {
+			return
+		}
+
+		cfg, ok := a.hcp.Take(ctx)
+		if !ok {
+			a.mu.Lock()
+			delay := a.jitter(a.Backoff.max())
+			a.mu.Unlock()
+
+			a.L.Debug("no hub available, trying again later", "delay", delay)
+			a.scheduleConnect(ctx, delay)
+			return
+		}
+
+		if wait := a.retryDelay(cfg); wait > 0 {
+			a.L.Debug("backing off before connecting to hub", "addr", cfg.Addr, "delay", wait)
+
+			timer := time.NewTimer(wait)
+			defer timer.Stop()
+
+			select {
+			case <-ctx.Done():
+				a.hcp.Return(cfg)
+				return
+			case <-timer.C:
+			}
+		}
+
+		metrics.IncrCounterWithLabels([]string{"hub", "reconnects"}, 1, hubLabels(cfg))
+
+		a.connectToHub(ctx, cfg, a.statuses)
+	})
+}
@@ -0,0 +1,92 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/horizon/pkg/discovery"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackoff(t *testing.T) {
+	t.Run("delays grow with each failure up to the max", func(t *testing.T) {
+		agent, err := NewAgent(hclog.NewNullLogger())
+		require.NoError(t, err)
+
+		agent.Backoff = Backoff{
+			Min: time.Second,
+			Max: 8 * time.Second,
+		}
+
+		expected := []time.Duration{
+			time.Second,
+			2 * time.Second,
+			4 * time.Second,
+			8 * time.Second,
+			8 * time.Second,
+		}
+
+		for i, d := range expected {
+			delay := agent.backoffDelay(i + 1)
+
+			assert.True(t, delay >= d/2, "delay %s below %s", delay, d/2)
+			assert.True(t, delay <= d, "delay %s above %s", delay, d)
+		}
+	})
+
+	t.Run("quarantines hubs that keep failing", func(t *testing.T) {
+		agent, err := NewAgent(hclog.NewNullLogger())
+		require.NoError(t, err)
+
+		agent.Backoff = Backoff{
+			QuarantineAfter: 2,
+			Quarantine:      100 * time.Millisecond,
+		}
+
+		cfg := discovery.HubConfig{Addr: "127.0.0.1:443"}
+
+		agent.hcp = discovery.HubConfigs()
+
+		ctx := context.Background()
+
+		agent.releaseHub(cfg, errors.New("connection refused"))
+
+		got, ok := agent.hcp.Take(ctx)
+		require.True(t, ok)
+
+		assert.Equal(t, cfg, got)
+		assert.True(t, agent.retryDelay(cfg) > 0)
+
+		agent.releaseHub(cfg, errors.New("connection refused"))
+
+		_, ok = agent.hcp.Take(ctx)
+		require.False(t, ok)
+
+		time.Sleep(200 * time.Millisecond)
+
+		got, ok = agent.hcp.Take(ctx)
+		require.True(t, ok)
+
+		assert.Equal(t, cfg, got)
+		assert.Equal(t, time.Duration(0), agent.retryDelay(cfg))
+	})
+
+	t.Run("connecting resets the backoff", func(t *testing.T) {
+		agent, err := NewAgent(hclog.NewNullLogger())
+		require.NoError(t, err)
+
+		cfg := discovery.HubConfig{Addr: "127.0.0.1:443"}
+
+		agent.hcp = discovery.HubConfigs()
+
+		agent.releaseHub(cfg, errors.New("connection refused"))
+		assert.True(t, agent.retryDelay(cfg) > 0)
+
+		agent.hubConnected(cfg)
+		assert.Equal(t, time.Duration(0), agent.retryDelay(cfg))
+	})
+}
@@ -0,0 +1,380 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/horizon/pkg/pb"
+	"github.com/pkg/errors"
+)
+
+// Config is the contents of an agent configuration file.
+type Config struct {
+	// The address of the control plane used to discover hubs.
+	Control string `json:"control"`
+
+	// The token to use, or a file to read it from.
+	Token     string `json:"token"`
+	TokenFile string `json:"token_file"`
+
+	// Labels that identify the agent itself.
+	Labels []string `json:"labels"`
+
+	Services []ServiceConfig `json:"services"`
+}
+
+// ServiceConfig declares one service for the agent to advertise.
+type ServiceConfig struct {
+	// An optional name used in log output.
+	Name string `json:"name"`
+
+	// One of http, tcp, or udp.
+	Type string `json:"type"`
+
+	// The address of the local server that handles the service. http
+	// services accept a full http://, https://, h2c://, or unix:/// URL, and
+	// tcp services accept unix:///path as well as host:port.
+	Target string `json:"target"`
+
+	// For http services, rewrite the Host header and prepend a path to each
+	// request.
+	Host       string `json:"host"`
+	PathPrefix string `json:"path_prefix"`
+
+	// For https upstreams, how to verify and authenticate to the upstream.
+	TLS *UpstreamTLS `json:"tls"`
+
+	// Record the requests to an http service with the agent's Inspector.
+	Inspect bool `json:"inspect"`
+
+	// Labels in the same format as the --labels flag.
+	Labels   string            `json:"labels"`
+	Metadata map[string]string `json:"metadata"`
+
+	HealthCheck *HealthCheckConfig `json:"health_check"`
+
+	// Limit how many streams the service handles at once, queueing up to
+	// MaxQueue more for up to QueueTimeout, a Go duration string.
+	MaxConcurrent int    `json:"max_concurrent"`
+	MaxQueue      int    `json:"max_queue"`
+	QueueTimeout  string `json:"queue_timeout"`
+}
+
+// HealthCheckConfig declares the health check for a service. Only one of HTTP
+// or TCP should be set.
+type HealthCheckConfig struct {
+	// A path (or full URL) to GET on the service's target. Only http
+	// services can use a path, others need the full URL.
+	HTTP string `json:"http"`
+
+	// The status expected from the HTTP check. Any 2xx if not set.
+	Status int `json:"status"`
+
+	// Connect to the service's target (or this address, if not "true").
+	TCP string `json:"tcp"`
+
+	// How often to run the check, as a Go duration string.
+	Interval string `json:"interval"`
+}
+
+// LoadConfig reads the JSON configuration file at path.
+func LoadConfig(path string) (*Config, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	return ParseConfig(f)
+}
+
+// ParseConfig decodes a JSON configuration and validates it.
+func ParseConfig(r io.Reader) (*Config, error) {
+	var cfg Config
+
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+
+	err := dec.Decode(&cfg)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error decoding configuration")
+	}
+
+	var merr error
+
+	for i, sc := range cfg.Services {
+		_, err := sc.Service()
+		if err != nil {
+			merr = multierror.Append(merr, errors.Wrapf(err, "service %d (%s)", i, sc.Name))
+		}
+	}
+
+	if merr != nil {
+		return nil, merr
+	}
+
+	return &cfg, nil
+}
+
+// ReadToken returns the configured token, reading it from TokenFile if
+// necessary.
+func (c *Config) ReadToken() (string, error) {
+	if c.Token != "" || c.TokenFile == "" {
+		return c.Token, nil
+	}
+
+	data, err := ioutil.ReadFile(c.TokenFile)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(data)), nil
+}
+
+// Interpret target as a host:port, defaulting the host to 127.0.0.1 when only
+// a port is given.
+func normalizeTarget(target, defPort string) (string, error) {
+	if strings.IndexByte(target, ':') != -1 {
+		return target, nil
+	}
+
+	_, err := strconv.Atoi(target)
+	if err == nil {
+		return "127.0.0.1:" + target, nil
+	}
+
+	if defPort == "" {
+		return "", fmt.Errorf("unable to interpret '%s' as a target address", target)
+	}
+
+	return target + ":" + defPort, nil
+}
+
+// Service builds the Service that the configuration describes.
+func (sc *ServiceConfig) Service() (*Service, error) {
+	return sc.service(nil)
+}
+
+// ServiceFromConfig builds the Service that sc describes, recording it's
+// requests with the agent's Inspector if the service has inspection enabled.
+func (a *Agent) ServiceFromConfig(sc *ServiceConfig) (*Service, error) {
+	return sc.service(a.Inspector)
+}
+
+func (sc *ServiceConfig) service(insp *Inspector) (*Service, error) {
+	if sc.Target == "" {
+		return nil, errors.New("no target specified")
+	}
+
+	var (
+		serv Service
+		err  error
+	)
+
+	serv.Type = sc.Type
+	serv.Labels = pb.ParseLabelSet(sc.Labels)
+	serv.Metadata = sc.Metadata
+
+	var (
+		target string
+		hh     *httpHandler
+	)
+
+	switch sc.Type {
+	case "http":
+		upstream := sc.Target
+
+		if !strings.Contains(upstream, "://") {
+			target, err = normalizeTarget(upstream, "80")
+			if err != nil {
+				return nil, err
+			}
+
+			upstream = "http://" + target
+		}
+
+		opts := &HTTPOptions{
+			Host:       sc.Host,
+			PathPrefix: sc.PathPrefix,
+		}
+
+		if sc.Inspect {
+			opts.Inspector = insp
+		}
+
+		if sc.TLS != nil {
+			opts.TLS, err = sc.TLS.Config()
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		handler, err := NewHTTPHandler(upstream, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		hh = handler.(*httpHandler)
+		target = hh.addr
+		serv.Handler = handler
+	case "tcp":
+		target = sc.Target
+
+		if !strings.HasPrefix(target, "unix://") {
+			target, err = normalizeTarget(target, "")
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		serv.Handler = TCPHandler(target)
+	case "udp":
+		target, err = normalizeTarget(sc.Target, "")
+		if err != nil {
+			return nil, err
+		}
+
+		serv.Handler = UDPHandler(target)
+	default:
+		return nil, fmt.Errorf("unknown service type: %s", sc.Type)
+	}
+
+	serv.MaxConcurrent = sc.MaxConcurrent
+	serv.MaxQueue = sc.MaxQueue
+
+	if sc.QueueTimeout != "" {
+		serv.QueueTimeout, err = time.ParseDuration(sc.QueueTimeout)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid queue timeout")
+		}
+	}
+
+	if hc := sc.HealthCheck; hc != nil {
+		switch {
+		case hc.HTTP != "":
+			switch {
+			case strings.Contains(hc.HTTP, "://"):
+				serv.HealthCheck = HTTPHealthCheck(hc.HTTP, hc.Status)
+			case hh != nil:
+				serv.HealthCheck = hh.healthCheck(hc.HTTP, hc.Status)
+			default:
+				return nil, errors.New("http health check requires a full URL for non-http services")
+			}
+		case hc.TCP != "":
+			addr := hc.TCP
+			if addr == "true" {
+				addr = target
+			}
+
+			serv.HealthCheck = TCPHealthCheck(addr)
+		default:
+			return nil, errors.New("health check must specify http or tcp")
+		}
+
+		if hc.Interval != "" {
+			serv.HealthInterval, err = time.ParseDuration(hc.Interval)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid health check interval")
+			}
+		}
+	}
+
+	return &serv, nil
+}
+
+// A stable identifier for the configuration, used to detect when a service
+// has changed between reloads.
+func (sc *ServiceConfig) key() string {
+	data, _ := json.Marshal(sc)
+	return string(data)
+}
+
+// SyncServices makes the agent's configured services match configs. Services
+// that are unchanged since the last call are left alone, while services that
+// were removed or changed are removed from the agent (and the hubs it's
+// connected to) and new or changed ones are added.
+func (a *Agent) SyncServices(configs []ServiceConfig) error {
+	want := map[string]*ServiceConfig{}
+
+	for i := range configs {
+		sc := &configs[i]
+		want[sc.key()] = sc
+	}
+
+	a.mu.Lock()
+	if a.configured == nil {
+		a.configured = map[string]*pb.ULID{}
+	}
+
+	var remove []string
+
+	for key := range a.configured {
+		if _, ok := want[key]; !ok {
+			remove = append(remove, key)
+		}
+	}
+
+	var add []string
+
+	for key := range want {
+		if _, ok := a.configured[key]; !ok {
+			add = append(add, key)
+		}
+	}
+	a.mu.Unlock()
+
+	// Keep the order stable so logs are easy to follow.
+	sort.Strings(remove)
+	sort.Strings(add)
+
+	var merr error
+
+	for _, key := range remove {
+		a.mu.Lock()
+		id := a.configured[key]
+		delete(a.configured, key)
+		a.mu.Unlock()
+
+		a.L.Info("removing configured service", "service", id)
+
+		err := a.RemoveService(id)
+		if err != nil {
+			merr = multierror.Append(merr, err)
+		}
+	}
+
+	for _, key := range add {
+		sc := want[key]
+
+		serv, err := a.ServiceFromConfig(sc)
+		if err != nil {
+			merr = multierror.Append(merr, errors.Wrapf(err, "service %s", sc.Name))
+			continue
+		}
+
+		id, err := a.AddService(serv)
+
+		// The service is registered with the agent even if some hubs failed
+		// to accept it, so it needs to be tracked either way.
+		a.mu.Lock()
+		a.configured[key] = id
+		a.mu.Unlock()
+
+		a.L.Info("added configured service", "service", id, "name", sc.Name, "type", sc.Type, "target", sc.Target)
+
+		if err != nil {
+			merr = multierror.Append(merr, err)
+		}
+	}
+
+	return merr
+}
@@ -0,0 +1,87 @@
+package agent
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig(t *testing.T) {
+	t.Run("parses services from a configuration file", func(t *testing.T) {
+		cfg, err := ParseConfig(strings.NewReader(`{
+			"control": "control.example.com",
+			"token_file": "/etc/hzn/token",
+			"labels": ["dc=test"],
+			"services": [
+				{
+					"name": "web",
+					"type": "http",
+					"target": "8080",
+					"labels": "service=web,env=prod",
+					"metadata": {"version": "1.2"},
+					"health_check": {"http": "/healthz", "interval": "5s"}
+				},
+				{"type": "tcp", "target": "db.internal:5432", "labels": "service=db"},
+				{"type": "udp", "target": "53", "labels": "service=dns"}
+			]
+		}`))
+		require.NoError(t, err)
+
+		assert.Equal(t, "control.example.com", cfg.Control)
+		assert.Equal(t, []string{"dc=test"}, cfg.Labels)
+		require.Equal(t, 3, len(cfg.Services))
+
+		serv, err := cfg.Services[0].Service()
+		require.NoError(t, err)
+
+		assert.Equal(t, "http", serv.Type)
+		assert.Equal(t, "env=prod,service=web", serv.Labels.SpecString())
+		assert.Equal(t, "1.2", serv.Metadata["version"])
+		assert.NotNil(t, serv.HealthCheck)
+		assert.Equal(t, "5s", serv.HealthInterval.String())
+	})
+
+	t.Run("rejects invalid services", func(t *testing.T) {
+		_, err := ParseConfig(strings.NewReader(`{"services": [{"type": "smtp", "target": "25"}]}`))
+		assert.Error(t, err)
+
+		_, err = ParseConfig(strings.NewReader(`{"services": [{"type": "tcp", "target": "db"}]}`))
+		assert.Error(t, err)
+
+		_, err = ParseConfig(strings.NewReader(`{"servics": []}`))
+		assert.Error(t, err)
+	})
+
+	t.Run("syncs services against the previous configuration", func(t *testing.T) {
+		agent, err := NewAgent(hclog.NewNullLogger())
+		require.NoError(t, err)
+
+		web := ServiceConfig{Type: "http", Target: "8080", Labels: "service=web"}
+		db := ServiceConfig{Type: "tcp", Target: "5432", Labels: "service=db"}
+
+		err = agent.SyncServices([]ServiceConfig{web, db})
+		require.NoError(t, err)
+
+		assert.Equal(t, 2, len(agent.services))
+
+		webId := agent.configured[web.key()]
+		require.NotNil(t, webId)
+
+		db.Labels = "service=db,env=prod"
+
+		err = agent.SyncServices([]ServiceConfig{web, db})
+		require.NoError(t, err)
+
+		assert.Equal(t, 2, len(agent.services))
+		assert.Equal(t, webId, agent.configured[web.key()])
+
+		err = agent.SyncServices([]ServiceConfig{db})
+		require.NoError(t, err)
+
+		assert.Equal(t, 1, len(agent.services))
+		assert.Nil(t, agent.services[webId.SpecString()])
+	})
+}
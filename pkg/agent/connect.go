@@ -3,6 +3,7 @@ package agent
 import (
 	"io"
 	"net"
+	"sync/atomic"
 	"time"
 
 	"github.com/hashicorp/horizon/pkg/pb"
@@ -64,12 +65,80 @@ func (c *Conn) SetWriteDeadline(t time.Time) error {
 }
 
 func (a *Agent) Connect(labels *pb.LabelSet) (net.Conn, error) {
-	a.mu.Lock()
-	stream, err := a.sessions[0].OpenStream()
-	a.mu.Unlock()
+	stream, ctx, err := a.connect(labels, "")
+	if err != nil {
+		return nil, err
+	}
+
+	r := ctx.Reader()
+	w := ctx.Writer()
+
+	return &Conn{Reader: r, WriteCloser: w, Stream: stream}, nil
+}
+
+var ErrNoHubSessions = errors.New("no hub sessions available")
+
+// Returns the live sessions, rotated so that each call starts with a different
+// session. This spreads new streams across all the hubs the agent is
+// connected to.
+func (a *Agent) pickSessions() []*yamux.Session {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	n := len(a.sessions)
+	if n == 0 {
+		return nil
+	}
+
+	start := int(atomic.AddUint64(a.nextSession, 1) % uint64(n))
+
+	out := make([]*yamux.Session, 0, n)
+
+	for i := 0; i < n; i++ {
+		sess := a.sessions[(start+i)%n]
+		if !sess.IsClosed() {
+			out = append(out, sess)
+		}
+	}
+
+	return out
+}
 
+// Sends a connect request for labels, trying each live session in turn until
+// one succeeds. An error sent back by the hub, such as there being no routes,
+// is returned right away since another hub would give the same answer.
+func (a *Agent) connect(labels *pb.LabelSet, protocolId string) (*yamux.Stream, wire.Context, error) {
+	sessions := a.pickSessions()
+	if len(sessions) == 0 {
+		return nil, nil, ErrNoHubSessions
+	}
+
+	var lastErr error
+
+	for _, session := range sessions {
+		stream, ctx, err := a.connectOn(session, labels, protocolId)
+		if err == nil {
+			return stream, ctx, nil
+		}
+
+		var remoteErr *wire.RemoteError
+
+		if errors.As(err, &remoteErr) {
+			return nil, nil, err
+		}
+
+		a.L.Debug("error connecting via hub session, trying next session", "error", err, "labels", labels)
+
+		lastErr = err
+	}
+
+	return nil, nil, lastErr
+}
+
+func (a *Agent) connectOn(session *yamux.Session, labels *pb.LabelSet, protocolId string) (*yamux.Stream, wire.Context, error) {
+	stream, err := session.OpenStream()
 	if err != nil {
-		return nil, errors.Wrapf(err, "error opening new yamux stream")
+		return nil, nil, errors.Wrapf(err, "error opening new yamux stream")
 	}
 
 	sr := lz4.NewReader(stream)
@@ -77,37 +146,38 @@ func (a *Agent) Connect(labels *pb.LabelSet) (net.Conn, error) {
 
 	fw, err := wire.NewFramingWriter(sw)
 	if err != nil {
-		return nil, err
+		stream.Close()
+		return nil, nil, err
 	}
 
 	fr, err := wire.NewFramingReader(sr)
 	if err != nil {
-		return nil, err
+		stream.Close()
+		return nil, nil, err
 	}
 
 	var conreq pb.ConnectRequest
 	conreq.Target = labels
+	conreq.ProtocolId = protocolId
 
 	_, err = fw.WriteMarshal(1, &conreq)
 	if err != nil {
-		return nil, errors.Wrapf(err, "error writing connect request")
+		stream.Close()
+		return nil, nil, errors.Wrapf(err, "error writing connect request")
 	}
 
 	var ack pb.ConnectAck
 
 	tag, _, err := fr.ReadMarshal(&ack)
 	if err != nil {
-		return nil, err
+		stream.Close()
+		return nil, nil, err
 	}
 
 	if tag != 1 {
-		return nil, wire.ErrProtocolError
+		stream.Close()
+		return nil, nil, wire.ErrProtocolError
 	}
 
-	ctx := wire.NewContext(nil, fr, fw)
-
-	r := ctx.Reader()
-	w := ctx.Writer()
-
-	return &Conn{Reader: r, WriteCloser: w, Stream: stream}, nil
+	return stream, wire.NewContext(nil, fr, fw), nil
 }
@@ -0,0 +1,62 @@
+package agent
+
+import (
+	"context"
+
+	"github.com/armon/go-metrics"
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/horizon/pkg/pb"
+	"github.com/hashicorp/yamux"
+)
+
+// Handles a GOAWAY from a draining hub. The session stops being used for new
+// streams and a replacement session to another hub is started right away, so
+// the agent's services are reachable elsewhere before the hub closes this one.
+// Streams already running on the session are left to finish.
+func (a *Agent) handleGoAway(ctx context.Context, L hclog.Logger, session *yamux.Session, msg []byte) {
+	var deadline pb.Timestamp
+
+	err := deadline.Unmarshal(msg)
+	if err != nil {
+		L.Error("error decoding goaway", "error", err)
+		return
+	}
+
+	a.mu.Lock()
+
+	hs, ok := a.hubs[session]
+	if !ok || !hs.closingAt.IsZero() {
+		a.mu.Unlock()
+		return
+	}
+
+	hs.closingAt = deadline.Time()
+
+	a.removeSession(session)
+
+	metrics.SetGauge([]string{"hubs", "connected"}, float32(len(a.sessions)))
+
+	draining := a.draining
+
+	a.mu.Unlock()
+
+	L.Info("hub is draining, moving to another hub", "addr", hs.cfg.Addr, "closing-at", hs.closingAt)
+
+	metrics.IncrCounterWithLabels([]string{"hub", "goaways"}, 1, hubLabels(hs.cfg))
+
+	if draining {
+		return
+	}
+
+	a.scheduleConnect(ctx, 0)
+}
+
+// Stops using session for new streams. Must be called with a.mu held.
+func (a *Agent) removeSession(session *yamux.Session) {
+	for i, sess := range a.sessions {
+		if sess == session {
+			a.sessions = append(a.sessions[:i], a.sessions[i+1:]...)
+			return
+		}
+	}
+}
@@ -0,0 +1,238 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/horizon/pkg/wire"
+)
+
+// A HealthChecker tests the local backend of a service. A non-nil error
+// means the backend is unhealthy.
+type HealthChecker interface {
+	CheckHealth(ctx context.Context) error
+}
+
+// HealthCheckFunc adapts a function to the HealthChecker interface.
+type HealthCheckFunc func(ctx context.Context) error
+
+func (f HealthCheckFunc) CheckHealth(ctx context.Context) error {
+	return f(ctx)
+}
+
+type httpHealthCheck struct {
+	url    string
+	status int
+	client *http.Client
+}
+
+// HTTPHealthCheck returns a HealthChecker that performs a GET of url and
+// expects the response to have the given status code. If status is 0, any
+// 2xx response is considered healthy.
+func HTTPHealthCheck(url string, status int) HealthChecker {
+	return &httpHealthCheck{url: url, status: status}
+}
+
+func (h *httpHealthCheck) CheckHealth(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", h.url, nil)
+	if err != nil {
+		return err
+	}
+
+	client := h.client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	resp.Body.Close()
+
+	if h.status == 0 {
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		}
+
+		return nil
+	}
+
+	if resp.StatusCode != h.status {
+		return fmt.Errorf("unexpected status code: %d (expected %d)", resp.StatusCode, h.status)
+	}
+
+	return nil
+}
+
+type tcpHealthCheck struct {
+	addr string
+}
+
+// TCPHealthCheck returns a HealthChecker that considers the backend healthy
+// if a connection to addr can be established. addr may be unix:///path to
+// check a unix domain socket.
+func TCPHealthCheck(addr string) HealthChecker {
+	return &tcpHealthCheck{addr: addr}
+}
+
+func (t *tcpHealthCheck) CheckHealth(ctx context.Context) error {
+	conn, err := dialUpstream(ctx, t.addr)
+	if err != nil {
+		return err
+	}
+
+	return conn.Close()
+}
+
+const (
+	// How often a health check is run if the service doesn't specify.
+	DefaultHealthInterval = 10 * time.Second
+
+	// How long a single health check can take before it's considered failed.
+	DefaultHealthTimeout = 5 * time.Second
+)
+
+// HealthStatus is the last observed health of a service.
+type HealthStatus struct {
+	Healthy   bool      `json:"healthy"`
+	LastCheck time.Time `json:"last_check"`
+	LastError string    `json:"last_error,omitempty"`
+
+	// The number of checks that have failed in a row.
+	Failures int `json:"failures"`
+}
+
+// ServiceHealth returns the current health of each service that has a
+// health check configured, keyed by service id.
+func (a *Agent) ServiceHealth() map[string]HealthStatus {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	out := make(map[string]HealthStatus, len(a.health))
+
+	for key, hs := range a.health {
+		out[key] = *hs
+	}
+
+	return out
+}
+
+// Indicates if the service should currently be advertised to hubs. Must be
+// called with a.mu held.
+func (a *Agent) isAdvertised(key string) bool {
+	hs, ok := a.health[key]
+	if !ok {
+		return true
+	}
+
+	return hs.Healthy
+}
+
+// Starts the health check loop for serv, if it has a check. Must be called
+// with a.mu held.
+func (a *Agent) startHealthCheck(serv *Service) {
+	if serv.HealthCheck == nil || a.runCtx == nil {
+		return
+	}
+
+	key := serv.Id.SpecString()
+
+	if _, ok := a.checks[key]; ok {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(a.runCtx)
+	a.checks[key] = cancel
+
+	go a.runHealthCheck(ctx, serv)
+}
+
+// Stops the health check loop for the service with the given key. Must be
+// called with a.mu held.
+func (a *Agent) stopHealthCheck(key string) {
+	if cancel, ok := a.checks[key]; ok {
+		cancel()
+		delete(a.checks, key)
+	}
+
+	delete(a.health, key)
+}
+
+func (a *Agent) runHealthCheck(ctx context.Context, serv *Service) {
+	interval := serv.HealthInterval
+	if interval == 0 {
+		interval = DefaultHealthInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	L := a.L.With("service", serv.Id, "type", serv.Type)
+
+	for {
+		cctx, cancel := context.WithTimeout(ctx, DefaultHealthTimeout)
+		err := serv.HealthCheck.CheckHealth(cctx)
+		cancel()
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		a.recordHealth(L, serv, err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Records the result of a check and withdraws or re-advertises the service
+// on the hubs when the health changes.
+func (a *Agent) recordHealth(L hclog.Logger, serv *Service, err error) {
+	serv.updates.Lock()
+	defer serv.updates.Unlock()
+
+	key := serv.Id.SpecString()
+
+	a.mu.Lock()
+
+	hs, ok := a.health[key]
+	if !ok || a.draining {
+		a.mu.Unlock()
+		return
+	}
+
+	prev := hs.Healthy
+
+	hs.LastCheck = time.Now()
+
+	if err == nil {
+		hs.Healthy = true
+		hs.LastError = ""
+		hs.Failures = 0
+	} else {
+		hs.Healthy = false
+		hs.LastError = err.Error()
+		hs.Failures++
+	}
+
+	sessions := a.liveSessions()
+
+	a.mu.Unlock()
+
+	switch {
+	case prev && err != nil:
+		L.Warn("service is unhealthy, withdrawing from hubs", "error", err)
+		a.updateHubs(sessions, wire.TagServiceRemove, serv.info())
+	case !prev && err == nil:
+		L.Info("service is healthy again, advertising to hubs")
+		a.updateHubs(sessions, wire.TagServiceAdd, serv.info())
+	}
+}
@@ -0,0 +1,81 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealth(t *testing.T) {
+	t.Run("tracks the health of a service", func(t *testing.T) {
+		agent, err := NewAgent(hclog.NewNullLogger())
+		require.NoError(t, err)
+
+		serv := &Service{
+			Type:    "test",
+			Handler: EchoHandler(),
+			HealthCheck: HealthCheckFunc(func(ctx context.Context) error {
+				return nil
+			}),
+		}
+
+		id, err := agent.AddService(serv)
+		require.NoError(t, err)
+
+		// Nothing is advertised until the first check passes.
+		hs := agent.ServiceHealth()[id.SpecString()]
+		assert.False(t, hs.Healthy)
+
+		agent.mu.RLock()
+		assert.False(t, agent.isAdvertised(id.SpecString()))
+		agent.mu.RUnlock()
+
+		agent.recordHealth(agent.L, serv, nil)
+
+		hs = agent.ServiceHealth()[id.SpecString()]
+		assert.True(t, hs.Healthy)
+
+		agent.recordHealth(agent.L, serv, errors.New("connection refused"))
+
+		hs = agent.ServiceHealth()[id.SpecString()]
+		assert.False(t, hs.Healthy)
+		assert.Equal(t, "connection refused", hs.LastError)
+		assert.Equal(t, 1, hs.Failures)
+
+		agent.mu.RLock()
+		assert.False(t, agent.isAdvertised(id.SpecString()))
+		agent.mu.RUnlock()
+
+		agent.recordHealth(agent.L, serv, nil)
+
+		hs = agent.ServiceHealth()[id.SpecString()]
+		assert.True(t, hs.Healthy)
+		assert.Equal(t, 0, hs.Failures)
+	})
+
+	t.Run("checks http backends for the expected status", func(t *testing.T) {
+		code := int32(http.StatusOK)
+
+		s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(int(atomic.LoadInt32(&code)))
+		}))
+		defer s.Close()
+
+		ctx := context.Background()
+
+		assert.NoError(t, HTTPHealthCheck(s.URL, 0).CheckHealth(ctx))
+		assert.Error(t, HTTPHealthCheck(s.URL, http.StatusNoContent).CheckHealth(ctx))
+
+		atomic.StoreInt32(&code, http.StatusServiceUnavailable)
+
+		assert.Error(t, HTTPHealthCheck(s.URL, 0).CheckHealth(ctx))
+		assert.NoError(t, TCPHealthCheck(s.Listener.Addr().String()).CheckHealth(ctx))
+	})
+}
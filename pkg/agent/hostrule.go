@@ -0,0 +1,111 @@
+package agent
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/horizon/pkg/pb"
+	"github.com/pkg/errors"
+)
+
+// DefaultHostRule maps hostnames like web.prod.hzn to service=web,env=prod.
+const DefaultHostRule = "{service}.{env}.hzn"
+
+var ErrHostMismatch = errors.New("hostname does not match rule")
+
+// A HostRule maps a hostname to the labels of the service to connect to. A
+// rule is a dotted pattern where each part is either a literal that must
+// match or {name}, which captures that part of the hostname as the value of
+// the label name.
+type HostRule struct {
+	parts []string
+	fixed *pb.LabelSet
+}
+
+// ParseHostRule parses rule. fixed, if not empty, are labels in the format of
+// pb.ParseLabelSet added to every label set the rule produces.
+func ParseHostRule(rule, fixed string) (*HostRule, error) {
+	rule = strings.ToLower(strings.TrimSuffix(rule, "."))
+
+	if rule == "" {
+		return nil, errors.New("empty host rule")
+	}
+
+	hr := &HostRule{
+		parts: strings.Split(rule, "."),
+	}
+
+	var captures int
+
+	for _, part := range hr.parts {
+		if part == "" {
+			return nil, fmt.Errorf("empty component in host rule: %s", rule)
+		}
+
+		if strings.HasPrefix(part, "{") != strings.HasSuffix(part, "}") {
+			return nil, fmt.Errorf("unbalanced braces in host rule: %s", rule)
+		}
+
+		if strings.HasPrefix(part, "{") {
+			if len(part) == 2 {
+				return nil, fmt.Errorf("empty label name in host rule: %s", rule)
+			}
+
+			captures++
+		}
+	}
+
+	if captures == 0 {
+		return nil, fmt.Errorf("host rule captures no labels: %s", rule)
+	}
+
+	if fixed != "" {
+		hr.fixed = pb.ParseLabelSet(fixed)
+	}
+
+	return hr, nil
+}
+
+// Labels returns the labels for host, which may include a port that is
+// ignored.
+func (hr *HostRule) Labels(host string) (*pb.LabelSet, error) {
+	if idx := strings.LastIndexByte(host, ':'); idx != -1 && !strings.HasSuffix(host, "]") {
+		host = host[:idx]
+	}
+
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+
+	parts := strings.Split(host, ".")
+	if len(parts) != len(hr.parts) {
+		return nil, errors.Wrapf(ErrHostMismatch, "%s", host)
+	}
+
+	var ls pb.LabelSet
+
+	if hr.fixed != nil {
+		ls.Labels = append(ls.Labels, hr.fixed.Labels...)
+	}
+
+	for i, part := range hr.parts {
+		if strings.HasPrefix(part, "{") {
+			if parts[i] == "" {
+				return nil, errors.Wrapf(ErrHostMismatch, "%s", host)
+			}
+
+			ls.Labels = append(ls.Labels, &pb.Label{
+				Name:  part[1 : len(part)-1],
+				Value: parts[i],
+			})
+
+			continue
+		}
+
+		if parts[i] != part {
+			return nil, errors.Wrapf(ErrHostMismatch, "%s", host)
+		}
+	}
+
+	ls.Finalize()
+
+	return &ls, nil
+}
@@ -0,0 +1,52 @@
+package agent
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHostRule(t *testing.T) {
+	t.Run("maps hostnames to labels", func(t *testing.T) {
+		hr, err := ParseHostRule(DefaultHostRule, "")
+		require.NoError(t, err)
+
+		ls, err := hr.Labels("Web.Prod.hzn:443")
+		require.NoError(t, err)
+
+		assert.Equal(t, "env=prod,service=web", ls.SpecString())
+	})
+
+	t.Run("adds fixed labels", func(t *testing.T) {
+		hr, err := ParseHostRule("{service}.internal", "team=core")
+		require.NoError(t, err)
+
+		ls, err := hr.Labels("db.internal")
+		require.NoError(t, err)
+
+		assert.Equal(t, "service=db,team=core", ls.SpecString())
+	})
+
+	t.Run("rejects hostnames that don't match", func(t *testing.T) {
+		hr, err := ParseHostRule(DefaultHostRule, "")
+		require.NoError(t, err)
+
+		_, err = hr.Labels("web.prod.example.com")
+		assert.Error(t, err)
+
+		_, err = hr.Labels("web.prod.com")
+		assert.Error(t, err)
+	})
+
+	t.Run("rejects invalid rules", func(t *testing.T) {
+		_, err := ParseHostRule("web.hzn", "")
+		assert.Error(t, err)
+
+		_, err = ParseHostRule("{service.hzn", "")
+		assert.Error(t, err)
+
+		_, err = ParseHostRule("{}.hzn", "")
+		assert.Error(t, err)
+	})
+}
@@ -1,27 +1,213 @@
 package agent
 
 import (
+	"bufio"
 	"context"
+	"crypto/tls"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"net/url"
 	"strings"
+	"time"
 
 	"github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/horizon/pkg/pb"
+	"github.com/hashicorp/horizon/pkg/wire"
+	"github.com/pkg/errors"
+	"golang.org/x/net/http2"
 )
 
+// HTTPOptions adjusts how requests are sent to an HTTP upstream.
+type HTTPOptions struct {
+	// The TLS configuration used with an https upstream. The ServerName
+	// defaults to the host in the upstream URL.
+	TLS *tls.Config
+
+	// Sent as the Host header rather than the one the client used.
+	Host string
+
+	// Prepended to the path of each request. Defaults to the path in the
+	// upstream URL.
+	PathPrefix string
+
+	// If set, requests and responses are recorded for inspection.
+	Inspector *Inspector
+}
+
 type httpHandler struct {
-	url string
+	// The address to dial, either host:port or unix:///path.
+	addr string
+
+	// The scheme and host used to build the upstream request URLs.
+	base string
+
+	prefix string
+	host   string
+	tls    *tls.Config
+	client *http.Client
+
+	inspector *Inspector
+}
+
+// HTTPHandler returns a ServiceHandler that sends requests to upstream with
+// the default options. If upstream is invalid, the handler answers every
+// request with a 502 giving the reason, use NewHTTPHandler to handle that
+// error up front.
+func HTTPHandler(upstream string) ServiceHandler {
+	h, err := NewHTTPHandler(upstream, nil)
+	if err != nil {
+		return &badUpstreamHandler{err: err}
+	}
+
+	return h
+}
+
+// Answers requests for an upstream that couldn't be parsed.
+type badUpstreamHandler struct {
+	err error
+}
+
+func (b *badUpstreamHandler) HandleRequest(ctx context.Context, L hclog.Logger, sctx ServiceContext) error {
+	var req pb.Request
+
+	_, err := sctx.ReadMarshal(&req)
+	if err != nil {
+		return err
+	}
+
+	L.Error("request for invalid upstream", "error", b.err, "method", req.Method, "path", req.Path)
+
+	var resp pb.Response
+	resp.Code = http.StatusBadGateway
+	resp.Headers = []*pb.Header{
+		{
+			Name:  "Content-Type",
+			Value: []string{"text/plain; charset=utf-8"},
+		},
+	}
+
+	err = sctx.WriteMarshal(1, &resp)
+	if err != nil {
+		return err
+	}
+
+	w := sctx.Writer()
+
+	fmt.Fprintf(w, "invalid upstream: %s\n", b.err)
+
+	return w.Close()
+}
+
+// NewHTTPHandler returns a ServiceHandler that sends requests to upstream,
+// which is one of http://host:port, https://host:port, h2c://host:port, or
+// unix:///path. A bare host:port is treated as http. h2c upstreams are sent
+// HTTP/2 without TLS, as gRPC servers commonly expect, and https upstreams use
+// HTTP/2 when the upstream supports it.
+func NewHTTPHandler(upstream string, opts *HTTPOptions) (ServiceHandler, error) {
+	if !strings.Contains(upstream, "://") {
+		upstream = "http://" + upstream
+	}
+
+	u, err := url.Parse(upstream)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid upstream url")
+	}
+
+	if opts == nil {
+		opts = &HTTPOptions{}
+	}
+
+	h := &httpHandler{
+		host:      opts.Host,
+		prefix:    opts.PathPrefix,
+		inspector: opts.Inspector,
+	}
+
+	switch u.Scheme {
+	case "http":
+		h.addr = upstreamHostPort(u, "80")
+		h.base = "http://" + u.Host
+	case "https":
+		h.addr = upstreamHostPort(u, "443")
+		h.base = "https://" + u.Host
+
+		if opts.TLS != nil {
+			h.tls = opts.TLS.Clone()
+		} else {
+			h.tls = &tls.Config{}
+		}
+
+		if h.tls.ServerName == "" {
+			h.tls.ServerName = u.Hostname()
+		}
+	case "h2c":
+		h.addr = upstreamHostPort(u, "80")
+		h.base = "http://" + u.Host
+	case "unix":
+		h.addr = "unix://" + u.Path
+		h.base = "http://localhost"
+	default:
+		return nil, errors.Errorf("unsupported upstream scheme: %s", u.Scheme)
+	}
+
+	// The path of a unix upstream is the socket, not a prefix.
+	if h.prefix == "" && u.Scheme != "unix" {
+		h.prefix = u.Path
+	}
+
+	h.prefix = strings.TrimSuffix(h.prefix, "/")
+	if h.prefix != "" && !strings.HasPrefix(h.prefix, "/") {
+		h.prefix = "/" + h.prefix
+	}
+
+	if u.Scheme == "h2c" {
+		h.client = &http.Client{
+			Transport: &http2.Transport{
+				AllowHTTP: true,
+				DialTLS: func(_, _ string, _ *tls.Config) (net.Conn, error) {
+					return dialUpstream(context.Background(), h.addr)
+				},
+			},
+		}
+
+		return h, nil
+	}
+
+	h.client = &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return dialUpstream(ctx, h.addr)
+			},
+			// The transport adds h2 to the config's NextProtos, so it gets
+			// it's own copy to keep that out of upgrades.
+			TLSClientConfig:     h.tls.Clone(),
+			ForceAttemptHTTP2:   true,
+			MaxIdleConnsPerHost: 10,
+			IdleConnTimeout:     90 * time.Second,
+		},
+	}
+
+	return h, nil
 }
 
-func HTTPHandler(url string) ServiceHandler {
-	if !strings.HasPrefix(url, "http://") {
-		url = "http://" + url
+func upstreamHostPort(u *url.URL, defPort string) string {
+	if u.Port() == "" {
+		return net.JoinHostPort(u.Hostname(), defPort)
 	}
 
-	return &httpHandler{url}
+	return u.Host
+}
+
+// Returns a health check that performs a GET of path on the upstream, using
+// the same transport as requests.
+func (h *httpHandler) healthCheck(path string, status int) HealthChecker {
+	return &httpHealthCheck{
+		url:    h.base + "/" + strings.TrimPrefix(path, "/"),
+		status: status,
+		client: h.client,
+	}
 }
 
 func (h *httpHandler) HandleRequest(ctx context.Context, L hclog.Logger, sctx ServiceContext) error {
@@ -40,12 +226,104 @@ func (h *httpHandler) HandleRequest(ctx context.Context, L hclog.Logger, sctx Se
 
 	L.Info("request started", "method", req.Method, "path", req.Path)
 
-	hreq, err := http.NewRequestWithContext(ctx, req.Method, h.url+req.Path, sctx.BodyReader())
+	// An upgrade request has no body, the stream is used to carry the
+	// upgraded connection's data instead.
+	var body io.Reader
+
+	if req.Type != pb.WEBSOCKET {
+		body = sctx.BodyReader()
+	}
+
+	var capture *Capture
+
+	if h.inspector != nil && req.Type != pb.WEBSOCKET {
+		capture = h.inspector.begin(h, &req)
+		body = io.TeeReader(body, &capture.reqBody)
+	}
+
+	hreq, err := h.newRequest(ctx, &req, body)
 	if err != nil {
 		return err
 	}
 
+	if req.Type == pb.WEBSOCKET {
+		return h.handleUpgrade(ctx, L, sctx, hreq)
+	}
+
+	hresp, err := h.client.Do(hreq)
+	if err != nil {
+		if capture != nil {
+			h.inspector.finish(capture, nil, err)
+		}
+
+		return err
+	}
+
+	defer hresp.Body.Close()
+
+	var src io.Reader = hresp.Body
+
+	if capture != nil {
+		src = io.TeeReader(hresp.Body, &capture.respBody)
+		defer h.inspector.finish(capture, hresp, nil)
+	}
+
+	var resp pb.Response
+	resp.Code = int32(hresp.StatusCode)
+
+	for k, v := range hresp.Header {
+		resp.Headers = append(resp.Headers, &pb.Header{
+			Name:  k,
+			Value: v,
+		})
+	}
+
+	err = sctx.WriteMarshal(1, &resp)
+	if err != nil {
+		return err
+	}
+
+	w := sctx.Writer()
+
+	n, _ := io.Copy(w, src)
+
+	err = w.Close()
+	if err != nil {
+		return err
+	}
+
+	L.Info("request ended", "size", n)
+
+	// Trailers are only available once the body has been read, so they
+	// follow the body rather than being sent with the headers.
+	if len(hresp.Trailer) == 0 {
+		return nil
+	}
+
+	var trailers pb.Response
+
+	for k, v := range hresp.Trailer {
+		trailers.Headers = append(trailers.Headers, &pb.Header{
+			Name:  k,
+			Value: v,
+		})
+	}
+
+	return sctx.WriteMarshal(wire.TagTrailers, &trailers)
+}
+
+// Builds the request to send to the upstream from one delivered by a hub.
+func (h *httpHandler) newRequest(ctx context.Context, req *pb.Request, body io.Reader) (*http.Request, error) {
+	hreq, err := http.NewRequestWithContext(ctx, req.Method, h.base+h.prefix+req.Path, body)
+	if err != nil {
+		return nil, err
+	}
+
 	hreq.Host = req.Host
+	if h.host != "" {
+		hreq.Host = h.host
+	}
+
 	hreq.URL.RawQuery = req.Query
 	hreq.URL.Fragment = req.Fragment
 	if req.Auth != nil {
@@ -57,12 +335,49 @@ func (h *httpHandler) HandleRequest(ctx context.Context, L hclog.Logger, sctx Se
 		}
 	}
 
-	hresp, err := http.DefaultClient.Do(hreq)
+	return hreq, nil
+}
+
+// Sends an upgrade request directly to the local backend and, if the backend
+// switches protocols, bridges the raw connection with the stream until either
+// side closes. http.Client can't be used here because it doesn't give us
+// access to the connection after a 101 response.
+func (h *httpHandler) handleUpgrade(ctx context.Context, L hclog.Logger, sctx ServiceContext, hreq *http.Request) error {
+	conn, err := dialUpstream(ctx, h.addr)
 	if err != nil {
 		return err
 	}
 
-	defer hresp.Body.Close()
+	if h.tls != nil {
+		// Upgrades only exist in HTTP/1.1, so don't let an h2 capable
+		// upstream negotiate HTTP/2.
+		cfg := h.tls.Clone()
+		cfg.NextProtos = []string{"http/1.1"}
+
+		tc := tls.Client(conn, cfg)
+
+		err = tc.Handshake()
+		if err != nil {
+			conn.Close()
+			return err
+		}
+
+		conn = tc
+	}
+
+	defer conn.Close()
+
+	err = hreq.Write(conn)
+	if err != nil {
+		return err
+	}
+
+	br := bufio.NewReader(conn)
+
+	hresp, err := http.ReadResponse(br, hreq)
+	if err != nil {
+		return err
+	}
 
 	var resp pb.Response
 	resp.Code = int32(hresp.StatusCode)
@@ -82,9 +397,34 @@ func (h *httpHandler) HandleRequest(ctx context.Context, L hclog.Logger, sctx Se
 	w := sctx.Writer()
 	defer w.Close()
 
-	n, _ := io.Copy(w, hresp.Body)
+	if hresp.StatusCode != http.StatusSwitchingProtocols {
+		defer hresp.Body.Close()
 
-	L.Info("request ended", "size", n)
+		n, _ := io.Copy(w, hresp.Body)
+
+		L.Info("upgrade refused by backend", "code", hresp.StatusCode, "size", n)
+		return nil
+	}
+
+	L.Info("connection upgraded", "upgrade", hresp.Header.Get("Upgrade"))
+
+	go func() {
+		io.Copy(conn, sctx.Reader())
+
+		// Let the backend know the client is done sending but keep reading
+		// whatever it has left to send.
+		if cw, ok := conn.(interface{ CloseWrite() error }); ok {
+			cw.CloseWrite()
+		} else {
+			conn.Close()
+		}
+	}()
+
+	// Use br so that any data buffered while reading the response is
+	// forwarded as well.
+	n, _ := io.Copy(w, br)
+
+	L.Info("upgraded connection ended", "size", n)
 
 	return nil
 }
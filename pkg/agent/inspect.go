@@ -0,0 +1,641 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"html/template"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/hashicorp/horizon/pkg/pb"
+	"github.com/pkg/errors"
+)
+
+const (
+	// How many requests an inspector keeps if not specified.
+	DefaultInspectorSize = 100
+
+	// How much of each body an inspector keeps if not specified.
+	DefaultInspectorBodySize = 64 * 1024
+)
+
+var (
+	ErrUnknownCapture = errors.New("unknown capture")
+	ErrNotReplayable  = errors.New("request body was truncated and can't be replayed")
+)
+
+// Records up to max bytes written to it, noting if anything was dropped.
+type capBuffer struct {
+	mu        sync.Mutex
+	buf       bytes.Buffer
+	max       int
+	truncated bool
+}
+
+func (c *capBuffer) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	room := c.max - c.buf.Len()
+
+	switch {
+	case room >= len(p):
+		c.buf.Write(p)
+	case room > 0:
+		c.buf.Write(p[:room])
+		c.truncated = true
+	case len(p) > 0:
+		c.truncated = true
+	}
+
+	return len(p), nil
+}
+
+func (c *capBuffer) contents() ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]byte(nil), c.buf.Bytes()...), c.truncated
+}
+
+// Capture is a request delivered to an HTTP service and the upstream's
+// response to it.
+type Capture struct {
+	ID       uint64        `json:"id"`
+	Start    time.Time     `json:"start"`
+	Duration time.Duration `json:"duration"`
+
+	// Set if the request was a replay of an earlier capture.
+	ReplayOf uint64 `json:"replay_of,omitempty"`
+
+	Method           string      `json:"method"`
+	Host             string      `json:"host"`
+	Path             string      `json:"path"`
+	Query            string      `json:"query,omitempty"`
+	RequestHeaders   http.Header `json:"request_headers"`
+	RequestBody      []byte      `json:"request_body,omitempty"`
+	RequestTruncated bool        `json:"request_truncated,omitempty"`
+
+	Status            int         `json:"status,omitempty"`
+	ResponseHeaders   http.Header `json:"response_headers,omitempty"`
+	ResponseBody      []byte      `json:"response_body,omitempty"`
+	ResponseTruncated bool        `json:"response_truncated,omitempty"`
+
+	Error string `json:"error,omitempty"`
+
+	req     *pb.Request
+	handler *httpHandler
+
+	reqBody, respBody capBuffer
+}
+
+// URL returns the URL the client requested.
+func (c *Capture) URL() string {
+	u := "http://" + c.Host + c.Path
+	if c.Query != "" {
+		u += "?" + c.Query
+	}
+
+	return u
+}
+
+// An Inspector records the most recent requests to the HTTP services it's
+// attached to with HTTPOptions, for debugging. Bodies are only kept up to a
+// limit so that large transfers don't use unbounded memory.
+type Inspector struct {
+	mu       sync.Mutex
+	captures []*Capture
+	next     int
+	lastID   uint64
+
+	bodySize int
+
+	// Set to record credential headers, such as Authorization and Cookie, as
+	// they were sent instead of redacting them. Replays always send the
+	// original headers.
+	KeepCredentials bool
+}
+
+// The headers that carry credentials, which captures redact unless
+// KeepCredentials is set.
+var credentialHeaders = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"Cookie",
+	"Set-Cookie",
+}
+
+const redacted = "[redacted]"
+
+// Replaces the values of the credential headers in h, unless the inspector
+// keeps them.
+func (i *Inspector) redact(h http.Header) {
+	if i.KeepCredentials {
+		return
+	}
+
+	for _, name := range credentialHeaders {
+		if values, ok := h[name]; ok {
+			for n := range values {
+				values[n] = redacted
+			}
+		}
+	}
+}
+
+// NewInspector creates an Inspector that keeps the last size requests and up
+// to bodySize bytes of each request and response body.
+func NewInspector(size, bodySize int) *Inspector {
+	if size <= 0 {
+		size = DefaultInspectorSize
+	}
+
+	if bodySize <= 0 {
+		bodySize = DefaultInspectorBodySize
+	}
+
+	return &Inspector{
+		captures: make([]*Capture, size),
+		bodySize: bodySize,
+	}
+}
+
+func (i *Inspector) begin(h *httpHandler, req *pb.Request) *Capture {
+	headers := make(http.Header)
+
+	for _, hdr := range req.Headers {
+		for _, v := range hdr.Value {
+			headers.Add(hdr.Name, v)
+		}
+	}
+
+	i.redact(headers)
+
+	return &Capture{
+		Start:          time.Now(),
+		Method:         req.Method,
+		Host:           req.Host,
+		Path:           req.Path,
+		Query:          req.Query,
+		RequestHeaders: headers,
+		req:            req,
+		handler:        h,
+		reqBody:        capBuffer{max: i.bodySize},
+		respBody:       capBuffer{max: i.bodySize},
+	}
+}
+
+// Completes c and adds it to the buffer, replacing the oldest capture if
+// it's full.
+func (i *Inspector) finish(c *Capture, resp *http.Response, err error) {
+	c.Duration = time.Since(c.Start)
+	c.RequestBody, c.RequestTruncated = c.reqBody.contents()
+
+	if resp != nil {
+		c.Status = resp.StatusCode
+		c.ResponseHeaders = resp.Header.Clone()
+		i.redact(c.ResponseHeaders)
+		c.ResponseBody, c.ResponseTruncated = c.respBody.contents()
+	}
+
+	if err != nil {
+		c.Error = err.Error()
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.lastID++
+	c.ID = i.lastID
+
+	i.captures[i.next] = c
+	i.next = (i.next + 1) % len(i.captures)
+}
+
+// Captures returns the recorded requests, newest first.
+func (i *Inspector) Captures() []*Capture {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	var out []*Capture
+
+	for n := 1; n <= len(i.captures); n++ {
+		c := i.captures[(i.next-n+len(i.captures))%len(i.captures)]
+		if c == nil {
+			break
+		}
+
+		out = append(out, c)
+	}
+
+	return out
+}
+
+// Capture returns the recorded request with the given id.
+func (i *Inspector) Capture(id uint64) (*Capture, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	for _, c := range i.captures {
+		if c != nil && c.ID == id {
+			return c, nil
+		}
+	}
+
+	return nil, ErrUnknownCapture
+}
+
+// Replay sends the request recorded as id to the service's upstream again.
+// The replay is recorded and returned as a new capture.
+func (i *Inspector) Replay(ctx context.Context, id uint64) (*Capture, error) {
+	orig, err := i.Capture(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if orig.RequestTruncated {
+		return nil, ErrNotReplayable
+	}
+
+	h := orig.handler
+
+	c := i.begin(h, orig.req)
+	c.ReplayOf = orig.ID
+	c.reqBody.Write(orig.RequestBody)
+
+	hreq, err := h.newRequest(ctx, orig.req, bytes.NewReader(orig.RequestBody))
+	if err != nil {
+		return nil, err
+	}
+
+	hresp, err := h.client.Do(hreq)
+	if err != nil {
+		i.finish(c, nil, err)
+		return c, nil
+	}
+
+	defer hresp.Body.Close()
+
+	io.Copy(&c.respBody, hresp.Body)
+
+	i.finish(c, hresp, nil)
+
+	return c, nil
+}
+
+// The subset of the HAR 1.2 format that we produce.
+type harLog struct {
+	Log struct {
+		Version string     `json:"version"`
+		Creator harCreator `json:"creator"`
+		Entries []harEntry `json:"entries"`
+	} `json:"log"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harEntry struct {
+	StartedDateTime time.Time   `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+	Comment         string      `json:"comment,omitempty"`
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	PostData    *harPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	Content     harContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+type harTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+func harHeaders(h http.Header) []harNameValue {
+	out := []harNameValue{}
+
+	for name, values := range h {
+		for _, v := range values {
+			out = append(out, harNameValue{Name: name, Value: v})
+		}
+	}
+
+	return out
+}
+
+// Returns body as HAR text, base64 encoding it if it isn't valid UTF-8.
+func harText(body []byte) (string, string) {
+	if utf8.Valid(body) {
+		return string(body), ""
+	}
+
+	return base64.StdEncoding.EncodeToString(body), "base64"
+}
+
+// HAR returns the recorded requests, oldest first, in the HAR 1.2 format.
+func (i *Inspector) HAR() ([]byte, error) {
+	var log harLog
+
+	log.Log.Version = "1.2"
+	log.Log.Creator = harCreator{Name: "hznagent", Version: "1"}
+	log.Log.Entries = []harEntry{}
+
+	captures := i.Captures()
+
+	for n := len(captures) - 1; n >= 0; n-- {
+		c := captures[n]
+
+		ms := float64(c.Duration) / float64(time.Millisecond)
+
+		e := harEntry{
+			StartedDateTime: c.Start,
+			Time:            ms,
+			Timings:         harTimings{Wait: ms},
+			Comment:         c.Error,
+		}
+
+		e.Request = harRequest{
+			Method:      c.Method,
+			URL:         c.URL(),
+			HTTPVersion: "HTTP/1.1",
+			Cookies:     []harNameValue{},
+			Headers:     harHeaders(c.RequestHeaders),
+			QueryString: []harNameValue{},
+			HeadersSize: -1,
+			BodySize:    len(c.RequestBody),
+		}
+
+		if len(c.RequestBody) > 0 {
+			text, _ := harText(c.RequestBody)
+
+			e.Request.PostData = &harPostData{
+				MimeType: c.RequestHeaders.Get("Content-Type"),
+				Text:     text,
+			}
+		}
+
+		for _, part := range strings.Split(c.Query, "&") {
+			if part == "" {
+				continue
+			}
+
+			nv := strings.SplitN(part, "=", 2)
+			q := harNameValue{Name: nv[0]}
+			if len(nv) == 2 {
+				q.Value = nv[1]
+			}
+
+			e.Request.QueryString = append(e.Request.QueryString, q)
+		}
+
+		text, encoding := harText(c.ResponseBody)
+
+		e.Response = harResponse{
+			Status:      c.Status,
+			StatusText:  http.StatusText(c.Status),
+			HTTPVersion: "HTTP/1.1",
+			Cookies:     []harNameValue{},
+			Headers:     harHeaders(c.ResponseHeaders),
+			HeadersSize: -1,
+			BodySize:    len(c.ResponseBody),
+			Content: harContent{
+				Size:     len(c.ResponseBody),
+				MimeType: c.ResponseHeaders.Get("Content-Type"),
+				Text:     text,
+				Encoding: encoding,
+			},
+		}
+
+		log.Log.Entries = append(log.Log.Entries, e)
+	}
+
+	return json.MarshalIndent(&log, "", "  ")
+}
+
+var inspectorTemplate = template.Must(template.New("inspector").Funcs(template.FuncMap{
+	"text": func(b []byte) string {
+		text, encoding := harText(b)
+		if encoding != "" {
+			return "(binary, base64) " + text
+		}
+
+		return text
+	},
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<title>hznagent inspector</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; width: 100%; }
+td, th { text-align: left; padding: 0.3em 0.6em; border-bottom: 1px solid #ddd; }
+pre { background: #f4f4f4; padding: 1em; overflow-x: auto; }
+.error { color: #b00; }
+</style>
+</head>
+<body>
+{{if .Capture}}{{with .Capture}}
+<p><a href="/">&larr; all requests</a></p>
+<h1>{{.Method}} {{.URL}}</h1>
+<p>{{.Start.Format "2006-01-02 15:04:05.000"}} &middot; {{.Duration}}{{if .ReplayOf}} &middot; replay of <a href="/requests/{{.ReplayOf}}">#{{.ReplayOf}}</a>{{end}}</p>
+{{if .Error}}<p class="error">{{.Error}}</p>{{end}}
+<form method="post" action="/requests/{{.ID}}/replay"><button{{if .RequestTruncated}} disabled{{end}}>Replay</button></form>
+<h2>Request</h2>
+<pre>{{range $k, $v := .RequestHeaders}}{{range $v}}{{$k}}: {{.}}
+{{end}}{{end}}</pre>
+{{if .RequestBody}}<pre>{{text .RequestBody}}{{if .RequestTruncated}}
+... (truncated){{end}}</pre>{{end}}
+<h2>Response {{.Status}}</h2>
+<pre>{{range $k, $v := .ResponseHeaders}}{{range $v}}{{$k}}: {{.}}
+{{end}}{{end}}</pre>
+{{if .ResponseBody}}<pre>{{text .ResponseBody}}{{if .ResponseTruncated}}
+... (truncated){{end}}</pre>{{end}}
+{{end}}{{else}}
+<h1>Requests</h1>
+<p><a href="/har">Download HAR</a> &middot; <a href="/api/requests">JSON</a></p>
+<table>
+<tr><th>#</th><th>Time</th><th>Method</th><th>URL</th><th>Status</th><th>Duration</th><th>Size</th><th></th></tr>
+{{range .Captures}}
+<tr>
+<td><a href="/requests/{{.ID}}">{{.ID}}</a></td>
+<td>{{.Start.Format "15:04:05.000"}}</td>
+<td>{{.Method}}</td>
+<td>{{.URL}}</td>
+<td>{{if .Error}}<span class="error">error</span>{{else}}{{.Status}}{{end}}</td>
+<td>{{.Duration}}</td>
+<td>{{len .ResponseBody}}{{if .ResponseTruncated}}+{{end}}</td>
+<td><form method="post" action="/requests/{{.ID}}/replay"><button{{if .RequestTruncated}} disabled{{end}}>Replay</button></form></td>
+</tr>
+{{end}}
+</table>
+{{end}}
+</body>
+</html>
+`))
+
+// Reports if a replay request came from the inspector's own UI. The Host must
+// be localhost or an IP, which defeats DNS rebinding, and a browser's Origin
+// must match it, which stops other sites from posting replays.
+func fromInspector(r *http.Request) bool {
+	host, _, err := net.SplitHostPort(r.Host)
+	if err != nil {
+		host = r.Host
+	}
+
+	if host != "localhost" && net.ParseIP(host) == nil {
+		return false
+	}
+
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+
+	return u.Host == r.Host
+}
+
+// Handler returns an http.Handler that serves a UI to browse and replay the
+// recorded requests, as well as the captures as JSON on /api/requests and as
+// a HAR file on /har.
+func (i *Inspector) Handler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		inspectorTemplate.Execute(w, map[string]interface{}{
+			"Captures": i.Captures(),
+		})
+	})
+
+	mux.HandleFunc("/requests/", func(w http.ResponseWriter, r *http.Request) {
+		rest := strings.TrimPrefix(r.URL.Path, "/requests/")
+
+		replay := strings.HasSuffix(rest, "/replay")
+		rest = strings.TrimSuffix(rest, "/replay")
+
+		id, err := strconv.ParseUint(rest, 10, 64)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		if replay {
+			if r.Method != "POST" {
+				http.Error(w, "replay requires POST", http.StatusMethodNotAllowed)
+				return
+			}
+
+			if !fromInspector(r) {
+				http.Error(w, "replay must come from the inspector", http.StatusForbidden)
+				return
+			}
+
+			c, err := i.Replay(r.Context(), id)
+			switch {
+			case err == ErrUnknownCapture:
+				http.NotFound(w, r)
+			case err != nil:
+				http.Error(w, err.Error(), http.StatusBadRequest)
+			default:
+				http.Redirect(w, r, "/requests/"+strconv.FormatUint(c.ID, 10), http.StatusSeeOther)
+			}
+
+			return
+		}
+
+		c, err := i.Capture(id)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		inspectorTemplate.Execute(w, map[string]interface{}{
+			"Capture": c,
+		})
+	})
+
+	mux.HandleFunc("/api/requests", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		enc.Encode(i.Captures())
+	})
+
+	mux.HandleFunc("/har", func(w http.ResponseWriter, r *http.Request) {
+		data, err := i.HAR()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Disposition", `attachment; filename="hzn-requests.har"`)
+		w.Write(data)
+	})
+
+	return mux
+}
@@ -0,0 +1,190 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/horizon/pkg/pb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInspector(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := ioutil.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte("echo: " + string(data)))
+	}))
+	defer upstream.Close()
+
+	// Simulates the handler serving a request without needing a hub.
+	record := func(t *testing.T, h *httpHandler, body string) {
+		req := &pb.Request{
+			Method: "POST",
+			Host:   "app.test",
+			Path:   "/submit",
+			Query:  "a=1",
+			Headers: []*pb.Header{
+				{Name: "Authorization", Value: []string{"Bearer secret"}},
+				{Name: "Accept", Value: []string{"text/plain"}},
+			},
+		}
+
+		c := h.inspector.begin(h, req)
+
+		hreq, err := h.newRequest(context.Background(), req, io.TeeReader(strings.NewReader(body), &c.reqBody))
+		require.NoError(t, err)
+
+		hresp, err := h.client.Do(hreq)
+		require.NoError(t, err)
+
+		defer hresp.Body.Close()
+
+		io.Copy(ioutil.Discard, io.TeeReader(hresp.Body, &c.respBody))
+
+		h.inspector.finish(c, hresp, nil)
+	}
+
+	t.Run("keeps the most recent requests with capped bodies", func(t *testing.T) {
+		insp := NewInspector(2, 8)
+
+		sh, err := NewHTTPHandler(upstream.URL, &HTTPOptions{Inspector: insp})
+		require.NoError(t, err)
+
+		h := sh.(*httpHandler)
+
+		record(t, h, "one")
+		record(t, h, "two")
+		record(t, h, "three is long")
+
+		captures := insp.Captures()
+		require.Len(t, captures, 2)
+
+		assert.Equal(t, uint64(3), captures[0].ID)
+		assert.Equal(t, uint64(2), captures[1].ID)
+
+		assert.Equal(t, "three is", string(captures[0].RequestBody))
+		assert.True(t, captures[0].RequestTruncated)
+
+		assert.Equal(t, "echo: tw", string(captures[1].ResponseBody))
+		assert.True(t, captures[1].ResponseTruncated)
+		assert.Equal(t, 200, captures[1].Status)
+
+		_, err = insp.Replay(context.Background(), 3)
+		assert.Equal(t, ErrNotReplayable, err)
+	})
+
+	t.Run("replays captured requests", func(t *testing.T) {
+		insp := NewInspector(10, 0)
+
+		sh, err := NewHTTPHandler(upstream.URL, &HTTPOptions{Inspector: insp})
+		require.NoError(t, err)
+
+		record(t, sh.(*httpHandler), "hello")
+
+		c, err := insp.Replay(context.Background(), 1)
+		require.NoError(t, err)
+
+		assert.Equal(t, uint64(2), c.ID)
+		assert.Equal(t, uint64(1), c.ReplayOf)
+		assert.Equal(t, "echo: hello", string(c.ResponseBody))
+	})
+
+	t.Run("exports captures as HAR", func(t *testing.T) {
+		insp := NewInspector(10, 0)
+
+		sh, err := NewHTTPHandler(upstream.URL, &HTTPOptions{Inspector: insp})
+		require.NoError(t, err)
+
+		record(t, sh.(*httpHandler), "hello")
+
+		s := httptest.NewServer(insp.Handler())
+		defer s.Close()
+
+		resp, err := http.Get(s.URL + "/har")
+		require.NoError(t, err)
+
+		defer resp.Body.Close()
+
+		var har harLog
+
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&har))
+
+		require.Len(t, har.Log.Entries, 1)
+
+		e := har.Log.Entries[0]
+		assert.Equal(t, "http://app.test/submit?a=1", e.Request.URL)
+		assert.Equal(t, "hello", e.Request.PostData.Text)
+		assert.Equal(t, 200, e.Response.Status)
+		assert.Equal(t, "echo: hello", e.Response.Content.Text)
+	})
+
+	t.Run("redacts credentials unless asked to keep them", func(t *testing.T) {
+		insp := NewInspector(10, 0)
+
+		sh, err := NewHTTPHandler(upstream.URL, &HTTPOptions{Inspector: insp})
+		require.NoError(t, err)
+
+		record(t, sh.(*httpHandler), "hello")
+
+		c := insp.Captures()[0]
+		assert.Equal(t, "[redacted]", c.RequestHeaders.Get("Authorization"))
+		assert.Equal(t, "text/plain", c.RequestHeaders.Get("Accept"))
+
+		insp.KeepCredentials = true
+
+		record(t, sh.(*httpHandler), "hello")
+
+		c = insp.Captures()[0]
+		assert.Equal(t, "Bearer secret", c.RequestHeaders.Get("Authorization"))
+	})
+
+	t.Run("only replays requests from the inspector", func(t *testing.T) {
+		insp := NewInspector(10, 0)
+
+		sh, err := NewHTTPHandler(upstream.URL, &HTTPOptions{Inspector: insp})
+		require.NoError(t, err)
+
+		record(t, sh.(*httpHandler), "hello")
+
+		s := httptest.NewServer(insp.Handler())
+		defer s.Close()
+
+		client := &http.Client{
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		}
+
+		replay := func(host, origin string) int {
+			req, err := http.NewRequest("POST", s.URL+"/requests/1/replay", nil)
+			require.NoError(t, err)
+
+			if host != "" {
+				req.Host = host
+			}
+
+			if origin != "" {
+				req.Header.Set("Origin", origin)
+			}
+
+			resp, err := client.Do(req)
+			require.NoError(t, err)
+
+			resp.Body.Close()
+
+			return resp.StatusCode
+		}
+
+		assert.Equal(t, http.StatusForbidden, replay("", "http://evil.example"))
+		assert.Equal(t, http.StatusForbidden, replay("evil.example", ""))
+		assert.Equal(t, http.StatusSeeOther, replay("", s.URL))
+		assert.Equal(t, http.StatusSeeOther, replay("", ""))
+	})
+}
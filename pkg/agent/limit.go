@@ -0,0 +1,125 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/armon/go-metrics"
+	"github.com/hashicorp/horizon/pkg/pb"
+)
+
+// How long a stream waits for a service at its MaxConcurrent limit if the
+// service doesn't set a QueueTimeout.
+const DefaultQueueTimeout = 10 * time.Second
+
+var ErrServiceBusy = errors.New("service is at capacity")
+
+// Bounds how many streams a service handles at once, queueing a limited
+// number of extra streams until one of the running ones finishes.
+type limiter struct {
+	slots    chan struct{}
+	queued   int64
+	maxQueue int64
+	timeout  time.Duration
+	labels   []metrics.Label
+}
+
+func newLimiter(serv *Service) *limiter {
+	if serv.MaxConcurrent <= 0 {
+		return nil
+	}
+
+	timeout := serv.QueueTimeout
+	if timeout <= 0 {
+		timeout = DefaultQueueTimeout
+	}
+
+	return &limiter{
+		slots:    make(chan struct{}, serv.MaxConcurrent),
+		maxQueue: int64(serv.MaxQueue),
+		timeout:  timeout,
+		labels:   serv.metricLabels(),
+	}
+}
+
+// Waits for the stream to be allowed to run, returning ErrServiceBusy if the
+// queue is full or the stream waited for too long. release must be called
+// when the stream is done if acquire returns nil.
+func (l *limiter) acquire(ctx context.Context) error {
+	select {
+	case l.slots <- struct{}{}:
+		return nil
+	default:
+	}
+
+	depth := atomic.AddInt64(&l.queued, 1)
+	defer l.dequeue()
+
+	if depth > l.maxQueue {
+		return ErrServiceBusy
+	}
+
+	l.emitDepth(depth)
+
+	timer := time.NewTimer(l.timeout)
+	defer timer.Stop()
+
+	select {
+	case l.slots <- struct{}{}:
+		return nil
+	case <-timer.C:
+		return ErrServiceBusy
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (l *limiter) dequeue() {
+	l.emitDepth(atomic.AddInt64(&l.queued, -1))
+}
+
+func (l *limiter) release() {
+	<-l.slots
+}
+
+func (l *limiter) depth() int64 {
+	return atomic.LoadInt64(&l.queued)
+}
+
+func (l *limiter) emitDepth(depth int64) {
+	if depth > l.maxQueue {
+		depth = l.maxQueue
+	}
+
+	metrics.SetGaugeWithLabels([]string{"service", "queue", "depth"}, float32(depth), l.labels)
+}
+
+// Replies to a stream the service can't take right now. HTTP requests get a
+// 503 so the client sees a normal response, anything else gets an error.
+func refuseStream(sctx *serviceContext, err error) error {
+	if sctx.protocolId == "http" {
+		var resp pb.Response
+		resp.Code = http.StatusServiceUnavailable
+		resp.Headers = []*pb.Header{
+			{
+				Name:  "Retry-After",
+				Value: []string{"1"},
+			},
+		}
+
+		err := sctx.WriteMarshal(1, &resp)
+		if err != nil {
+			return err
+		}
+
+		return sctx.Writer().Close()
+	}
+
+	var resp pb.Response
+	resp.Error = err.Error()
+
+	return sctx.WriteMarshal(255, &resp)
+}
@@ -0,0 +1,75 @@
+package agent
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/horizon/pkg/pb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimiter(t *testing.T) {
+	newService := func() *Service {
+		return &Service{
+			Id:            pb.NewULID(),
+			Type:          "test",
+			MaxConcurrent: 1,
+			MaxQueue:      1,
+			QueueTimeout:  100 * time.Millisecond,
+		}
+	}
+
+	t.Run("no limiter without MaxConcurrent", func(t *testing.T) {
+		assert.Nil(t, newLimiter(&Service{Id: pb.NewULID()}))
+	})
+
+	t.Run("queued streams run once a slot is released", func(t *testing.T) {
+		l := newLimiter(newService())
+
+		ctx := context.Background()
+
+		err := l.acquire(ctx)
+		require.NoError(t, err)
+
+		acquired := make(chan error)
+
+		go func() {
+			acquired <- l.acquire(ctx)
+		}()
+
+		time.Sleep(10 * time.Millisecond)
+
+		assert.Equal(t, int64(1), l.depth())
+
+		l.release()
+
+		require.NoError(t, <-acquired)
+
+		assert.Equal(t, int64(0), l.depth())
+	})
+
+	t.Run("refuses streams when the queue is full", func(t *testing.T) {
+		l := newLimiter(newService())
+
+		ctx := context.Background()
+
+		err := l.acquire(ctx)
+		require.NoError(t, err)
+
+		queued := make(chan error)
+
+		go func() {
+			queued <- l.acquire(ctx)
+		}()
+
+		time.Sleep(10 * time.Millisecond)
+
+		err = l.acquire(ctx)
+		assert.Equal(t, ErrServiceBusy, err)
+
+		// The queued stream times out since the slot is never released.
+		assert.Equal(t, ErrServiceBusy, <-queued)
+	})
+}
@@ -0,0 +1,92 @@
+package agent
+
+import (
+	"io"
+	"sync/atomic"
+	"time"
+
+	"github.com/armon/go-metrics"
+	"github.com/hashicorp/horizon/pkg/discovery"
+)
+
+// The agent emits its metrics through the global go-metrics instance, the
+// same as pkg/connect, so they go wherever the process has configured.
+
+func hubLabels(cfg discovery.HubConfig) []metrics.Label {
+	return []metrics.Label{
+		{
+			Name:  "hub",
+			Value: cfg.Addr,
+		},
+	}
+}
+
+func (s *Service) metricLabels() []metrics.Label {
+	var labels string
+	if s.Labels != nil {
+		labels = s.Labels.SpecString()
+	}
+
+	return []metrics.Label{
+		{
+			Name:  "service",
+			Value: s.Id.SpecString(),
+		},
+		{
+			Name:  "type",
+			Value: s.Type,
+		},
+		{
+			Name:  "labels",
+			Value: labels,
+		},
+	}
+}
+
+// Records a successful handshake with a hub. Must be called with a.mu held.
+func (a *Agent) hubConnectedMetrics(cfg discovery.HubConfig, latency, skew time.Duration) {
+	labels := hubLabels(cfg)
+
+	metrics.SetGaugeWithLabels([]string{"hub", "connected"}, 1, labels)
+	metrics.AddSampleWithLabels([]string{"hub", "handshake_latency"}, float32(latency.Seconds()*1000), labels)
+	metrics.SetGaugeWithLabels([]string{"hub", "skew"}, float32(skew.Seconds()*1000), labels)
+	metrics.SetGauge([]string{"hubs", "connected"}, float32(len(a.sessions)))
+}
+
+// Records that a session with a hub has ended. Must be called with a.mu held.
+func (a *Agent) hubDisconnectedMetrics(cfg discovery.HubConfig) {
+	labels := hubLabels(cfg)
+
+	metrics.SetGaugeWithLabels([]string{"hub", "connected"}, 0, labels)
+	metrics.IncrCounterWithLabels([]string{"hub", "disconnects"}, 1, labels)
+	metrics.SetGauge([]string{"hubs", "connected"}, float32(len(a.sessions)))
+}
+
+func (s *serviceStats) emitActive(labels []metrics.Label) {
+	metrics.SetGaugeWithLabels([]string{"service", "streams", "active"}, float32(atomic.LoadInt64(&s.active)), labels)
+}
+
+// Counts the bytes read from a stream, so they can be reported once the
+// stream is done rather than on every read.
+type countingReader struct {
+	io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(b []byte) (int, error) {
+	n, err := c.Reader.Read(b)
+	atomic.AddInt64(&c.n, int64(n))
+	return n, err
+}
+
+// Counts the bytes written to a stream.
+type countingWriter struct {
+	io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(b []byte) (int, error) {
+	n, err := c.Writer.Write(b)
+	atomic.AddInt64(&c.n, int64(n))
+	return n, err
+}
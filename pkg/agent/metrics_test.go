@@ -0,0 +1,117 @@
+package agent
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/armon/go-metrics"
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/horizon/pkg/pb"
+	"github.com/hashicorp/horizon/pkg/wire"
+	"github.com/hashicorp/yamux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetrics(t *testing.T) {
+	sink := metrics.NewInmemSink(time.Minute, time.Minute)
+
+	mcfg := metrics.DefaultConfig("test")
+	mcfg.EnableHostname = false
+	mcfg.EnableRuntimeMetrics = false
+
+	_, err := metrics.NewGlobal(mcfg, sink)
+	require.NoError(t, err)
+
+	L := hclog.NewNullLogger()
+
+	agent, err := NewAgent(L)
+	require.NoError(t, err)
+
+	id, err := agent.AddService(&Service{
+		Type:    "test",
+		Labels:  pb.ParseLabelSet("service=echo"),
+		Handler: EchoHandler(),
+	})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	c1, c2 := net.Pipe()
+
+	server, err := yamux.Server(c1, nil)
+	require.NoError(t, err)
+
+	defer server.Close()
+
+	client, err := yamux.Client(c2, nil)
+	require.NoError(t, err)
+
+	defer client.Close()
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		stream, err := server.AcceptStream()
+		if err != nil {
+			return
+		}
+
+		agent.handleStream(ctx, L, server, stream, false)
+	}()
+
+	stream, err := client.OpenStream()
+	require.NoError(t, err)
+
+	fw, err := wire.NewFramingWriter(stream)
+	require.NoError(t, err)
+
+	fr, err := wire.NewFramingReader(stream)
+	require.NoError(t, err)
+
+	_, err = fw.WriteMarshal(11, &pb.SessionIdentification{ServiceId: id})
+	require.NoError(t, err)
+
+	mb := wire.MarshalBytes("hello hzn")
+
+	_, err = fw.WriteMarshal(30, &mb)
+	require.NoError(t, err)
+
+	var mb2 wire.MarshalBytes
+
+	_, _, err = fr.ReadMarshal(&mb2)
+	require.NoError(t, err)
+
+	stream.Close()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		t.Fatal("stream was not handled")
+	}
+
+	counters := map[string]float64{}
+	gauges := map[string]float32{}
+
+	for _, interval := range sink.Data() {
+		for key, c := range interval.Counters {
+			counters[strings.SplitN(key, ";", 2)[0]] += c.Sum
+		}
+
+		for key, g := range interval.Gauges {
+			gauges[strings.SplitN(key, ";", 2)[0]] = g.Value
+		}
+	}
+
+	assert.Equal(t, float64(1), counters["test.service.streams.total"])
+	assert.True(t, counters["test.service.bytes_in"] > 0)
+	assert.True(t, counters["test.service.bytes_out"] > 0)
+	assert.Equal(t, float64(0), counters["test.service.errors"])
+	assert.Equal(t, float32(0), gauges["test.service.streams.active"])
+}
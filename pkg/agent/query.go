@@ -1,37 +1,59 @@
 package agent
 
 import (
+	"net"
+
+	"github.com/hashicorp/horizon/pkg/pb"
 	"github.com/hashicorp/horizon/pkg/wire"
+	"github.com/hashicorp/yamux"
+	"github.com/pierrec/lz4/v3"
 )
 
+// RPCClient returns a client for making a single call to one of the agent's
+// hubs.
 func (a *Agent) RPCClient() (*wire.RPCClient, error) {
-	a.mu.Lock()
-	stream, err := a.sessions[0].OpenStream()
-	a.mu.Unlock()
+	_, rpc, err := a.openRPC()
+	return rpc, err
+}
 
-	if err != nil {
-		return nil, err
+func (a *Agent) openRPC() (*yamux.Stream, *wire.RPCClient, error) {
+	sessions := a.pickSessions()
+	if len(sessions) == 0 {
+		return nil, nil, ErrNoHubSessions
 	}
 
-	return wire.NewRPCClient(stream), nil
-}
+	var lastErr error
 
-/*
+	for _, session := range sessions {
+		stream, err := session.OpenStream()
+		if err == nil {
+			return stream, wire.NewRPCClient(lz4.NewReader(stream), lz4.NewWriter(stream), stream), nil
+		}
+
+		lastErr = err
+	}
+
+	return nil, nil, lastErr
+}
 
-func (a *Agent) QueryPeerService(labels []string) ([]*agents.Service, error) {
+// QueryPeerService returns the services in the agent's account that match
+// labels, including the agent's own. If serviceType is set, only services of
+// that type are returned.
+func (a *Agent) QueryPeerService(labels *pb.LabelSet, serviceType string) ([]*pb.Service, error) {
 	rpc, err := a.RPCClient()
 	if err != nil {
 		return nil, err
 	}
 
 	var (
-		req  agents.QueryRequest
-		resp agents.QueryResponse
+		req  pb.ConnectRequest
+		resp pb.ListServicesResponse
 	)
 
-	req.Labels = labels
+	req.Target = labels
+	req.Type = serviceType
 
-	err = rpc.Call("agents.edge", "/query/peers", &req, &resp)
+	err = rpc.Call("agents.edge", wire.RPCQueryPeers, &req, &resp)
 	if err != nil {
 		return nil, err
 	}
@@ -39,21 +61,43 @@ func (a *Agent) QueryPeerService(labels []string) ([]*agents.Service, error) {
 	return resp.Services, nil
 }
 
-func (a *Agent) ConnectToPeer(serv *agents.Service) (wire.Context, error) {
-	rpc, err := a.RPCClient()
+// ConnectToPeer connects to serv, as returned by QueryPeerService. Unlike
+// Connect, which reaches any service matching some labels, the connection is
+// made to this specific service.
+func (a *Agent) ConnectToPeer(serv *pb.Service) (net.Conn, error) {
+	stream, rpc, err := a.openRPC()
 	if err != nil {
 		return nil, err
 	}
 
-	var req agents.ConnectRequest
-	req.Target = serv
+	var req pb.SessionIdentification
+	req.ServiceId = serv.Id
 
-	wctx, err := rpc.Begin("agents.edge", "/connect/peer", &req)
+	wctx, err := rpc.Begin("agents.edge", wire.RPCConnectPeer, &req)
 	if err != nil {
+		stream.Close()
 		return nil, err
 	}
 
-	return wctx, nil
-}
+	var ack pb.ConnectAck
 
-*/
+	// A hub that can't connect us replies with a pb.Response on tag 255,
+	// which comes back as a *wire.RemoteError with the hub's error text.
+	tag, err := wctx.ReadMarshal(&ack)
+	if err != nil {
+		stream.Close()
+		return nil, err
+	}
+
+	if tag != 1 {
+		stream.Close()
+		return nil, wire.ErrProtocolError
+	}
+
+	return &Conn{
+		Reader:      wctx.Reader(),
+		WriteCloser: wctx.Writer(),
+		Stream:      stream,
+		Labels:      serv.Labels,
+	}, nil
+}
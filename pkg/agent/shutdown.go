@@ -0,0 +1,92 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/hashicorp/horizon/pkg/wire"
+)
+
+var ErrShutdown = errors.New("agent is shutting down")
+
+// How often Shutdown checks if the active streams have finished.
+var drainPollInterval = 100 * time.Millisecond
+
+func (a *Agent) isDraining() bool {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	return a.draining
+}
+
+// Shutdown gracefully stops the agent. The agent's services are withdrawn from
+// every hub so that no new streams are routed to it, then Shutdown waits for
+// the streams already being handled to finish or for ctx to be done,
+// whichever comes first. Finally all hub sessions are closed, which causes
+// Wait to return.
+func (a *Agent) Shutdown(ctx context.Context) error {
+	a.mu.Lock()
+
+	if a.draining {
+		a.mu.Unlock()
+		return nil
+	}
+
+	a.draining = true
+
+	var services []*Service
+
+	for key, serv := range a.services {
+		if a.isAdvertised(key) {
+			services = append(services, serv)
+		}
+	}
+
+	// Stop the health checks so they don't re-advertise anything.
+	for _, cancel := range a.checks {
+		cancel()
+	}
+
+	sessions := a.liveSessions()
+
+	a.mu.Unlock()
+
+	a.L.Info("shutting down, withdrawing services from hubs", "services", len(services), "hubs", len(sessions))
+
+	// Waiting on each service's update lock lets any update that was being
+	// sent finish first, so the removal is the last thing the hubs hear.
+	for _, serv := range services {
+		serv.updates.Lock()
+		a.updateHubs(sessions, wire.TagServiceRemove, serv.info())
+		serv.updates.Unlock()
+	}
+
+	var err error
+
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+
+drain:
+	for atomic.LoadInt64(a.activeStreams) > 0 {
+		select {
+		case <-ctx.Done():
+			a.L.Warn("streams still active at the end of the drain, closing them",
+				"active", atomic.LoadInt64(a.activeStreams))
+			err = ctx.Err()
+			break drain
+		case <-ticker.C:
+		}
+	}
+
+	a.L.Info("closing hub sessions")
+
+	for _, sess := range sessions {
+		sess.Close()
+	}
+
+	close(a.shutdown)
+
+	return err
+}
@@ -0,0 +1,158 @@
+package agent
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+type serviceStats struct {
+	active int64
+	total  int64
+	errors int64
+}
+
+func (s *serviceStats) begin() {
+	atomic.AddInt64(&s.active, 1)
+	atomic.AddInt64(&s.total, 1)
+}
+
+func (s *serviceStats) end() {
+	atomic.AddInt64(&s.active, -1)
+}
+
+func (s *serviceStats) failed() {
+	atomic.AddInt64(&s.errors, 1)
+}
+
+// Status is a snapshot of what the agent is doing.
+type Status struct {
+	Hubs     []HubStatus     `json:"hubs"`
+	Services []ServiceStatus `json:"services"`
+
+	ActiveStreams int64 `json:"active_streams"`
+	TotalStreams  int64 `json:"total_streams"`
+	StreamErrors  int64 `json:"stream_errors"`
+
+	Draining bool `json:"draining"`
+}
+
+// HubStatus describes the agent's session with one hub.
+type HubStatus struct {
+	Addr        string    `json:"addr"`
+	Name        string    `json:"name"`
+	ConnectedAt time.Time `json:"connected_at"`
+
+	// The round trip time and clock skew measured during the handshake.
+	Latency time.Duration `json:"latency"`
+	Skew    time.Duration `json:"skew"`
+
+	// Set once the hub has asked the agent to move to another hub, to when
+	// the hub will close the session.
+	ClosingAt *time.Time `json:"closing_at,omitempty"`
+}
+
+// ServiceStatus describes one service the agent has registered.
+type ServiceStatus struct {
+	Id       string            `json:"id"`
+	Type     string            `json:"type"`
+	Labels   string            `json:"labels"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+
+	// False when the service has been withdrawn because it's unhealthy.
+	Advertised bool          `json:"advertised"`
+	Health     *HealthStatus `json:"health,omitempty"`
+
+	ActiveStreams int64 `json:"active_streams"`
+	TotalStreams  int64 `json:"total_streams"`
+	StreamErrors  int64 `json:"stream_errors"`
+
+	// Streams waiting for the service to be below its MaxConcurrent limit.
+	QueuedStreams int64 `json:"queued_streams,omitempty"`
+}
+
+// Status returns a snapshot of the agent's hubs, services, and stream
+// counters.
+func (a *Agent) Status() *Status {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	st := &Status{
+		ActiveStreams: atomic.LoadInt64(a.activeStreams),
+		TotalStreams:  atomic.LoadInt64(a.totalStreams),
+		StreamErrors:  atomic.LoadInt64(a.streamErrors),
+		Draining:      a.draining,
+	}
+
+	for _, hs := range a.hubs {
+		hst := HubStatus{
+			Addr:        hs.cfg.Addr,
+			Name:        hs.cfg.Name,
+			ConnectedAt: hs.connectedAt,
+			Latency:     hs.latency,
+			Skew:        hs.skew,
+		}
+
+		if !hs.closingAt.IsZero() {
+			closingAt := hs.closingAt
+			hst.ClosingAt = &closingAt
+		}
+
+		st.Hubs = append(st.Hubs, hst)
+	}
+
+	sort.Slice(st.Hubs, func(i, j int) bool {
+		return st.Hubs[i].Addr < st.Hubs[j].Addr
+	})
+
+	for key, serv := range a.services {
+		ss := ServiceStatus{
+			Id:            key,
+			Type:          serv.Type,
+			Metadata:      serv.Metadata,
+			Advertised:    a.isAdvertised(key),
+			ActiveStreams: atomic.LoadInt64(&serv.stats.active),
+			TotalStreams:  atomic.LoadInt64(&serv.stats.total),
+			StreamErrors:  atomic.LoadInt64(&serv.stats.errors),
+		}
+
+		if serv.Labels != nil {
+			ss.Labels = serv.Labels.SpecString()
+		}
+
+		if serv.limit != nil {
+			ss.QueuedStreams = serv.limit.depth()
+		}
+
+		if hs, ok := a.health[key]; ok {
+			health := *hs
+			ss.Health = &health
+		}
+
+		st.Services = append(st.Services, ss)
+	}
+
+	sort.Slice(st.Services, func(i, j int) bool {
+		return st.Services[i].Id < st.Services[j].Id
+	})
+
+	return st
+}
+
+// AdminHandler returns an http.Handler that serves the agent's status as JSON
+// on /status.
+func (a *Agent) AdminHandler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		enc.Encode(a.Status())
+	})
+
+	return mux
+}
@@ -0,0 +1,48 @@
+package agent
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/horizon/pkg/pb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatus(t *testing.T) {
+	agent, err := NewAgent(hclog.NewNullLogger())
+	require.NoError(t, err)
+
+	id, err := agent.AddService(&Service{
+		Type:     "test",
+		Labels:   pb.ParseLabelSet("service=echo"),
+		Metadata: map[string]string{"version": "1"},
+		Handler:  EchoHandler(),
+	})
+	require.NoError(t, err)
+
+	s := httptest.NewServer(agent.AdminHandler())
+	defer s.Close()
+
+	resp, err := s.Client().Get(s.URL + "/status")
+	require.NoError(t, err)
+
+	defer resp.Body.Close()
+
+	var st Status
+
+	err = json.NewDecoder(resp.Body).Decode(&st)
+	require.NoError(t, err)
+
+	assert.Equal(t, 0, len(st.Hubs))
+	require.Equal(t, 1, len(st.Services))
+
+	serv := st.Services[0]
+	assert.Equal(t, id.SpecString(), serv.Id)
+	assert.Equal(t, "service=echo", serv.Labels)
+	assert.Equal(t, "1", serv.Metadata["version"])
+	assert.True(t, serv.Advertised)
+	assert.Nil(t, serv.Health)
+}
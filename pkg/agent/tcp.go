@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 	"io"
-	"net"
 	"sync"
 
 	"github.com/hashicorp/go-hclog"
@@ -15,6 +14,8 @@ type tcpHandler struct {
 	addr string
 }
 
+// TCPHandler returns a ServiceHandler that bridges streams to the server at
+// addr, which is either a host:port or unix:///path for a unix domain socket.
 func TCPHandler(addr string) ServiceHandler {
 	return &tcpHandler{addr}
 }
@@ -27,7 +28,7 @@ func (h *tcpHandler) HandleRequest(ctx context.Context, L hclog.Logger, sctx Ser
 		return fmt.Errorf("unknown protocol: %s", proto)
 	}
 
-	c, err := net.Dial("tcp", h.addr)
+	c, err := dialUpstream(ctx, h.addr)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,153 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/horizon/pkg/pb"
+	"github.com/hashicorp/horizon/pkg/wire"
+	"github.com/hashicorp/yamux"
+	"github.com/pkg/errors"
+)
+
+// Each UDP datagram is transmitted as a single frame with this tag, which
+// preserves the message boundaries across the stream.
+const udpDatagramTag = 50
+
+// The largest datagram we'll read from a UDP socket.
+const maxDatagramSize = 64 * 1024
+
+type udpHandler struct {
+	addr string
+}
+
+// UDPHandler returns a ServiceHandler that forwards datagrams to and from
+// the UDP server at addr.
+func UDPHandler(addr string) ServiceHandler {
+	return &udpHandler{addr}
+}
+
+func (h *udpHandler) HandleRequest(ctx context.Context, L hclog.Logger, sctx ServiceContext) error {
+	defer sctx.Close()
+	proto := sctx.ProtocolId()
+
+	if proto != "udp" {
+		return fmt.Errorf("unknown protocol: %s", proto)
+	}
+
+	c, err := net.Dial("udp", h.addr)
+	if err != nil {
+		return err
+	}
+
+	defer c.Close()
+
+	id := pb.NewULID()
+
+	L.Trace("udp session started", "id", id, "addr", h.addr, "session-addr", c.LocalAddr())
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+
+		buf := make([]byte, maxDatagramSize)
+
+		for {
+			n, err := c.Read(buf)
+			if err != nil {
+				return
+			}
+
+			mb := wire.MarshalBytes(buf[:n])
+
+			err = sctx.WriteMarshal(udpDatagramTag, &mb)
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	var mb wire.MarshalBytes
+
+	for {
+		tag, err := sctx.ReadMarshal(&mb)
+		if err != nil {
+			break
+		}
+
+		if tag != udpDatagramTag {
+			L.Error("unexpected tag in udp session", "tag", tag)
+			break
+		}
+
+		_, err = c.Write(mb)
+		if err != nil {
+			L.Error("error writing datagram", "error", err)
+			break
+		}
+	}
+
+	// Unblock the reader goroutine.
+	c.Close()
+
+	wg.Wait()
+
+	L.Trace("udp session ended", "id", id)
+
+	return nil
+}
+
+// DatagramConn is a connection to a UDP service, where each Send and Recv
+// transfers exactly one datagram.
+type DatagramConn struct {
+	Stream *yamux.Stream
+	Labels *pb.LabelSet
+
+	wctx wire.Context
+}
+
+// ConnectUDP connects to a udp service matching labels.
+func (a *Agent) ConnectUDP(labels *pb.LabelSet) (*DatagramConn, error) {
+	stream, wctx, err := a.connect(labels, "udp")
+	if err != nil {
+		return nil, err
+	}
+
+	return &DatagramConn{Stream: stream, Labels: labels, wctx: wctx}, nil
+}
+
+// Send transmits b as one datagram.
+func (d *DatagramConn) Send(b []byte) error {
+	mb := wire.MarshalBytes(b)
+	return d.wctx.WriteMarshal(udpDatagramTag, &mb)
+}
+
+// Recv returns the next datagram sent by the service.
+func (d *DatagramConn) Recv() ([]byte, error) {
+	var mb wire.MarshalBytes
+
+	tag, err := d.wctx.ReadMarshal(&mb)
+	if err != nil {
+		return nil, err
+	}
+
+	if tag != udpDatagramTag {
+		return nil, errors.Wrapf(wire.ErrProtocolError, "unexpected tag: %d", tag)
+	}
+
+	return mb, nil
+}
+
+// Accounting returns the number of datagrams and bytes sent on the connection.
+func (d *DatagramConn) Accounting() (int64, int64) {
+	return d.wctx.Accounting()
+}
+
+func (d *DatagramConn) Close() error {
+	return d.Stream.Close()
+}
@@ -0,0 +1,77 @@
+package agent
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"net"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Splits an upstream address into the network and address to dial. Addresses
+// of the form unix:///path refer to a unix domain socket, anything else is
+// a TCP host:port.
+func upstreamNetwork(target string) (string, string) {
+	if strings.HasPrefix(target, "unix://") {
+		return "unix", strings.TrimPrefix(target, "unix://")
+	}
+
+	return "tcp", target
+}
+
+func dialUpstream(ctx context.Context, target string) (net.Conn, error) {
+	var dialer net.Dialer
+
+	network, addr := upstreamNetwork(target)
+
+	return dialer.DialContext(ctx, network, addr)
+}
+
+// UpstreamTLS describes how to connect to an upstream that requires TLS.
+type UpstreamTLS struct {
+	// A PEM file of CA certificates used to verify the upstream. The system
+	// roots are used if not set.
+	CAFile string `json:"ca_file"`
+
+	// The name to send via SNI and to verify the upstream's certificate
+	// against. Defaults to the host in the upstream URL.
+	ServerName string `json:"server_name"`
+
+	// A client certificate and key to present to the upstream.
+	CertFile string `json:"cert_file"`
+	KeyFile  string `json:"key_file"`
+}
+
+// Config loads the files referenced by u and returns the TLS configuration
+// to use with the upstream.
+func (u *UpstreamTLS) Config() (*tls.Config, error) {
+	var cfg tls.Config
+
+	cfg.ServerName = u.ServerName
+
+	if u.CAFile != "" {
+		data, err := ioutil.ReadFile(u.CAFile)
+		if err != nil {
+			return nil, err
+		}
+
+		cfg.RootCAs = x509.NewCertPool()
+		if !cfg.RootCAs.AppendCertsFromPEM(data) {
+			return nil, errors.Errorf("no certificates found in %s", u.CAFile)
+		}
+	}
+
+	if u.CertFile != "" || u.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(u.CertFile, u.KeyFile)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error loading client certificate")
+		}
+
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	return &cfg, nil
+}
@@ -20,24 +20,33 @@ type Session struct {
 }
 
 type Conn struct {
+	stream    *yamux.Stream
 	serviceId *pb.ULID
 	fr        *wire.FramingReader
 	fw        *wire.FramingWriter
 }
 
-var ErrInvalidToken = errors.New("invalid token")
+var (
+	ErrInvalidToken = errors.New("invalid token")
+	ErrHubDraining  = errors.New("hub is draining")
+	ErrOverQuota    = errors.New("account over quota")
+)
 
 // We're trying this because in a busy system it could be a limiting
 // factor so having it pre-tracked will be useful.
 // TODO(emp): expose this via expvar or something like that.
 var activeSessions = new(int64)
 
-func Connect(L hclog.Logger, addr, token string) (*Session, error) {
-	var clientTlsConfig tls.Config
-	clientTlsConfig.InsecureSkipVerify = true
-	clientTlsConfig.NextProtos = []string{"hzn"}
+// Connect establishes a session with the hub at addr. The hub's certificate
+// is verified using tlsCfg, which is usually created by HubTLSConfig. If
+// tlsCfg is nil, the certificate is verified against the system roots using
+// the host in addr.
+func Connect(L hclog.Logger, addr, token string, tlsCfg *tls.Config) (*Session, error) {
+	if tlsCfg == nil {
+		tlsCfg = HubTLSConfig("", nil, nil)
+	}
 
-	cconn, err := tls.Dial("tcp", addr, &clientTlsConfig)
+	cconn, err := tls.Dial("tcp", addr, tlsCfg)
 	if err != nil {
 		return nil, err
 	}
@@ -67,7 +76,14 @@ func Connect(L hclog.Logger, addr, token string) (*Session, error) {
 		return nil, err
 	}
 
-	if confirmation.Status != "connected" {
+	switch confirmation.Status {
+	case "connected":
+		// ok
+	case "draining":
+		return nil, ErrHubDraining
+	case "over-quota":
+		return nil, ErrOverQuota
+	default:
 		return nil, ErrInvalidToken
 	}
 
@@ -104,12 +120,47 @@ func (s *Session) Close() error {
 	return s.conn.Close()
 }
 
+// IsClosed returns true once the session has been closed, either by Close or
+// because the connection to the hub failed.
+func (s *Session) IsClosed() bool {
+	return s.session.IsClosed()
+}
+
+// Ping checks that the hub is still responding, returning the round trip time.
+func (s *Session) Ping() (time.Duration, error) {
+	return s.session.Ping()
+}
+
+// NumStreams returns the number of streams open on the session.
+func (s *Session) NumStreams() int {
+	return s.session.NumStreams()
+}
+
 func (s *Session) ConnecToAccountService(acc *pb.Account, labels *pb.LabelSet) (*Conn, error) {
+	return s.ConnectWithRequest(&pb.ConnectRequest{
+		Target:       labels,
+		PivotAccount: acc,
+	})
+}
+
+// ConnectWithRequest opens a stream to the service described by req, which
+// allows connecting to a specific service by setting req.ServiceId.
+func (s *Session) ConnectWithRequest(req *pb.ConnectRequest) (*Conn, error) {
 	stream, err := s.session.OpenStream()
 	if err != nil {
 		return nil, err
 	}
 
+	conn, err := s.connectStream(stream, req)
+	if err != nil {
+		stream.Close()
+		return nil, err
+	}
+
+	return conn, nil
+}
+
+func (s *Session) connectStream(stream *yamux.Stream, conreq *pb.ConnectRequest) (*Conn, error) {
 	fr2, err := wire.NewFramingReader(stream)
 	if err != nil {
 		return nil, err
@@ -120,11 +171,7 @@ func (s *Session) ConnecToAccountService(acc *pb.Account, labels *pb.LabelSet) (
 		return nil, err
 	}
 
-	var conreq pb.ConnectRequest
-	conreq.Target = labels
-	conreq.PivotAccount = acc
-
-	_, err = fw2.WriteMarshal(1, &conreq)
+	_, err = fw2.WriteMarshal(1, conreq)
 	if err != nil {
 		return nil, err
 	}
@@ -140,7 +187,7 @@ func (s *Session) ConnecToAccountService(acc *pb.Account, labels *pb.LabelSet) (
 		return nil, wire.ErrProtocolError
 	}
 
-	return &Conn{serviceId: ack.ServiceId, fr: fr2, fw: fw2}, nil
+	return &Conn{stream: stream, serviceId: ack.ServiceId, fr: fr2, fw: fw2}, nil
 }
 
 func (s *Session) ConnecToService(labels *pb.LabelSet) (*Conn, error) {
@@ -168,3 +215,8 @@ func (c *Conn) WireContext(accountId *pb.Account) wire.Context {
 func (c *Conn) ServiceId() *pb.ULID {
 	return c.serviceId
 }
+
+// Close closes the stream to the service, leaving the session open.
+func (c *Conn) Close() error {
+	return c.stream.Close()
+}
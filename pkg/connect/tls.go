@@ -0,0 +1,74 @@
+package connect
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+)
+
+var (
+	ErrNoPeerCertificate = errors.New("hub presented no certificate")
+	ErrPinMismatch       = errors.New("hub certificate does not match pinned key")
+)
+
+// HubTLSConfig returns the TLS configuration used to connect to the hub named
+// serverName. The hub's certificate must chain to roots (or the system roots
+// if nil) and be valid for serverName. If pinned is set, a certificate with
+// the same public key is also accepted, which lets hubs verify each other
+// using the certificate that control distributes to all of them.
+func HubTLSConfig(serverName string, roots *x509.CertPool, pinned *x509.Certificate) *tls.Config {
+	cfg := &tls.Config{
+		ServerName: serverName,
+		RootCAs:    roots,
+		NextProtos: []string{"hzn"},
+	}
+
+	if pinned == nil {
+		return cfg
+	}
+
+	// The standard verification would reject a pinned certificate that
+	// doesn't chain to roots, so we do the full verification ourselves.
+	cfg.InsecureSkipVerify = true
+	cfg.VerifyPeerCertificate = func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		if len(rawCerts) == 0 {
+			return ErrNoPeerCertificate
+		}
+
+		leaf, err := x509.ParseCertificate(rawCerts[0])
+		if err != nil {
+			return err
+		}
+
+		if bytes.Equal(leaf.RawSubjectPublicKeyInfo, pinned.RawSubjectPublicKeyInfo) {
+			return nil
+		}
+
+		// Without a name to check, only the pinned key can be trusted.
+		if serverName == "" {
+			return ErrPinMismatch
+		}
+
+		intermediates := x509.NewCertPool()
+
+		for _, raw := range rawCerts[1:] {
+			cert, err := x509.ParseCertificate(raw)
+			if err != nil {
+				return err
+			}
+
+			intermediates.AddCert(cert)
+		}
+
+		_, err = leaf.Verify(x509.VerifyOptions{
+			Roots:         roots,
+			Intermediates: intermediates,
+			DNSName:       serverName,
+		})
+
+		return err
+	}
+
+	return cfg
+}
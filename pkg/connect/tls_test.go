@@ -0,0 +1,110 @@
+package connect
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"io/ioutil"
+	"net"
+	"testing"
+
+	"github.com/hashicorp/horizon/pkg/testutils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHubTLSConfig(t *testing.T) {
+	serve := func(t *testing.T) (net.Listener, *x509.Certificate) {
+		certPEM, keyPEM, err := testutils.SelfSignedCert()
+		require.NoError(t, err)
+
+		cert, err := tls.X509KeyPair(certPEM, keyPEM)
+		require.NoError(t, err)
+
+		leaf, err := x509.ParseCertificate(cert.Certificate[0])
+		require.NoError(t, err)
+
+		li, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+			Certificates: []tls.Certificate{cert},
+			NextProtos:   []string{"hzn"},
+		})
+		require.NoError(t, err)
+
+		go func() {
+			for {
+				c, err := li.Accept()
+				if err != nil {
+					return
+				}
+
+				go func(c net.Conn) {
+					defer c.Close()
+					c.(*tls.Conn).Handshake()
+					io.Copy(ioutil.Discard, c)
+				}(c)
+			}
+		}()
+
+		return li, leaf
+	}
+
+	dial := func(addr string, cfg *tls.Config) error {
+		conn, err := tls.Dial("tcp", addr, cfg)
+		if err != nil {
+			return err
+		}
+
+		return conn.Close()
+	}
+
+	t.Run("accepts the pinned certificate", func(t *testing.T) {
+		li, leaf := serve(t)
+		defer li.Close()
+
+		addr := li.Addr().String()
+
+		err := dial(addr, HubTLSConfig("", nil, leaf))
+		require.NoError(t, err)
+	})
+
+	t.Run("rejects a different certificate", func(t *testing.T) {
+		li, _ := serve(t)
+		defer li.Close()
+
+		li2, other := serve(t)
+		defer li2.Close()
+
+		addr := li.Addr().String()
+
+		err := dial(addr, HubTLSConfig("", nil, other))
+		require.Error(t, err)
+
+		err = dial(addr, HubTLSConfig("hub.test", nil, other))
+		require.Error(t, err)
+	})
+
+	t.Run("verifies against the given roots", func(t *testing.T) {
+		li, leaf := serve(t)
+		defer li.Close()
+
+		addr := li.Addr().String()
+
+		roots := x509.NewCertPool()
+		roots.AddCert(leaf)
+
+		err := dial(addr, HubTLSConfig("hub.test", roots, nil))
+		require.NoError(t, err)
+
+		err = dial(addr, HubTLSConfig("other.test", roots, nil))
+		require.Error(t, err)
+	})
+
+	t.Run("rejects unknown certificates without roots", func(t *testing.T) {
+		li, _ := serve(t)
+		defer li.Close()
+
+		addr := li.Addr().String()
+
+		err := dial(addr, HubTLSConfig("hub.test", nil, nil))
+		require.Error(t, err)
+	})
+}
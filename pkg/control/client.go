@@ -4,6 +4,7 @@ import (
 	context "context"
 	"crypto/ed25519"
 	"crypto/tls"
+	"crypto/x509"
 	"encoding/hex"
 	io "io"
 	"io/ioutil"
@@ -12,6 +13,7 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 
@@ -85,6 +87,10 @@ type Client struct {
 	recentLabelLinks     []*pb.LabelLink
 	lessRecentLabelLinks []*pb.LabelLink
 
+	quotaMu      sync.RWMutex
+	lastQuotaMD5 string
+	quotas       map[string]AccountQuotas
+
 	rawtlsCert []byte
 	rawtlsKey  []byte
 	tlsCert    *tls.Certificate
@@ -242,6 +248,11 @@ func (c *Client) BootstrapConfig(ctx context.Context) error {
 		return err
 	}
 
+	cert.Leaf, err = x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return err
+	}
+
 	c.tlsCert = &cert
 
 	if resp.S3AccessKey != "" {
@@ -275,8 +286,47 @@ func (c *Client) TokenPub() ed25519.PublicKey {
 	return c.tokenPub
 }
 
+// HubCertificate returns the certificate that control distributed to the
+// hubs, or nil if the configuration hasn't been fetched yet.
+func (c *Client) HubCertificate() *x509.Certificate {
+	if c.tlsCert == nil || len(c.tlsCert.Certificate) == 0 {
+		return nil
+	}
+
+	if c.tlsCert.Leaf != nil {
+		return c.tlsCert.Leaf
+	}
+
+	leaf, err := x509.ParseCertificate(c.tlsCert.Certificate[0])
+	if err != nil {
+		return nil
+	}
+
+	return leaf
+}
+
+// HubDomain returns the domain that hub names are within, taken from the
+// wildcard name in the hub certificate.
+func (c *Client) HubDomain() string {
+	cert := c.HubCertificate()
+	if cert == nil {
+		return ""
+	}
+
+	for _, name := range cert.DNSNames {
+		if strings.HasPrefix(name, "*.") {
+			return name[2:]
+		}
+	}
+
+	return ""
+}
+
 type NPNHandler func(hs *http.Server, c *tls.Conn, h http.Handler)
 
+// RunIngress serves h over TLS on li. Clients that support HTTP/2 are served
+// with it, which gRPC clients require. Connections that negotiate one of the
+// protocols in npn are handed to its handler instead.
 func (c *Client) RunIngress(ctx context.Context, li net.Listener, npn map[string]NPNHandler, h http.Handler) error {
 	L := c.L
 
@@ -307,7 +357,10 @@ func (c *Client) RunIngress(ctx context.Context, li net.Listener, npn map[string
 		NewWriteScheduler: func() http2.WriteScheduler { return http2.NewPriorityWriteScheduler(nil) },
 	}
 
-	http2.ConfigureServer(hs, conf)
+	err := http2.ConfigureServer(hs, conf)
+	if err != nil {
+		return err
+	}
 
 	for proto, fn := range c.cfg.NextProto {
 		hs.TLSConfig.NextProtos = append(hs.TLSConfig.NextProtos, proto)
@@ -411,6 +464,20 @@ func (c *RouteCalculation) Services() []*pb.ServiceRoute {
 	return c.shuffle(c.All)
 }
 
+// ServiceMetadata returns the metadata of a service registered through this
+// client. Routes to services on other hubs don't carry metadata, so nil is
+// returned for them.
+func (c *Client) ServiceMetadata(id *pb.ULID) []*pb.KVPair {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if reg, ok := c.localServices[id.SpecString()]; ok {
+		return reg.Metadata
+	}
+
+	return nil
+}
+
 func (c *Client) LookupService(ctx context.Context, account *pb.Account, labels *pb.LabelSet) (*RouteCalculation, error) {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
@@ -668,6 +735,11 @@ func (c *Client) Run(ctx context.Context) error {
 		return err
 	}
 
+	err = c.updateAccountQuotas(ctx, L)
+	if err != nil {
+		return err
+	}
+
 	var activity pb.ControlServices_StreamActivityClient
 
 	activityChan := make(chan *pb.CentralActivity)
@@ -697,6 +769,11 @@ func (c *Client) Run(ctx context.Context) error {
 			if err != nil {
 				L.Error("error updating label links", "error", err)
 			}
+
+			err = c.updateAccountQuotas(ctx, L)
+			if err != nil {
+				L.Error("error updating account quotas", "error", err)
+			}
 		case ev, ok := <-activityChan:
 			if !ok {
 				select {
@@ -865,6 +942,100 @@ func (c *Client) updateLabelLinks(ctx context.Context, L hclog.Logger) error {
 	return err
 }
 
+func (c *Client) ForceAccountQuotaUpdate(ctx context.Context, L hclog.Logger) error {
+	return c.updateAccountQuotas(ctx, L)
+}
+
+// Downloads the account quotas published by control, if they've changed.
+func (c *Client) updateAccountQuotas(ctx context.Context, L hclog.Logger) error {
+	if c.bucket == "" {
+		L.Debug("no bucket configured, not updating account quotas")
+		return nil
+	}
+
+	L.Trace("updating account quotas")
+
+	obj := &s3.GetObjectInput{
+		Bucket: aws.String(c.bucket),
+		Key:    aws.String("account_quotas"),
+	}
+
+	if c.lastQuotaMD5 != "" {
+		obj.IfNoneMatch = aws.String(c.lastQuotaMD5)
+	}
+
+	resp, err := c.s3api.GetObjectWithContext(ctx, obj)
+	if err != nil {
+		if rf, ok := err.(awserr.RequestFailure); ok {
+			if rf.StatusCode() == 304 {
+				L.Trace("account quotas not modified")
+				return nil
+			}
+
+			if rf.StatusCode() == 404 {
+				L.Trace("no account quotas available")
+				return nil
+			}
+		}
+
+		if s3e, ok := err.(awserr.Error); ok {
+			if s3e.Code() == s3.ErrCodeNoSuchKey {
+				return nil
+			}
+		}
+
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	compressedData, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	data, err := zstdDecompress(compressedData)
+	if err != nil {
+		return err
+	}
+
+	var list pb.AccountQuotasList
+	err = list.Unmarshal(data)
+	if err != nil {
+		return err
+	}
+
+	quotas := make(map[string]AccountQuotas, len(list.Quotas))
+
+	for _, q := range list.Quotas {
+		quotas[q.Account.StringKey()] = AccountQuotas{
+			Agents:   q.Agents,
+			Services: q.Services,
+			Streams:  q.Streams,
+		}
+	}
+
+	c.lastQuotaMD5 = *resp.ETag
+
+	c.quotaMu.Lock()
+	defer c.quotaMu.Unlock()
+
+	c.quotas = quotas
+
+	L.Info("account quotas updated", "etag", c.lastQuotaMD5, "size", len(quotas))
+
+	return nil
+}
+
+// AccountQuotas returns the quotas of account, which are all zero if it has
+// none.
+func (c *Client) AccountQuotas(account *pb.Account) AccountQuotas {
+	c.quotaMu.RLock()
+	defer c.quotaMu.RUnlock()
+
+	return c.quotas[account.StringKey()]
+}
+
 func (c *Client) ResolveLabelLink(label *pb.LabelSet) (*pb.Account, *pb.LabelSet, *pb.Account_Limits, error) {
 	c.labelMu.RLock()
 	defer c.labelMu.RUnlock()
@@ -911,6 +1082,43 @@ func (c *Client) ResolveLabelLink(label *pb.LabelSet) (*pb.Account, *pb.LabelSet
 	return nil, nil, nil, nil
 }
 
+// LabelLinksWithLabel returns the label links that have a label with the
+// given name, such as the links for TCP ports.
+func (c *Client) LabelLinksWithLabel(name string) []*pb.LabelLink {
+	c.labelMu.RLock()
+	defer c.labelMu.RUnlock()
+
+	var (
+		out  []*pb.LabelLink
+		seen = make(map[string]bool)
+	)
+
+	add := func(lls []*pb.LabelLink) {
+		for _, ll := range lls {
+			if _, ok := ll.Labels.GetLabel(name); !ok {
+				continue
+			}
+
+			key := ll.Labels.SpecString()
+			if seen[key] {
+				continue
+			}
+
+			seen[key] = true
+			out = append(out, ll)
+		}
+	}
+
+	add(c.recentLabelLinks)
+	add(c.lessRecentLabelLinks)
+
+	if c.labelLinks != nil {
+		add(c.labelLinks.LabelLinks)
+	}
+
+	return out
+}
+
 func (c *Client) AllHubs(ctx context.Context) ([]*pb.HubInfo, error) {
 	list, err := c.client.AllHubs(ctx, &pb.Noop{})
 	if err != nil {
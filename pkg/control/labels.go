@@ -1,11 +1,15 @@
 package control
 
 import (
+	"bytes"
 	fmt "fmt"
 	"sort"
 	"strings"
 
+	"github.com/hashicorp/horizon/pkg/dbx"
 	"github.com/hashicorp/horizon/pkg/pb"
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
 )
 
 func FlattenLabels(labels *pb.LabelSet) string {
@@ -61,3 +65,59 @@ func ExplodeLabels(list string) *pb.LabelSet {
 
 	return &set
 }
+
+// Reports if set has a label called name, whatever its value.
+func hasLabel(set *pb.LabelSet, name string) bool {
+	if set == nil {
+		return false
+	}
+
+	for _, lbl := range set.Labels {
+		if strings.EqualFold(lbl.Name, name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// The label of the label links that send TLS connections for a server name
+// straight to the services of an account, without the hub terminating TLS.
+const SNILabel = ":sni"
+
+// The label of the label links that send HTTP requests for a hostname to the
+// services of an account.
+const HostnameLabel = ":hostname"
+
+// Rejects linking a :sni or :hostname label for a name that another account
+// has linked with the other label, since the hub would route the name's TLS
+// connections and HTTP requests to different accounts.
+func (s *Server) checkNameOwner(account *pb.Account, labels *pb.LabelSet) error {
+	for _, lbl := range labels.Labels {
+		var other string
+
+		switch strings.ToLower(lbl.Name) {
+		case SNILabel:
+			other = HostnameLabel
+		case HostnameLabel:
+			other = SNILabel
+		default:
+			continue
+		}
+
+		var links []*LabelLink
+
+		err := dbx.Check(s.db.Where("lower(labels) = ?", other+"="+strings.ToLower(lbl.Value)).Find(&links))
+		if err != nil && err != gorm.ErrRecordNotFound {
+			return err
+		}
+
+		for _, link := range links {
+			if !bytes.Equal(link.AccountID, account.Key()) {
+				return errors.Wrapf(ErrInvalidRequest, "%s is linked to another account with %s", lbl.Value, other)
+			}
+		}
+	}
+
+	return nil
+}
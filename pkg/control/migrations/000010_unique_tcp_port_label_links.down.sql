@@ -0,0 +1 @@
+DROP INDEX IF EXISTS label_links_tcp_port;
@@ -0,0 +1 @@
+CREATE UNIQUE INDEX IF NOT EXISTS label_links_tcp_port ON label_links (labels) WHERE labels LIKE ':tcp-port=%';
@@ -0,0 +1,166 @@
+package control
+
+import (
+	"context"
+
+	"github.com/hashicorp/horizon/pkg/dbx"
+	"github.com/hashicorp/horizon/pkg/pb"
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+)
+
+// AccountQuotas limits how much of a single hub an account can use. A zero
+// value means there is no limit. They are stored in the account's data next
+// to its limits and published to the hubs like the label links.
+type AccountQuotas struct {
+	// Concurrent agent sessions.
+	Agents int64 `json:"agents,omitempty"`
+
+	// Services advertised by the account's agents.
+	Services int64 `json:"services,omitempty"`
+
+	// Concurrent streams opened by the account's agents.
+	Streams int64 `json:"streams,omitempty"`
+}
+
+func (q *AccountQuotas) empty() bool {
+	return q.Agents == 0 && q.Services == 0 && q.Streams == 0
+}
+
+// SetAccountQuotas stores the quotas of an existing account and sends them
+// out to the hubs.
+func (s *Server) SetAccountQuotas(ctx context.Context, req *pb.AccountQuotas) (*pb.Noop, error) {
+	L := s.L.Named("set-account-quotas")
+
+	caller, err := s.checkMgmtAllowed(ctx)
+	if err != nil {
+		L.Error("error checking mgmt token", "err", err)
+		return nil, err
+	}
+
+	if req.Account == nil {
+		return nil, errors.Wrapf(ErrInvalidRequest, "account is required")
+	}
+
+	if req.Account.Namespace == "" {
+		req.Account.Namespace = caller.Account().Namespace
+	}
+
+	if !caller.AllowAccount(req.Account.Namespace) {
+		L.Error(
+			"rejected access to account based on caller namespace",
+			"caller-namespace", caller.Account().Namespace,
+			"requested-namespace", req.Account.Namespace,
+		)
+
+		return nil, errors.Wrapf(ErrInvalidRequest, "invalid namespace requested")
+	}
+
+	if req.Agents < 0 || req.Services < 0 || req.Streams < 0 {
+		return nil, errors.Wrapf(ErrInvalidRequest, "quotas can't be negative")
+	}
+
+	var ao Account
+
+	err = dbx.Check(s.db.First(&ao, req.Account.Key()))
+	if err != nil {
+		return nil, errors.Wrapf(err, "account not found")
+	}
+
+	err = ao.Data.Set("quotas", &AccountQuotas{
+		Agents:   req.Agents,
+		Services: req.Services,
+		Streams:  req.Streams,
+	})
+	if err != nil {
+		return nil, errors.Wrapf(ErrInvalidRequest, "error encoding quotas: %s", err)
+	}
+
+	L.Info("setting account quotas",
+		"account", req.Account.SpecString(),
+		"agents", req.Agents,
+		"services", req.Services,
+		"streams", req.Streams,
+	)
+
+	err = dbx.Check(s.db.Model(&ao).Update("data", ao.Data))
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.updateAccountQuotas(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.Noop{}, nil
+}
+
+// Publishes the quotas of every account that has them for the hubs to
+// download.
+func (s *Server) updateAccountQuotas(ctx context.Context) error {
+	var lastId []byte
+
+	accounts := make([]*Account, 0, 100)
+
+	var out pb.AccountQuotasList
+
+	for {
+		// Gotta poll the context since database/sql and gorm don't expose a context
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		q := s.db.Order("id ASC").Limit(100)
+		if lastId != nil {
+			q = q.Where("id > ?", lastId)
+		}
+
+		err := dbx.Check(q.Find(&accounts))
+		if err != nil && err != gorm.ErrRecordNotFound {
+			return err
+		}
+
+		if len(accounts) == 0 {
+			break
+		}
+
+		for _, acc := range accounts {
+			var quotas AccountQuotas
+
+			ok, err := acc.Data.Get("quotas", &quotas)
+			if err != nil {
+				return err
+			}
+
+			if !ok || quotas.empty() {
+				continue
+			}
+
+			account, err := pb.AccountFromKey(acc.ID)
+			if err != nil {
+				return err
+			}
+
+			out.Quotas = append(out.Quotas, &pb.AccountQuotas{
+				Account:  account,
+				Agents:   quotas.Agents,
+				Services: quotas.Services,
+				Streams:  quotas.Streams,
+			})
+		}
+
+		lastId = accounts[len(accounts)-1].ID
+
+		accounts = accounts[:0]
+	}
+
+	data, err := out.Marshal()
+	if err != nil {
+		return err
+	}
+
+	return s.putCompressedObject("account_quotas", data)
+}
@@ -0,0 +1,255 @@
+package control
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/horizon/pkg/pb"
+)
+
+// RouteSelector decides which of the routes to a service is tried first.
+// The hub uses the same selector for agent streams and web requests so that
+// what it learns about a service applies to both.
+type RouteSelector interface {
+	// SelectRoutes returns the candidate routes of calc, best first.
+	SelectRoutes(ctx context.Context, calc *RouteCalculation) []*pb.ServiceRoute
+
+	// ConnectFailed records that a stream could not be opened to route.
+	ConnectFailed(route *pb.ServiceRoute, err error)
+
+	// Connected records that a stream to route was opened, taking latency to
+	// do so. The returned function must be called when the stream ends.
+	Connected(route *pb.ServiceRoute, latency time.Duration) func()
+}
+
+const (
+	// Score added per step away from this hub: services on this hub cost
+	// nothing, hubs with the same network location labels one step, any other
+	// hub two steps.
+	localityWeight = 10.0
+
+	// Score added for each stream this hub currently has open to a service.
+	streamWeight = 1.0
+
+	// Score added per millisecond of average connect latency.
+	latencyWeight = 0.1
+
+	// Score added for a service that failed every recent connect.
+	failureWeight = 50.0
+
+	// How much each new observation moves the latency and failure averages.
+	observeDecay = 0.2
+
+	// How often the locations of the other hubs are refreshed.
+	hubLocationRefresh = time.Minute
+
+	// Stats for services that haven't been used in this long are dropped.
+	routeStatsTTL = 10 * time.Minute
+)
+
+type routeStats struct {
+	active   int64
+	latency  float64
+	failures float64
+	lastUsed time.Time
+}
+
+// ScoringSelector orders routes by locality, load, latency, and failures.
+// Services on this hub are preferred, then those on hubs in the same network
+// location as this one. The load of a service is the number of streams this
+// hub has open to it, and latency and failures are averaged over the recent
+// connects this hub made to it. Routes that score the same are left in the
+// random order of RouteCalculation.Services, and deployment order is always
+// respected before the score.
+type ScoringSelector struct {
+	L hclog.Logger
+
+	hub       *pb.ULID
+	locations func() []*pb.NetworkLocation
+	allHubs   func(ctx context.Context) ([]*pb.HubInfo, error)
+
+	mu           sync.Mutex
+	stats        map[string]*routeStats
+	hubLocations map[string][]*pb.NetworkLocation
+	lastRefresh  time.Time
+	refreshing   bool
+}
+
+// NewScoringSelector creates a ScoringSelector for the hub using c.
+func NewScoringSelector(c *Client) *ScoringSelector {
+	return &ScoringSelector{
+		L:            c.L,
+		hub:          c.Id(),
+		locations:    c.Locations,
+		allHubs:      c.AllHubs,
+		stats:        make(map[string]*routeStats),
+		hubLocations: make(map[string][]*pb.NetworkLocation),
+	}
+}
+
+func (s *ScoringSelector) SelectRoutes(ctx context.Context, calc *RouteCalculation) []*pb.ServiceRoute {
+	routes := calc.Services()
+	if len(routes) == 0 {
+		return routes
+	}
+
+	s.maybeRefreshHubs()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	scores := make(map[*pb.ServiceRoute]float64, len(routes))
+
+	for _, route := range routes {
+		scores[route] = s.score(route)
+	}
+
+	out := make([]*pb.ServiceRoute, len(routes))
+	copy(out, routes)
+
+	sort.SliceStable(out, func(i, j int) bool {
+		io, _ := out[i].Labels.GetLabel(deploymentOrder)
+		jo, _ := out[j].Labels.GetLabel(deploymentOrder)
+
+		if io != jo {
+			return io > jo
+		}
+
+		return scores[out[i]] < scores[out[j]]
+	})
+
+	return out
+}
+
+// Returns the score of route, lower is better. Must be called with s.mu held.
+func (s *ScoringSelector) score(route *pb.ServiceRoute) float64 {
+	score := localityWeight * float64(s.locality(route.Hub))
+
+	if st, ok := s.stats[route.Id.SpecString()]; ok {
+		score += streamWeight * float64(st.active)
+		score += latencyWeight * st.latency
+		score += failureWeight * st.failures
+	}
+
+	return score
+}
+
+// Returns how many steps away the hub is: 0 for this hub, 1 for a hub in the
+// same network location, and 2 for any other hub. Must be called with s.mu
+// held.
+func (s *ScoringSelector) locality(hub *pb.ULID) int {
+	if hub.Equal(s.hub) {
+		return 0
+	}
+
+	for _, loc := range s.hubLocations[hub.SpecString()] {
+		for _, self := range s.locations() {
+			if loc.SameLabels(self) {
+				return 1
+			}
+		}
+	}
+
+	return 2
+}
+
+func (s *ScoringSelector) statsFor(route *pb.ServiceRoute) *routeStats {
+	key := route.Id.SpecString()
+
+	st, ok := s.stats[key]
+	if !ok {
+		st = &routeStats{}
+		s.stats[key] = st
+	}
+
+	st.lastUsed = time.Now()
+
+	return st
+}
+
+func (s *ScoringSelector) ConnectFailed(route *pb.ServiceRoute, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st := s.statsFor(route)
+	st.failures += observeDecay * (1 - st.failures)
+}
+
+func (s *ScoringSelector) Connected(route *pb.ServiceRoute, latency time.Duration) func() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st := s.statsFor(route)
+
+	ms := float64(latency) / float64(time.Millisecond)
+
+	if st.latency == 0 {
+		st.latency = ms
+	} else {
+		st.latency += observeDecay * (ms - st.latency)
+	}
+
+	st.failures -= observeDecay * st.failures
+	st.active++
+
+	var once sync.Once
+
+	return func() {
+		once.Do(func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+
+			st.active--
+			st.lastUsed = time.Now()
+		})
+	}
+}
+
+// Starts a refresh of the other hubs' locations in the background if they are
+// out of date. Until it finishes, routes are scored with the old locations.
+func (s *ScoringSelector) maybeRefreshHubs() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.refreshing || time.Since(s.lastRefresh) < hubLocationRefresh {
+		return
+	}
+
+	s.refreshing = true
+
+	go s.refreshHubs()
+}
+
+func (s *ScoringSelector) refreshHubs() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	hubs, err := s.allHubs(ctx)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.refreshing = false
+	s.lastRefresh = time.Now()
+
+	if err != nil {
+		s.L.Warn("error refreshing hub locations for route selection", "error", err)
+	} else {
+		locs := make(map[string][]*pb.NetworkLocation, len(hubs))
+
+		for _, hub := range hubs {
+			locs[hub.Id.SpecString()] = hub.Locations
+		}
+
+		s.hubLocations = locs
+	}
+
+	for key, st := range s.stats {
+		if st.active == 0 && time.Since(st.lastUsed) > routeStatsTTL {
+			delete(s.stats, key)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package control
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/horizon/pkg/pb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScoringSelector(t *testing.T) {
+	self := pb.NewULID()
+	peer := pb.NewULID()
+	far := pb.NewULID()
+
+	region := &pb.NetworkLocation{
+		Addresses: []string{"10.0.0.1"},
+		Labels:    pb.ParseLabelSet("region=us-west-2"),
+	}
+
+	newSelector := func() *ScoringSelector {
+		return &ScoringSelector{
+			L:   hclog.NewNullLogger(),
+			hub: self,
+			locations: func() []*pb.NetworkLocation {
+				return []*pb.NetworkLocation{region}
+			},
+			allHubs: func(ctx context.Context) ([]*pb.HubInfo, error) {
+				return nil, nil
+			},
+			stats: make(map[string]*routeStats),
+			hubLocations: map[string][]*pb.NetworkLocation{
+				peer.SpecString(): {region},
+				far.SpecString(): {
+					{
+						Addresses: []string{"10.1.0.1"},
+						Labels:    pb.ParseLabelSet("region=eu-west-1"),
+					},
+				},
+			},
+			lastRefresh: time.Now(),
+		}
+	}
+
+	route := func(hub *pb.ULID, labels string) *pb.ServiceRoute {
+		return &pb.ServiceRoute{
+			Id:     pb.NewULID(),
+			Hub:    hub,
+			Type:   "test",
+			Labels: pb.ParseLabelSet(labels),
+		}
+	}
+
+	ctx := context.Background()
+
+	t.Run("prefers this hub, then the same location", func(t *testing.T) {
+		s := newSelector()
+
+		local := route(self, "env=test")
+		near := route(peer, "env=test")
+		remote := route(far, "env=test")
+
+		routes := s.SelectRoutes(ctx, &RouteCalculation{
+			All: []*pb.ServiceRoute{remote, near, local},
+		})
+
+		assert.Equal(t, []*pb.ServiceRoute{local, near, remote}, routes)
+	})
+
+	t.Run("avoids busy services", func(t *testing.T) {
+		s := newSelector()
+
+		busy := route(self, "env=test")
+		idle := route(peer, "env=test")
+
+		for i := 0; i < 20; i++ {
+			done := s.Connected(busy, time.Millisecond)
+			defer done()
+		}
+
+		routes := s.SelectRoutes(ctx, &RouteCalculation{
+			All: []*pb.ServiceRoute{busy, idle},
+		})
+
+		assert.Equal(t, idle, routes[0])
+	})
+
+	t.Run("streams that end stop counting against a service", func(t *testing.T) {
+		s := newSelector()
+
+		local := route(self, "env=test")
+		near := route(peer, "env=test")
+
+		for i := 0; i < 20; i++ {
+			done := s.Connected(local, time.Millisecond)
+			done()
+			done()
+		}
+
+		routes := s.SelectRoutes(ctx, &RouteCalculation{
+			All: []*pb.ServiceRoute{near, local},
+		})
+
+		assert.Equal(t, local, routes[0])
+	})
+
+	t.Run("avoids slow and failing services", func(t *testing.T) {
+		s := newSelector()
+
+		slow := route(self, "env=test")
+		failing := route(self, "env=test")
+		good := route(self, "env=test")
+
+		s.Connected(slow, 200*time.Millisecond)()
+		s.Connected(good, time.Millisecond)()
+
+		for i := 0; i < 5; i++ {
+			s.ConnectFailed(failing, errors.New("no session"))
+		}
+
+		routes := s.SelectRoutes(ctx, &RouteCalculation{
+			All: []*pb.ServiceRoute{failing, slow, good},
+		})
+
+		require.Equal(t, 3, len(routes))
+		assert.Equal(t, good, routes[0])
+		assert.Equal(t, slow, routes[1])
+		assert.Equal(t, failing, routes[2])
+	})
+
+	t.Run("respects deployment order before the score", func(t *testing.T) {
+		s := newSelector()
+
+		old := route(self, "env=test,:deployment-order=1")
+		latest := route(far, "env=test,:deployment-order=2")
+
+		routes := s.SelectRoutes(ctx, &RouteCalculation{
+			Best: []*pb.ServiceRoute{old, latest},
+		})
+
+		assert.Equal(t, latest, routes[0])
+	})
+}
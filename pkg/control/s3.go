@@ -238,6 +238,12 @@ func (s *Server) updateLabelLinks(ctx context.Context) error {
 		return err
 	}
 
+	return s.putCompressedObject("label_links", data)
+}
+
+// Uploads data, compressed with zstd, as the object key for the hubs to
+// download.
+func (s *Server) putCompressedObject(key string, data []byte) error {
 	outData, err := zstdCompress(data)
 	if err != nil {
 		return err
@@ -257,7 +263,7 @@ func (s *Server) updateLabelLinks(ctx context.Context) error {
 		ContentMD5:  aws.String(inputEtag),
 		ContentType: aws.String("application/horizon"),
 		Bucket:      &s.bucket,
-		Key:         aws.String("label_links"),
+		Key:         aws.String(key),
 		Tagging:     aws.String("usage=horizon"),
 	}
 
@@ -104,6 +104,11 @@ type ServerConfig struct {
 
 	DataDogAddr       string
 	DisablePrometheus bool
+
+	// The range of public TCP ports handed out by AllocateTCPPort. Defaults
+	// to DefaultTCPPortMin through DefaultTCPPortMax.
+	TCPPortMin int
+	TCPPortMax int
 }
 
 func NewServer(cfg ServerConfig) (*Server, error) {
@@ -972,14 +977,38 @@ func (s *Server) AddLabelLink(ctx context.Context, req *pb.AddLabelLinkRequest)
 		return nil, errors.Wrapf(ErrInvalidRequest, "invalid namespace requested")
 	}
 
+	// Ports are only linked by AllocateTCPPort, which keeps them within the
+	// range the hubs listen on.
+	if hasLabel(req.Labels, TCPPortLabel) {
+		L.Error("rejected label-link for a tcp port", "labels", req.Labels.SpecString())
+		return nil, errors.Wrapf(ErrInvalidRequest, "%s label links are created by allocating a tcp port", TCPPortLabel)
+	}
+
+	err = s.checkNameOwner(req.Account, req.Labels)
+	if err != nil {
+		L.Error("rejected label-link for a name owned by another account", "error", err)
+		return nil, err
+	}
+
+	err = s.addLabelLink(ctx, L, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.Noop{}, nil
+}
+
+// Stores the label link in req and sends it out to the hubs. The caller must
+// have checked that it's allowed to link the account.
+func (s *Server) addLabelLink(ctx context.Context, L hclog.Logger, req *pb.AddLabelLinkRequest) error {
 	var ao Account
 
 	de := s.db.First(&ao, req.Account.Key())
 
-	err = dbx.Check(de)
+	err := dbx.Check(de)
 	if err != nil {
 		L.Error("error reading account information for label-link", "error", err)
-		return nil, errors.Wrapf(err, "account for label-link not found")
+		return errors.Wrapf(err, "account for label-link not found")
 	}
 
 	L.Trace("account for label-link initialized correctly")
@@ -992,7 +1021,7 @@ func (s *Server) AddLabelLink(ctx context.Context, req *pb.AddLabelLinkRequest)
 	err = dbx.Check(s.db.Create(&llr))
 	if err != nil {
 		L.Error("error creating label-link record", "error", err)
-		return nil, err
+		return err
 	}
 
 	L.Trace("label-link saved to database")
@@ -1013,12 +1042,7 @@ func (s *Server) AddLabelLink(ctx context.Context, req *pb.AddLabelLinkRequest)
 		NewLabelLinks: &out,
 	})
 
-	err = s.updateLabelLinks(ctx)
-	if err != nil {
-		return nil, err
-	}
-
-	return &pb.Noop{}, nil
+	return s.updateLabelLinks(ctx)
 }
 
 func (s *Server) RemoveLabelLink(ctx context.Context, req *pb.RemoveLabelLinkRequest) (*pb.Noop, error) {
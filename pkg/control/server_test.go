@@ -207,6 +207,105 @@ func TestServer(t *testing.T) {
 		require.True(t, ok)
 	})
 
+	t.Run("publishes account quotas to the hubs", func(t *testing.T) {
+		db := testsql.TestPostgresDB(t, "hzn")
+		defer db.Close()
+
+		var s Server
+		s.L = L
+		s.db = db
+		s.vaultClient = vc
+		s.vaultPath = pb.NewULID().SpecString()
+		s.keyId = "k1"
+		s.registerToken = "aabbcc"
+		s.awsSess = sess
+		s.bucket = bucket
+
+		pub, err := token.SetupVault(vc, s.vaultPath)
+		require.NoError(t, err)
+
+		s.pubKey = pub
+
+		top := context.Background()
+
+		md := make(metadata.MD)
+		md.Set("authorization", "aabbcc")
+
+		ctx := metadata.NewIncomingContext(top, md)
+
+		ct, err := s.Register(ctx, &pb.ControlRegister{
+			Namespace: "/",
+		})
+
+		require.NoError(t, err)
+
+		md2 := make(metadata.MD)
+		md2.Set("authorization", ct.Token)
+
+		mctx := metadata.NewIncomingContext(top, md2)
+
+		account := &pb.Account{
+			Namespace: "/",
+			AccountId: pb.NewULID(),
+		}
+
+		_, err = s.AddAccount(mctx, &pb.AddAccountRequest{
+			Account: account,
+		})
+		require.NoError(t, err)
+
+		_, err = s.AddAccount(mctx, &pb.AddAccountRequest{
+			Account: &pb.Account{
+				Namespace: "/",
+				AccountId: pb.NewULID(),
+			},
+		})
+		require.NoError(t, err)
+
+		_, err = s.SetAccountQuotas(mctx, &pb.AccountQuotas{
+			Account: account,
+			Agents:  2,
+			Streams: 100,
+		})
+		require.NoError(t, err)
+
+		s3api := s3.New(sess)
+
+		resp, err := s3api.GetObject(&s3.GetObjectInput{
+			Bucket: aws.String(s.bucket),
+			Key:    aws.String("account_quotas"),
+		})
+
+		require.NoError(t, err)
+
+		compressedData, err := ioutil.ReadAll(resp.Body)
+		require.NoError(t, err)
+
+		data, err := zstdDecompress(compressedData)
+		require.NoError(t, err)
+
+		var list pb.AccountQuotasList
+
+		err = list.Unmarshal(data)
+		require.NoError(t, err)
+
+		// Only the account with quotas is published.
+		require.Equal(t, 1, len(list.Quotas))
+
+		q := list.Quotas[0]
+
+		assert.Equal(t, account.AccountId, q.Account.AccountId)
+		assert.Equal(t, int64(2), q.Agents)
+		assert.Equal(t, int64(0), q.Services)
+		assert.Equal(t, int64(100), q.Streams)
+
+		_, err = s.SetAccountQuotas(mctx, &pb.AccountQuotas{
+			Account: account,
+			Agents:  -1,
+		})
+		assert.True(t, errors.Is(err, ErrInvalidRequest))
+	})
+
 	t.Run("disallows creating an agent token in a different namespace", func(t *testing.T) {
 		db := testsql.TestPostgresDB(t, "hzn")
 		defer db.Close()
@@ -647,6 +746,158 @@ func TestServer(t *testing.T) {
 		require.Equal(t, 0, len(lls2.LabelLinks))
 	})
 
+	t.Run("rejects label links for tcp ports", func(t *testing.T) {
+		db := testsql.TestPostgresDB(t, "hzn")
+		defer db.Close()
+
+		var s Server
+		s.L = L
+		s.db = db
+		s.vaultClient = vc
+		s.vaultPath = pb.NewULID().SpecString()
+		s.keyId = "k1"
+		s.registerToken = "aabbcc"
+		s.awsSess = sess
+		s.bucket = bucket
+
+		pub, err := token.SetupVault(vc, s.vaultPath)
+		require.NoError(t, err)
+
+		s.pubKey = pub
+
+		top := context.Background()
+
+		md := make(metadata.MD)
+		md.Set("authorization", "aabbcc")
+
+		ct, err := s.Register(metadata.NewIncomingContext(top, md), &pb.ControlRegister{
+			Namespace: "/",
+		})
+
+		require.NoError(t, err)
+
+		md2 := make(metadata.MD)
+		md2.Set("authorization", ct.Token)
+
+		account := &pb.Account{
+			AccountId: pb.NewULID(),
+			Namespace: "/",
+		}
+
+		_, err = s.AddAccount(
+			metadata.NewIncomingContext(top, md2),
+			&pb.AddAccountRequest{
+				Account: account,
+			},
+		)
+
+		require.NoError(t, err)
+
+		_, err = s.AddLabelLink(
+			metadata.NewIncomingContext(top, md2),
+			&pb.AddLabelLinkRequest{
+				Labels:  pb.ParseLabelSet(":tcp-port=22"),
+				Account: account,
+				Target:  pb.ParseLabelSet("service=ssh"),
+			},
+		)
+
+		assert.True(t, errors.Is(err, ErrInvalidRequest))
+
+		var llr LabelLink
+		err = dbx.Check(db.First(&llr))
+
+		assert.Error(t, err)
+	})
+
+	t.Run("rejects sni links for a name another account links by hostname", func(t *testing.T) {
+		db := testsql.TestPostgresDB(t, "hzn")
+		defer db.Close()
+
+		var s Server
+		s.L = L
+		s.db = db
+		s.vaultClient = vc
+		s.vaultPath = pb.NewULID().SpecString()
+		s.keyId = "k1"
+		s.registerToken = "aabbcc"
+		s.awsSess = sess
+		s.bucket = bucket
+
+		pub, err := token.SetupVault(vc, s.vaultPath)
+		require.NoError(t, err)
+
+		s.pubKey = pub
+
+		top := context.Background()
+
+		md := make(metadata.MD)
+		md.Set("authorization", "aabbcc")
+
+		ct, err := s.Register(metadata.NewIncomingContext(top, md), &pb.ControlRegister{
+			Namespace: "/",
+		})
+
+		require.NoError(t, err)
+
+		md2 := make(metadata.MD)
+		md2.Set("authorization", ct.Token)
+
+		account := &pb.Account{
+			AccountId: pb.NewULID(),
+			Namespace: "/",
+		}
+
+		other := &pb.Account{
+			AccountId: pb.NewULID(),
+			Namespace: "/",
+		}
+
+		for _, acc := range []*pb.Account{account, other} {
+			_, err = s.AddAccount(
+				metadata.NewIncomingContext(top, md2),
+				&pb.AddAccountRequest{
+					Account: acc,
+				},
+			)
+
+			require.NoError(t, err)
+		}
+
+		_, err = s.AddLabelLink(
+			metadata.NewIncomingContext(top, md2),
+			&pb.AddLabelLinkRequest{
+				Labels:  pb.ParseLabelSet(":hostname=app.example.com"),
+				Account: account,
+				Target:  pb.ParseLabelSet("service=web"),
+			},
+		)
+
+		require.NoError(t, err)
+
+		_, err = s.AddLabelLink(
+			metadata.NewIncomingContext(top, md2),
+			&pb.AddLabelLinkRequest{
+				Labels:  pb.ParseLabelSet(":sni=app.example.com"),
+				Account: other,
+				Target:  pb.ParseLabelSet("service=tls"),
+			},
+		)
+
+		assert.True(t, errors.Is(err, ErrInvalidRequest))
+
+		_, err = s.AddLabelLink(
+			metadata.NewIncomingContext(top, md2),
+			&pb.AddLabelLinkRequest{
+				Labels:  pb.ParseLabelSet(":sni=app.example.com"),
+				Account: account,
+				Target:  pb.ParseLabelSet("service=tls"),
+			},
+		)
+
+		require.NoError(t, err)
+	})
+
 	t.Run("can create and remove a service for an account", func(t *testing.T) {
 		db := testsql.TestPostgresDB(t, "hzn")
 		defer db.Close()
@@ -0,0 +1,149 @@
+package control
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/horizon/pkg/dbx"
+	"github.com/hashicorp/horizon/pkg/pb"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+)
+
+// The label of the label links that connect a public TCP port on the hubs to
+// the services of an account, like :hostname does for HTTP.
+const TCPPortLabel = ":tcp-port"
+
+const (
+	DefaultTCPPortMin = 20000
+	DefaultTCPPortMax = 29999
+)
+
+var ErrNoTCPPorts = errors.New("no tcp ports available")
+
+// ParseTCPPortRange parses a range of TCP ports written as first-last, such
+// as 20000-29999.
+func ParseTCPPortRange(str string) (int, int, error) {
+	idx := strings.IndexByte(str, '-')
+	if idx == -1 {
+		return 0, 0, errors.Errorf("tcp port range must be written as first-last: %s", str)
+	}
+
+	first, err := strconv.Atoi(strings.TrimSpace(str[:idx]))
+	if err != nil {
+		return 0, 0, errors.Wrapf(err, "invalid first port in tcp port range")
+	}
+
+	last, err := strconv.Atoi(strings.TrimSpace(str[idx+1:]))
+	if err != nil {
+		return 0, 0, errors.Wrapf(err, "invalid last port in tcp port range")
+	}
+
+	if first <= 0 || last > 65535 || first > last {
+		return 0, 0, errors.Errorf("invalid tcp port range: %s", str)
+	}
+
+	return first, last, nil
+}
+
+// TCPPortLabels returns the label set used to link port.
+func TCPPortLabels(port int) *pb.LabelSet {
+	return &pb.LabelSet{
+		Labels: []*pb.Label{
+			{
+				Name:  TCPPortLabel,
+				Value: strconv.Itoa(port),
+			},
+		},
+	}
+}
+
+// AllocateTCPPort picks a free public TCP port and links it to the services
+// of the account matching the target labels. Every hub listens on the port
+// and bridges connections to those services. The port is released by removing
+// its label link, which uses the labels from TCPPortLabels.
+func (s *Server) AllocateTCPPort(ctx context.Context, req *pb.AllocateTCPPortRequest) (*pb.AllocateTCPPortResponse, error) {
+	L := s.L.Named("allocate-tcp-port")
+
+	caller, err := s.checkMgmtAllowed(ctx)
+	if err != nil {
+		L.Error("error checking mgmt token", "err", err)
+		return nil, err
+	}
+
+	if req.Account == nil || req.Target == nil {
+		return nil, errors.Wrapf(ErrInvalidRequest, "account and target are required")
+	}
+
+	if req.Account.Namespace == "" {
+		req.Account.Namespace = caller.Account().Namespace
+	}
+
+	if !caller.AllowAccount(req.Account.Namespace) {
+		L.Error(
+			"rejected access to account based on caller namespace",
+			"caller-namespace", caller.Account().Namespace,
+			"requested-namespace", req.Account.Namespace,
+		)
+
+		return nil, errors.Wrapf(ErrInvalidRequest, "invalid namespace requested")
+	}
+
+	first, last := s.cfg.TCPPortMin, s.cfg.TCPPortMax
+	if first == 0 {
+		first = DefaultTCPPortMin
+	}
+
+	if last == 0 {
+		last = DefaultTCPPortMax
+	}
+
+	var lls []*LabelLink
+
+	err = dbx.Check(s.db.Where("labels LIKE ?", TCPPortLabel+"=%").Find(&lls))
+	if err != nil {
+		return nil, err
+	}
+
+	used := make(map[int]bool)
+
+	for _, ll := range lls {
+		port, err := strconv.Atoi(strings.TrimPrefix(ll.Labels, TCPPortLabel+"="))
+		if err == nil {
+			used[port] = true
+		}
+	}
+
+	for port := first; port <= last; port++ {
+		if used[port] {
+			continue
+		}
+
+		err = s.addLabelLink(ctx, L, &pb.AddLabelLinkRequest{
+			Account: req.Account,
+			Labels:  TCPPortLabels(port),
+			Target:  req.Target,
+		})
+
+		if err != nil {
+			// Someone else allocated the port since we looked, so try the
+			// next one.
+			if pqe, ok := errors.Cause(err).(*pq.Error); ok && pqe.Code == "23505" {
+				continue
+			}
+
+			return nil, err
+		}
+
+		L.Info("allocated tcp port",
+			"account", req.Account.SpecString(),
+			"port", port,
+			"target", req.Target.SpecString(),
+		)
+
+		return &pb.AllocateTCPPortResponse{Port: int32(port)}, nil
+	}
+
+	return nil, ErrNoTCPPorts
+}
@@ -4,6 +4,7 @@ import (
 	"container/list"
 	"context"
 	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -20,6 +21,10 @@ import (
 type Client struct {
 	URL string
 
+	// The roots used to verify the discovery server. The system roots are
+	// used if nil.
+	RootCAs *x509.CertPool
+
 	mu sync.Mutex
 
 	location []*pb.NetworkLocation
@@ -89,7 +94,7 @@ func (c *Client) Refresh(ctx context.Context) error {
 	client := &http.Client{
 		Transport: &http.Transport{
 			TLSClientConfig: &tls.Config{
-				InsecureSkipVerify: true,
+				RootCAs: c.RootCAs,
 			},
 		},
 	}
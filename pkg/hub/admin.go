@@ -0,0 +1,223 @@
+package hub
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/hashicorp/horizon/pkg/pb"
+	"github.com/hashicorp/horizon/pkg/token"
+)
+
+// AgentStatus describes an agent session connected to the hub.
+type AgentStatus struct {
+	Id          string          `json:"id"`
+	Account     string          `json:"account"`
+	ConnectedAt time.Time       `json:"connected_at"`
+	Services    []ServiceStatus `json:"services"`
+
+	ActiveStreams int64 `json:"active_streams"`
+	TotalStreams  int64 `json:"total_streams"`
+}
+
+// ServiceStatus describes a service advertised by an agent.
+type ServiceStatus struct {
+	Id     string `json:"id"`
+	Type   string `json:"type"`
+	Labels string `json:"labels"`
+}
+
+// Serves the admin API under /__hzn/admin/:
+//
+//	GET  /agents       the connected agents, optionally only those of ?account=
+//	GET  /services     the ids of the services reachable on this hub
+//	POST /disconnect   closes the session of ?agent=, or all the sessions
+//	                   of ?account=
+//
+// Requests must carry a management or hub token in the Authorization header.
+// Management tokens only see and act on agents in the accounts they have
+// access to.
+func (h *Hub) registerAdmin() {
+	h.mux.HandleFunc("/__hzn/admin/agents", h.adminAuth("GET", h.adminAgents))
+	h.mux.HandleFunc("/__hzn/admin/services", h.adminAuth("GET", h.adminServices))
+	h.mux.HandleFunc("/__hzn/admin/disconnect", h.adminAuth("POST", h.adminDisconnect))
+}
+
+type adminHandler func(w http.ResponseWriter, r *http.Request, caller *token.ValidToken)
+
+func (h *Hub) adminAuth(method string, f adminHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != method {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		stoken := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if stoken == "" {
+			http.Error(w, "missing token", http.StatusUnauthorized)
+			return
+		}
+
+		caller, err := h.ValidateToken(stoken)
+		if err != nil {
+			h.L.Warn("invalid token used for admin api", "error", err, "path", r.URL.Path)
+			http.Error(w, "invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		switch caller.Body.Role {
+		case pb.MANAGE, pb.HUB:
+			// ok
+		default:
+			http.Error(w, "token not authorized for admin api", http.StatusForbidden)
+			return
+		}
+
+		f(w, r, caller)
+	}
+}
+
+// Returns the agents the caller may see, limited to those of account if it's
+// set.
+func (h *Hub) adminAgentsFor(caller *token.ValidToken, account string) []*agentConn {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	var out []*agentConn
+
+	for ai := range h.agents {
+		if caller.Body.Role != pb.HUB && !caller.AllowAccount(ai.Account.Namespace) {
+			continue
+		}
+
+		if account != "" && ai.Account.StringKey() != account {
+			continue
+		}
+
+		out = append(out, ai)
+	}
+
+	return out
+}
+
+func (h *Hub) adminAgents(w http.ResponseWriter, r *http.Request, caller *token.ValidToken) {
+	agents := h.adminAgentsFor(caller, r.URL.Query().Get("account"))
+
+	out := []AgentStatus{}
+
+	for _, ai := range agents {
+		st := AgentStatus{
+			Id:            ai.ID.String(),
+			Account:       ai.Account.StringKey(),
+			ConnectedAt:   ai.Start.Time(),
+			Services:      []ServiceStatus{},
+			ActiveStreams: atomic.LoadInt64(ai.ActiveStreams),
+			TotalStreams:  atomic.LoadInt64(ai.TotalStreams),
+		}
+
+		for _, serv := range ai.services() {
+			ss := ServiceStatus{
+				Id:   serv.ServiceId.String(),
+				Type: serv.Type,
+			}
+
+			if serv.Labels != nil {
+				ss.Labels = serv.Labels.SpecString()
+			}
+
+			st.Services = append(st.Services, ss)
+		}
+
+		out = append(out, st)
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].ConnectedAt.Before(out[j].ConnectedAt)
+	})
+
+	writeAdminJSON(w, out)
+}
+
+func (h *Hub) adminServices(w http.ResponseWriter, r *http.Request, caller *token.ValidToken) {
+	// h.active doesn't know the account of a service, so management tokens
+	// only see the services of the agents they can see.
+	var visible map[string]bool
+
+	if caller.Body.Role != pb.HUB {
+		visible = make(map[string]bool)
+
+		for _, ai := range h.adminAgentsFor(caller, "") {
+			for _, serv := range ai.services() {
+				visible[serv.ServiceId.SpecString()] = true
+			}
+		}
+	}
+
+	h.mu.RLock()
+
+	out := make([]string, 0, len(h.active))
+
+	for id := range h.active {
+		if visible == nil || visible[id] {
+			out = append(out, id)
+		}
+	}
+
+	h.mu.RUnlock()
+
+	sort.Strings(out)
+
+	writeAdminJSON(w, out)
+}
+
+func (h *Hub) adminDisconnect(w http.ResponseWriter, r *http.Request, caller *token.ValidToken) {
+	var (
+		query   = r.URL.Query()
+		agent   = query.Get("agent")
+		account = query.Get("account")
+	)
+
+	if agent == "" && account == "" {
+		http.Error(w, "agent or account must be given", http.StatusBadRequest)
+		return
+	}
+
+	var closed []string
+
+	for _, ai := range h.adminAgentsFor(caller, account) {
+		if agent != "" && ai.ID.String() != agent {
+			continue
+		}
+
+		h.L.Info("disconnecting agent from admin api",
+			"agent", ai.ID, "account", ai.Account, "caller-role", caller.Body.Role.String())
+
+		ai.sess.Close()
+
+		closed = append(closed, ai.ID.String())
+	}
+
+	if agent != "" && len(closed) == 0 {
+		http.Error(w, "no such agent", http.StatusNotFound)
+		return
+	}
+
+	if closed == nil {
+		closed = []string{}
+	}
+
+	writeAdminJSON(w, map[string][]string{
+		"disconnected": closed,
+	})
+}
+
+func writeAdminJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
@@ -0,0 +1,141 @@
+package hub
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/horizon/pkg/agent"
+	"github.com/hashicorp/horizon/pkg/discovery"
+	"github.com/hashicorp/horizon/pkg/pb"
+	"github.com/hashicorp/horizon/pkg/testutils/central"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdmin(t *testing.T) {
+	central.Dev(t, func(setup *central.DevSetup) {
+		L := hclog.L()
+
+		hub, err := NewHub(L, setup.ControlClient, setup.HubServToken)
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		go hub.Run(ctx, setup.ClientListener)
+
+		time.Sleep(time.Second)
+
+		g, err := agent.NewAgent(L.Named("agent"))
+		require.NoError(t, err)
+
+		g.Token = setup.AgentToken
+
+		serviceId, err := g.AddService(&agent.Service{
+			Type:    "test",
+			Labels:  pb.ParseLabelSet("env=test,service=echo"),
+			Handler: agent.EchoHandler(),
+		})
+		require.NoError(t, err)
+
+		err = g.Start(ctx, discovery.HubConfigs(discovery.HubConfig{
+			Addr:     setup.HubAddr,
+			Insecure: true,
+		}))
+		require.NoError(t, err)
+
+		go g.Wait(ctx)
+
+		time.Sleep(time.Second)
+
+		call := func(method, path, token string, out interface{}) int {
+			req := httptest.NewRequest(method, path, nil)
+
+			if token != "" {
+				req.Header.Set("Authorization", "Bearer "+token)
+			}
+
+			w := httptest.NewRecorder()
+
+			hub.ServeHTTP(w, req)
+
+			if out != nil && w.Code == http.StatusOK {
+				require.NoError(t, json.NewDecoder(w.Body).Decode(out))
+			}
+
+			return w.Code
+		}
+
+		t.Run("requires a hub or management token", func(t *testing.T) {
+			assert.Equal(t, http.StatusUnauthorized, call("GET", "/__hzn/admin/agents", "", nil))
+			assert.Equal(t, http.StatusUnauthorized, call("GET", "/__hzn/admin/agents", "garbage", nil))
+			assert.Equal(t, http.StatusForbidden, call("GET", "/__hzn/admin/agents", setup.AgentToken, nil))
+			assert.Equal(t, http.StatusMethodNotAllowed, call("GET", "/__hzn/admin/disconnect", setup.HubToken, nil))
+		})
+
+		var agents []AgentStatus
+
+		t.Run("lists the connected agents and services", func(t *testing.T) {
+			code := call("GET", "/__hzn/admin/agents", setup.HubToken, &agents)
+			require.Equal(t, http.StatusOK, code)
+
+			require.Equal(t, 1, len(agents))
+
+			st := agents[0]
+			assert.Equal(t, setup.Account.StringKey(), st.Account)
+			assert.False(t, st.ConnectedAt.IsZero())
+
+			require.Equal(t, 1, len(st.Services))
+			assert.Equal(t, serviceId.String(), st.Services[0].Id)
+			assert.Equal(t, "env=test, service=echo", st.Services[0].Labels)
+
+			var services []string
+
+			code = call("GET", "/__hzn/admin/services", setup.HubToken, &services)
+			require.Equal(t, http.StatusOK, code)
+
+			assert.Equal(t, []string{serviceId.SpecString()}, services)
+
+			var other []AgentStatus
+
+			code = call("GET", "/__hzn/admin/agents?account=/!"+pb.NewULID().String(), setup.HubToken, &other)
+			require.Equal(t, http.StatusOK, code)
+
+			assert.Equal(t, 0, len(other))
+		})
+
+		t.Run("disconnects agents", func(t *testing.T) {
+			require.Equal(t, 1, len(agents))
+
+			code := call("POST", "/__hzn/admin/disconnect?agent="+pb.NewULID().String(), setup.HubToken, nil)
+			assert.Equal(t, http.StatusNotFound, code)
+
+			var out map[string][]string
+
+			code = call("POST",
+				"/__hzn/admin/disconnect?account="+url.QueryEscape(setup.Account.StringKey()),
+				setup.HubToken, &out)
+			require.Equal(t, http.StatusOK, code)
+
+			assert.Equal(t, []string{agents[0].Id}, out["disconnected"])
+
+			time.Sleep(time.Second)
+
+			var after []AgentStatus
+
+			code = call("GET", "/__hzn/admin/agents", setup.HubToken, &after)
+			require.Equal(t, http.StatusOK, code)
+
+			// The agent reconnects, but as a new session.
+			for _, st := range after {
+				assert.NotEqual(t, agents[0].Id, st.Id)
+			}
+		})
+	})
+}
@@ -2,8 +2,11 @@ package hub
 
 import (
 	"context"
+	"crypto/tls"
 	"io"
 	"net"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/hashicorp/horizon/pkg/connect"
@@ -23,6 +26,11 @@ func (h *Hub) ConnectToService(
 	proto string,
 	token string,
 ) (wire.Context, error) {
+	// New work would keep the drain from ever finishing.
+	if h.isDraining() {
+		return nil, ErrDraining
+	}
+
 	var (
 		wctx wire.Context
 		err  error
@@ -142,34 +150,51 @@ func (h *Hub) ConnectToService(
 		}
 	}()
 
-	wrapped := wire.WithCloser(wctx, func() error { cancel(); return nil })
+	// Count the connection until it's closed so that a drain waits for it.
+	atomic.AddInt64(h.activeStreams, 1)
+
+	var once sync.Once
+
+	wrapped := wire.WithCloser(wctx, func() error {
+		cancel()
+		once.Do(func() {
+			atomic.AddInt64(h.activeStreams, -1)
+		})
+		return nil
+	})
 
 	return wrapped, nil
 }
 
-func (h *Hub) connectToRemoteService(
-	ctx context.Context,
-	target *pb.ServiceRoute,
-	account *pb.Account,
-	proto string,
-	token string,
-) (wire.Context, error) {
-	defer timing.Track(ctx, "connect-remote").Stop()
+// Returns the TLS configuration used to verify the peer hub with the given
+// id. Hubs are named within the domain of the certificate control gives them,
+// and since they all share that certificate it's also pinned.
+func (h *Hub) peerTLSConfig(id *pb.ULID) *tls.Config {
+	var serverName string
 
+	if domain := h.cc.HubDomain(); domain != "" {
+		serverName = id.SpecString() + "." + domain
+	}
+
+	return connect.HubTLSConfig(serverName, h.RootCAs, h.cc.HubCertificate())
+}
+
+// Opens a new session to the hub with the given id, authenticated with token.
+func (h *Hub) dialHub(ctx context.Context, id *pb.ULID, token string) (*connect.Session, error) {
 	L := h.L
 
-	locs, err := h.cc.GetHubAddresses(ctx, target.Hub)
+	locs, err := h.cc.GetHubAddresses(ctx, id)
 	if err != nil {
-		L.Error("error fetching locations for target hub", "hub", target.Hub)
+		L.Error("error fetching locations for target hub", "hub", id)
 		return nil, err
 	}
 
 	if len(locs) == 0 {
-		L.Error("no locations for target hub", "hub", target.Hub)
+		L.Error("no locations for target hub", "hub", id)
 		return nil, ErrNoSuchSession
 	}
 
-	L.Trace("locations for target hub", "hub", target.Hub, "locations", locs)
+	L.Trace("locations for target hub", "hub", id, "locations", locs)
 
 	addr, err := h.pickAddress(locs)
 	if err != nil {
@@ -186,23 +211,31 @@ func (h *Hub) connectToRemoteService(
 
 	addr = net.JoinHostPort(host, port)
 
-	L.Trace("spawning connection to peer hub", "hub", target.Hub, "addr", addr)
+	L.Trace("spawning connection to peer hub", "hub", id, "addr", addr)
 
-	// TODO: rather than spinning up a new session each time, use a connection
-	// pool.
-	session, err := connect.Connect(L, addr, token)
-	if err != nil {
-		return nil, err
-	}
+	return connect.Connect(L, addr, token, h.peerTLSConfig(id))
+}
+
+func (h *Hub) connectToRemoteService(
+	ctx context.Context,
+	target *pb.ServiceRoute,
+	account *pb.Account,
+	proto string,
+	token string,
+) (wire.Context, error) {
+	defer timing.Track(ctx, "connect-remote").Stop()
 
 	// We're allowing the target hub to do it's own lookup again rather than
 	// passing the service id we calculated here. The advantage is that things
 	// might have changed and the target has a better target (which would result
 	// in multiple relays).
-	conn, err := session.ConnecToAccountService(account, target.Labels)
+	conn, release, err := h.pool.connect(ctx, target.Hub, token, &pb.ConnectRequest{
+		Target:       target.Labels,
+		PivotAccount: account,
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	return wire.WithCloser(conn.WireContext(account), session.Close), nil
+	return wire.WithCloser(conn.WireContext(account), release), nil
 }
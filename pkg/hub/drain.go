@@ -0,0 +1,145 @@
+package hub
+
+import (
+	"context"
+	"io"
+	"sync/atomic"
+	"time"
+
+	"github.com/hashicorp/horizon/pkg/pb"
+	"github.com/hashicorp/horizon/pkg/wire"
+	"github.com/pierrec/lz4/v3"
+	"github.com/pkg/errors"
+)
+
+var ErrDraining = errors.New("hub is draining")
+
+// How long Drain lets streams finish if its context has no deadline.
+const DefaultDrainTimeout = 30 * time.Second
+
+// How often Drain checks if the active streams have finished.
+var drainPollInterval = 100 * time.Millisecond
+
+func (h *Hub) isDraining() bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return h.draining
+}
+
+// Drain gracefully stops the hub. Each agent session is sent a GOAWAY with
+// the drain deadline so the agent can connect to another hub, and new
+// sessions, streams and ingress connections are refused while the health
+// check fails. The streams already running are given
+// until the deadline of ctx, or DefaultDrainTimeout if it has none, to finish.
+// Finally all agent sessions are closed.
+func (h *Hub) Drain(ctx context.Context) error {
+	h.mu.Lock()
+
+	if h.draining {
+		h.mu.Unlock()
+		return nil
+	}
+
+	h.draining = true
+
+	agents := make([]*agentConn, 0, len(h.agents))
+	for ai := range h.agents {
+		agents = append(agents, ai)
+	}
+
+	h.mu.Unlock()
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, DefaultDrainTimeout)
+		defer cancel()
+
+		deadline, _ = ctx.Deadline()
+	}
+
+	h.L.Info("draining hub, asking agents to move to other hubs",
+		"agents", len(agents), "deadline", deadline)
+
+	for _, ai := range agents {
+		go func(ai *agentConn) {
+			err := h.sendGoAway(ai, deadline)
+			if err != nil {
+				h.L.Warn("error sending goaway to agent", "agent", ai.ID, "error", err)
+			}
+		}(ai)
+	}
+
+	var err error
+
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+
+drain:
+	for atomic.LoadInt64(h.activeStreams) > 0 {
+		select {
+		case <-ctx.Done():
+			h.L.Warn("streams still active at the end of the drain, closing them",
+				"active", atomic.LoadInt64(h.activeStreams))
+			err = ctx.Err()
+			break drain
+		case <-ticker.C:
+		}
+	}
+
+	h.mu.RLock()
+	agents = agents[:0]
+	for ai := range h.agents {
+		agents = append(agents, ai)
+	}
+	h.mu.RUnlock()
+
+	h.L.Info("closing agent sessions", "agents", len(agents))
+
+	for _, ai := range agents {
+		ai.sess.Close()
+	}
+
+	return err
+}
+
+// Tells the agent that the hub will close its session at deadline.
+func (h *Hub) sendGoAway(ai *agentConn, deadline time.Time) error {
+	stream, err := ai.sess.OpenStream()
+	if err != nil {
+		return err
+	}
+
+	defer stream.Close()
+
+	var w io.Writer = stream
+
+	if ai.useLZ4 {
+		w = lz4.NewWriter(stream)
+	}
+
+	fw, err := wire.NewFramingWriter(w)
+	if err != nil {
+		return err
+	}
+
+	defer fw.Recycle()
+
+	_, err = fw.WriteMarshal(wire.TagGoAway, pb.NewTimestamp(deadline))
+	return err
+}
+
+func (h *Hub) trackAgent(ai *agentConn) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.agents[ai] = struct{}{}
+}
+
+func (h *Hub) untrackAgent(ai *agentConn) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	delete(h.agents, ai)
+}
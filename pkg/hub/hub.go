@@ -3,6 +3,7 @@ package hub
 import (
 	"context"
 	"crypto/tls"
+	"crypto/x509"
 	"io"
 	"net"
 	"net/http"
@@ -11,6 +12,7 @@ import (
 	"time"
 
 	"github.com/hashicorp/go-hclog"
+	lru "github.com/hashicorp/golang-lru"
 	"github.com/hashicorp/horizon/internal/httpassets"
 	"github.com/hashicorp/horizon/pkg/control"
 	"github.com/hashicorp/horizon/pkg/pb"
@@ -52,8 +54,42 @@ type Hub struct {
 	mux *http.ServeMux
 	fe  *web.Frontend
 
+	// The methods agents can call on their sessions.
+	rpc wire.RPCServer
+
+	// Used to connect to services on other hubs for web requests and TCP
+	// ingress connections.
+	feToken string
+
+	// Bandwidth limiters by account for raw TCP connections.
+	tcpRates *lru.ARCCache
+
+	// Orders the routes to a service for both agent streams and web requests.
+	selector control.RouteSelector
+
+	// Sessions to other hubs, reused when relaying connections to them.
+	pool *sessionPool
+
+	// The sessions currently connected, so a drain can reach each of them.
+	// Protected by mu, as is draining.
+	agents   map[*agentConn]struct{}
+	draining bool
+
+	// Streams being handled for agents and connections made to services,
+	// which a drain waits on.
+	activeStreams *int64
+
 	activeAgents *int64
 	totalAgents  *int64
+
+	// What each account is using on this hub, checked against the quotas
+	// in its tokens.
+	usageMu sync.Mutex
+	usage   map[string]*accountUsage
+
+	// Additional roots used to verify peer hubs. Peers presenting the
+	// certificate control distributes to all hubs are always accepted.
+	RootCAs *x509.CertPool
 }
 
 func NewHub(L hclog.Logger, client *control.Client, feToken string) (*Hub, error) {
@@ -66,31 +102,56 @@ func NewHub(L hclog.Logger, client *control.Client, feToken string) (*Hub, error
 	cfg.LogOutput = nil
 
 	h := &Hub{
-		L:            L,
-		cfg:          cfg,
-		active:       make(map[string]*agentConnection),
-		cc:           client,
-		id:           client.Id(),
-		mux:          http.NewServeMux(),
-		activeAgents: new(int64),
-		totalAgents:  new(int64),
+		L:             L,
+		cfg:           cfg,
+		active:        make(map[string]*agentConnection),
+		cc:            client,
+		feToken:       feToken,
+		id:            client.Id(),
+		mux:           http.NewServeMux(),
+		activeAgents:  new(int64),
+		agents:        make(map[*agentConn]struct{}),
+		activeStreams: new(int64),
+		totalAgents:   new(int64),
+		usage:         make(map[string]*accountUsage),
 	}
 
+	rates, err := lru.NewARC(10000)
+	if err != nil {
+		return nil, err
+	}
+
+	h.tcpRates = rates
+
 	fe, err := web.NewFrontend(L, h, client, feToken)
 	if err != nil {
 		return nil, err
 	}
 
 	h.fe = fe
+	h.SetRouteSelector(control.NewScoringSelector(client))
+
 	h.mux.HandleFunc("/__hzn/healthz", h.handleHeathz)
+	h.registerAdmin()
 	h.mux.Handle("/__hzn/static/", http.StripPrefix("/__hzn/static/", http.FileServer(httpassets.AssetFile())))
 	h.mux.Handle("/", h.fe)
 
 	h.location = client.Locations()
 
+	h.pool = newSessionPool(L.Named("pool"), h.dialHub)
+
+	h.registerRPC()
+
 	return h, nil
 }
 
+// SetRouteSelector changes how the hub picks between the routes to a service.
+// Must be called before the hub is run.
+func (h *Hub) SetRouteSelector(rs control.RouteSelector) {
+	h.selector = rs
+	h.fe.Selector = rs
+}
+
 func (h *Hub) Serve(ctx context.Context, l net.Listener) error {
 	for {
 		conn, err := l.Accept()
@@ -111,6 +172,11 @@ func (hub *Hub) Run(ctx context.Context, li net.Listener) error {
 	defer cancel()
 
 	go hub.sendStats(ctx)
+	go hub.pool.run(ctx)
+
+	// TLS connections for names linked with :sni are passed through to
+	// services before the ingress terminates TLS.
+	li = hub.passthroughListener(ctx, li)
 
 	err := hub.cc.RunIngress(ctx, li, npn, hub)
 	if err != nil {
@@ -199,18 +265,50 @@ type agentConn struct {
 
 	token *token.ValidToken
 
-	sess        *yamux.Session
-	useLZ4      bool
+	sess   *yamux.Session
+	useLZ4 bool
+
+	// protects cleanups, connectOnly, and preamble.Services, which change
+	// when the agent adds or removes services on a live session.
+	mu          sync.Mutex
 	cleanups    []func()
 	connectOnly bool
+
+	// serializes the agent's service updates, so each sees the quota
+	// reservations and services left by the one before.
+	updates sync.Mutex
+}
+
+// Indicates if the agent currently advertises the service with the given id.
+// Must be called with ai.mu held.
+func (ai *agentConn) advertises(id *pb.ULID) bool {
+	for _, serv := range ai.preamble.Services {
+		if serv.ServiceId.Equal(id) {
+			return true
+		}
+	}
+
+	return false
 }
 
 func (ai *agentConn) cleanup() {
-	for _, f := range ai.cleanups {
+	ai.mu.Lock()
+	cleanups := ai.cleanups
+	ai.mu.Unlock()
+
+	for _, f := range cleanups {
 		f()
 	}
 }
 
+// Returns the services currently advertised by the agent.
+func (ai *agentConn) services() []*pb.ServiceInfo {
+	ai.mu.Lock()
+	defer ai.mu.Unlock()
+
+	return append([]*pb.ServiceInfo(nil), ai.preamble.Services...)
+}
+
 func (h *Hub) handshake(ctx context.Context, fr *wire.FramingReader, fw *wire.FramingWriter) (*agentConn, error) {
 	var preamble pb.Preamble
 
@@ -240,6 +338,17 @@ func (h *Hub) handshake(ctx context.Context, fr *wire.FramingReader, fw *wire.Fr
 		useLZ4 = true
 	}
 
+	if h.isDraining() {
+		wc.Status = "draining"
+
+		_, err = fw.WriteMarshal(1, &wc)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error marshalling confirmation")
+		}
+
+		return nil, ErrDraining
+	}
+
 	vt, err := h.ValidateToken(preamble.Token)
 	if err != nil {
 		h.L.Error("invalid token received", "error", err)
@@ -267,6 +376,27 @@ func (h *Hub) handshake(ctx context.Context, fr *wire.FramingReader, fw *wire.Fr
 		}
 	}
 
+	// Sessions that only connect to services, such as the ones hubs use to
+	// relay connections, don't count against the agents quota until they
+	// advertise a service.
+	connectOnly := len(preamble.Services) == 0
+
+	if !connectOnly {
+		quotas := h.cc.AccountQuotas(vt.Account())
+
+		err = h.reserveAgent(vt.Account(), quotas, len(preamble.Services))
+		if err != nil {
+			wc.Status = "over-quota"
+
+			_, werr := fw.WriteMarshal(1, &wc)
+			if werr != nil {
+				return nil, errors.Wrapf(werr, "error marshalling confirmation")
+			}
+
+			return nil, err
+		}
+	}
+
 	for _, serv := range preamble.Services {
 		err = h.cc.AddService(ctx, &pb.ServiceRequest{
 			Account:  vt.Account(),
@@ -278,6 +408,7 @@ func (h *Hub) handshake(ctx context.Context, fr *wire.FramingReader, fw *wire.Fr
 		})
 
 		if err != nil {
+			h.releaseAgent(vt.Account(), len(preamble.Services))
 			return nil, errors.Wrapf(err, "error adding services")
 		}
 
@@ -291,12 +422,30 @@ func (h *Hub) handshake(ctx context.Context, fr *wire.FramingReader, fw *wire.Fr
 
 	_, err = fw.WriteMarshal(1, &wc)
 	if err != nil {
+		if !connectOnly {
+			h.releaseAgent(vt.Account(), len(preamble.Services))
+		}
+
 		return nil, errors.Wrapf(err, "error marshalling confirmation")
 	}
 
-	cleanup := func() {
-		for _, serv := range preamble.Services {
-			err = h.cc.RemoveService(ctx, &pb.ServiceRequest{
+	ai := &agentConn{
+		ID:            pb.NewULID(),
+		Account:       vt.Account(),
+		Start:         pb.NewTimestamp(ts),
+		Services:      int32(len(preamble.Services)),
+		ActiveStreams: new(int64),
+		TotalStreams:  new(int64),
+		stoken:        preamble.Token,
+		preamble:      &preamble,
+		token:         vt,
+		useLZ4:        useLZ4,
+		connectOnly:   connectOnly,
+	}
+
+	ai.cleanups = append(ai.cleanups, func() {
+		for _, serv := range ai.services() {
+			err := h.cc.RemoveService(ctx, &pb.ServiceRequest{
 				Account:  vt.Account(),
 				Hub:      h.id,
 				Id:       serv.ServiceId,
@@ -310,21 +459,15 @@ func (h *Hub) handshake(ctx context.Context, fr *wire.FramingReader, fw *wire.Fr
 				// we want to try all of them regardless of the error.
 			}
 		}
-	}
 
-	ai := &agentConn{
-		ID:            pb.NewULID(),
-		Account:       vt.Account(),
-		Services:      int32(len(preamble.Services)),
-		ActiveStreams: new(int64),
-		TotalStreams:  new(int64),
-		stoken:        preamble.Token,
-		preamble:      &preamble,
-		token:         vt,
-		useLZ4:        useLZ4,
-		cleanups:      []func(){cleanup},
-		connectOnly:   len(preamble.Services) == 0,
-	}
+		ai.mu.Lock()
+		serving := !ai.connectOnly
+		ai.mu.Unlock()
+
+		if serving {
+			h.releaseAgent(vt.Account(), len(ai.services()))
+		}
+	})
 
 	return ai, nil
 }
@@ -334,7 +477,7 @@ func (h *Hub) registerAgent(ai *agentConn) error {
 	atomic.AddInt64(h.totalAgents, 1)
 
 	h.mu.Lock()
-	for _, serv := range ai.preamble.Services {
+	for _, serv := range ai.services() {
 		h.active[serv.ServiceId.SpecString()] = &agentConnection{
 			useLZ4:  ai.useLZ4,
 			session: ai.sess,
@@ -344,16 +487,18 @@ func (h *Hub) registerAgent(ai *agentConn) error {
 
 	h.L.Debug("register agent", "id", ai.ID)
 
+	ai.mu.Lock()
 	ai.cleanups = append(ai.cleanups, func() {
 		h.L.Debug("unregister agent", "id", ai.ID)
 		atomic.AddInt64(h.activeAgents, -1)
 
 		h.mu.Lock()
-		for _, serv := range ai.preamble.Services {
+		for _, serv := range ai.services() {
 			delete(h.active, serv.ServiceId.SpecString())
 		}
 		h.mu.Unlock()
 	})
+	ai.mu.Unlock()
 
 	return nil
 }
@@ -401,6 +546,9 @@ func (h *Hub) handleConn(ctx context.Context, conn net.Conn) {
 
 	ai.sess = sess
 
+	h.trackAgent(ai)
+	defer h.untrackAgent(ai)
+
 	if !ai.connectOnly {
 		err = h.registerAgent(ai)
 		if err != nil {
@@ -448,6 +596,7 @@ func (h *Hub) handleConn(ctx context.Context, conn net.Conn) {
 
 		atomic.AddInt64(ai.ActiveStreams, 1)
 		atomic.AddInt64(ai.TotalStreams, 1)
+		atomic.AddInt64(h.activeStreams, 1)
 
 		h.sendAgentInfoFlow(ai)
 
@@ -7,6 +7,13 @@ func (h *Hub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Hub) handleHeathz(w http.ResponseWriter, r *http.Request) {
+	// Fail the check while draining so load balancers stop sending traffic.
+	if h.isDraining() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte("draining"))
+		return
+	}
+
 	w.WriteHeader(200)
 	w.Write([]byte("ok"))
 }
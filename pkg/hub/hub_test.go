@@ -22,6 +22,20 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
+// Echoes a single message and then ends the stream.
+type echoOnceHandler struct{}
+
+func (echoOnceHandler) HandleRequest(ctx context.Context, L hclog.Logger, sctx agent.ServiceContext) error {
+	var mb wire.MarshalBytes
+
+	tag, err := sctx.ReadMarshal(&mb)
+	if err != nil {
+		return err
+	}
+
+	return sctx.WriteMarshal(tag, &mb)
+}
+
 func TestHub(t *testing.T) {
 	t.Run("is registered in control server database", func(t *testing.T) {
 		central.Dev(t, func(setup *central.DevSetup) {
@@ -292,7 +306,7 @@ func TestHub(t *testing.T) {
 
 				time.Sleep(time.Second)
 
-				sess, err := connect.Connect(L, li.Addr().String(), setup.AgentToken)
+				sess, err := connect.Connect(L, li.Addr().String(), setup.AgentToken, connect.HubTLSConfig("", nil, nc.HubCertificate()))
 				require.NoError(t, err)
 
 				L.Info("connecting to service")
@@ -417,4 +431,280 @@ func TestHub(t *testing.T) {
 			})
 		})
 	})
+
+	t.Run("drains agents to other hubs", func(t *testing.T) {
+		central.Dev(t, func(setup *central.DevSetup) {
+			L := hclog.L()
+
+			hub1, err := NewHub(L.Named("hub1"), setup.ControlClient, setup.HubServToken)
+			require.NoError(t, err)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			go hub1.Run(ctx, setup.ClientListener)
+
+			time.Sleep(time.Second)
+
+			setup.NewControlClient(t, func(nc *control.Client, li net.Listener) {
+				hub2, err := NewHub(L.Named("hub2"), nc, setup.HubServToken)
+				require.NoError(t, err)
+
+				go nc.Run(ctx)
+
+				go hub2.Run(ctx, li)
+
+				time.Sleep(time.Second)
+
+				g, err := agent.NewAgent(L.Named("agent"))
+				require.NoError(t, err)
+
+				g.Token = setup.AgentToken
+				g.TargetSessions = 1
+
+				_, err = g.AddService(&agent.Service{
+					Type:    "test",
+					Labels:  pb.ParseLabelSet("env=test,service=echo"),
+					Handler: echoOnceHandler{},
+				})
+				require.NoError(t, err)
+
+				err = g.Start(ctx, discovery.HubConfigs(
+					discovery.HubConfig{
+						Addr:     setup.HubAddr,
+						Insecure: true,
+					},
+					discovery.HubConfig{
+						Addr:     li.Addr().String(),
+						Insecure: true,
+					},
+				))
+				require.NoError(t, err)
+
+				go g.Wait(ctx)
+
+				st := g.Status()
+				require.Equal(t, 1, len(st.Hubs))
+				assert.Equal(t, setup.HubAddr, st.Hubs[0].Addr)
+
+				tlsCfg := connect.HubTLSConfig("", nil, setup.ControlClient.HubCertificate())
+
+				sess, err := connect.Connect(L, setup.HubAddr, setup.AgentToken, tlsCfg)
+				require.NoError(t, err)
+
+				defer sess.Close()
+
+				c, err := sess.ConnecToService(pb.ParseLabelSet("service=echo"))
+				require.NoError(t, err)
+
+				drainCtx, drainCancel := context.WithTimeout(ctx, 10*time.Second)
+				defer drainCancel()
+
+				drained := make(chan error, 1)
+
+				go func() {
+					drained <- hub1.Drain(drainCtx)
+				}()
+
+				var moved bool
+
+				for i := 0; i < 50 && !moved; i++ {
+					time.Sleep(100 * time.Millisecond)
+
+					for _, hs := range g.Status().Hubs {
+						if hs.Addr == li.Addr().String() {
+							moved = true
+						}
+					}
+				}
+
+				require.True(t, moved, "agent did not connect to the second hub")
+
+				// The hub refuses new streams while draining...
+				_, err = sess.ConnecToService(pb.ParseLabelSet("service=echo"))
+				assert.Error(t, err)
+
+				// ...and new sessions.
+				_, err = connect.Connect(L, setup.HubAddr, setup.AgentToken, tlsCfg)
+				assert.Error(t, err)
+
+				// But the stream opened before the drain still works.
+				mb := wire.MarshalBytes("hello hzn while draining")
+
+				err = c.WriteMarshal(30, &mb)
+				require.NoError(t, err)
+
+				var mb2 wire.MarshalBytes
+
+				tag, err := c.ReadMarshal(&mb2)
+				require.NoError(t, err)
+
+				assert.Equal(t, byte(30), tag)
+				assert.Equal(t, mb, mb2)
+
+				c.Close()
+
+				select {
+				case err := <-drained:
+					require.NoError(t, err)
+				case <-drainCtx.Done():
+					t.Fatal("drain did not finish once the stream ended")
+				}
+			})
+		})
+	})
+
+	t.Run("connects to a specific service by id", func(t *testing.T) {
+		central.Dev(t, func(setup *central.DevSetup) {
+			L := hclog.L()
+
+			hub, err := NewHub(L, setup.ControlClient, setup.HubServToken)
+			require.NoError(t, err)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			go hub.Run(ctx, setup.ClientListener)
+
+			time.Sleep(time.Second)
+
+			g, err := agent.NewAgent(L.Named("agent"))
+			require.NoError(t, err)
+
+			g.Token = setup.AgentToken
+
+			labels := pb.ParseLabelSet("env=test,service=echo")
+
+			_, err = g.AddService(&agent.Service{
+				Type:    "test",
+				Labels:  labels,
+				Handler: agent.EchoHandler(),
+			})
+			require.NoError(t, err)
+
+			serviceId, err := g.AddService(&agent.Service{
+				Type:    "test",
+				Labels:  labels,
+				Handler: agent.EchoHandler(),
+			})
+			require.NoError(t, err)
+
+			err = g.Start(ctx, discovery.HubConfigs(discovery.HubConfig{
+				Addr:     setup.HubAddr,
+				Insecure: true,
+			}))
+			require.NoError(t, err)
+
+			go g.Wait(ctx)
+
+			time.Sleep(time.Second)
+
+			tlsCfg := connect.HubTLSConfig("", nil, setup.ControlClient.HubCertificate())
+
+			sess, err := connect.Connect(L, setup.HubAddr, setup.AgentToken, tlsCfg)
+			require.NoError(t, err)
+
+			defer sess.Close()
+
+			for i := 0; i < 5; i++ {
+				conn, err := sess.ConnectWithRequest(&pb.ConnectRequest{
+					Target:    labels,
+					ServiceId: serviceId,
+				})
+				require.NoError(t, err)
+
+				assert.Equal(t, serviceId, conn.ServiceId())
+
+				conn.Close()
+			}
+
+			_, err = sess.ConnectWithRequest(&pb.ConnectRequest{
+				Target:    labels,
+				ServiceId: pb.NewULID(),
+			})
+			assert.Error(t, err)
+		})
+	})
+
+	t.Run("rejects agents over the account quota", func(t *testing.T) {
+		central.Dev(t, func(setup *central.DevSetup) {
+			L := hclog.L()
+
+			hub, err := NewHub(L, setup.ControlClient, setup.HubServToken)
+			require.NoError(t, err)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			go hub.Run(ctx, setup.ClientListener)
+
+			time.Sleep(time.Second)
+
+			_, err = setup.MgmtClient.SetAccountQuotas(ctx, &pb.AccountQuotas{
+				Account: setup.Account,
+				Agents:  1,
+			})
+			require.NoError(t, err)
+
+			require.NoError(t, setup.ControlClient.ForceAccountQuotaUpdate(ctx, L))
+
+			tlsCfg := connect.HubTLSConfig("", nil, setup.ControlClient.HubCertificate())
+
+			// Opens an agent session advertising a service, returning the
+			// status the hub confirmed it with.
+			serve := func() (net.Conn, string) {
+				conn, err := tls.Dial("tcp", setup.HubAddr, tlsCfg)
+				require.NoError(t, err)
+
+				fw, err := wire.NewFramingWriter(conn)
+				require.NoError(t, err)
+
+				_, err = fw.WriteMarshal(1, &pb.Preamble{
+					Token: setup.AgentToken,
+					Services: []*pb.ServiceInfo{
+						{
+							ServiceId: pb.NewULID(),
+							Type:      "test",
+							Labels:    pb.ParseLabelSet("service=quota"),
+						},
+					},
+				})
+				require.NoError(t, err)
+
+				fr, err := wire.NewFramingReader(conn)
+				require.NoError(t, err)
+
+				var wc pb.Confirmation
+
+				_, _, err = fr.ReadMarshal(&wc)
+				require.NoError(t, err)
+
+				return conn, wc.Status
+			}
+
+			conn, status := serve()
+			require.Equal(t, "connected", status)
+
+			other, status := serve()
+			other.Close()
+
+			assert.Equal(t, "over-quota", status)
+
+			// Sessions that only connect to services don't use up the quota.
+			sess, err := connect.Connect(L, setup.HubAddr, setup.AgentToken, tlsCfg)
+			require.NoError(t, err)
+
+			sess.Close()
+
+			conn.Close()
+
+			time.Sleep(time.Second)
+
+			// Once the first session is gone, there is room for another.
+			conn, status = serve()
+			conn.Close()
+
+			assert.Equal(t, "connected", status)
+		})
+	})
 }
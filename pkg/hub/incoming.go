@@ -3,12 +3,11 @@ package hub
 import (
 	"context"
 	"io"
-	"net"
 	"strconv"
 	"sync/atomic"
 	"time"
 
-	"github.com/hashicorp/horizon/pkg/connect"
+	"github.com/hashicorp/horizon/pkg/control"
 	"github.com/hashicorp/horizon/pkg/pb"
 	"github.com/hashicorp/horizon/pkg/wire"
 	"github.com/hashicorp/yamux"
@@ -21,14 +20,15 @@ type pivotAccountContext struct {
 	pa *pb.Account
 }
 
-func (p *pivotAccountContext) AccountId() *pb.ULID {
-	return p.pa.AccountId
+func (p *pivotAccountContext) Account() *pb.Account {
+	return p.pa
 }
 
 func (h *Hub) handleAgentStream(ctx context.Context, ai *agentConn, stream *yamux.Stream, wctx wire.Context) {
 	defer stream.Close()
 	defer func() {
-		atomic.AddInt64(ai.TotalStreams, -1)
+		atomic.AddInt64(ai.ActiveStreams, -1)
+		atomic.AddInt64(h.activeStreams, -1)
 		h.sendAgentInfoFlow(ai)
 	}()
 
@@ -37,19 +37,47 @@ func (h *Hub) handleAgentStream(ctx context.Context, ai *agentConn, stream *yamu
 	L.Trace("stream accepted", "hub", h.id, "id", stream.StreamID())
 	defer L.Trace("stream ended", "id", stream.StreamID())
 
-	var req pb.ConnectRequest
+	// The first message decides what the stream is for, so read it raw and
+	// decode it once we know the tag.
+	var msg wire.MarshalBytes
 
-	tag, err := wctx.ReadMarshal(&req)
+	tag, err := wctx.ReadMarshal(&msg)
 	if err != nil {
 		L.Error("error decoding request", "error", err)
 		return
 	}
 
-	if tag != 1 {
+	switch tag {
+	case 1:
+		// connect request, handled below
+	case wire.TagServiceAdd, wire.TagServiceRemove:
+		h.handleServiceUpdate(ctx, ai, tag, msg, wctx)
+		return
+	case wire.TagRPC:
+		h.handleRPC(ctx, ai, msg, wctx)
+		return
+	default:
 		L.Error("incorrect message tag", "tag", tag)
 		return
 	}
 
+	// Only connections are refused while draining and count against the
+	// stream quota, so agents can still update their services and make RPCs.
+	if h.isDraining() {
+		var resp pb.Response
+		resp.Error = ErrDraining.Error()
+		wctx.WriteMarshal(255, &resp)
+		return
+	}
+
+	var req pb.ConnectRequest
+
+	err = req.Unmarshal(msg)
+	if err != nil {
+		L.Error("error decoding request", "error", err)
+		return
+	}
+
 	if req.PivotAccount != nil {
 		if ai.token.AllowAccount(req.PivotAccount.Namespace) {
 			wctx = &pivotAccountContext{wctx, req.PivotAccount}
@@ -61,7 +89,21 @@ func (h *Hub) handleAgentStream(ctx context.Context, ai *agentConn, stream *yamu
 		}
 	}
 
-	calc, err := h.cc.LookupService(ctx, wctx.Account(), req.Target)
+	// Charged to the account the stream is for, which differs from the
+	// agent's when it pivots.
+	account := wctx.Account()
+
+	err = h.reserveStream(account, h.cc.AccountQuotas(account))
+	if err != nil {
+		var resp pb.Response
+		resp.Error = err.Error()
+		wctx.WriteMarshal(255, &resp)
+		return
+	}
+
+	defer h.releaseStream(account)
+
+	calc, err := h.cc.LookupService(ctx, account, req.Target)
 	if err != nil {
 		var resp pb.Response
 		resp.Error = err.Error()
@@ -69,19 +111,27 @@ func (h *Hub) handleAgentStream(ctx context.Context, ai *agentConn, stream *yamu
 		return
 	}
 
-	routes := calc.Services()
+	var routes []*pb.ServiceRoute
 
-	for len(routes) > 0 {
-		var target *pb.ServiceRoute
+	if req.ServiceId != nil {
+		for _, route := range calc.All {
+			if route.Id.Equal(req.ServiceId) {
+				routes = append(routes, route)
+				break
+			}
+		}
 
-		target, routes, err = h.pickRoute(routes)
-		if err != nil {
+		if len(routes) == 0 {
 			var resp pb.Response
-			resp.Error = err.Error()
+			resp.Error = errors.Wrapf(ErrNoSuchService, "service %s", req.ServiceId.SpecString()).Error()
 			wctx.WriteMarshal(255, &resp)
 			return
 		}
+	} else {
+		routes = h.selector.SelectRoutes(ctx, calc)
+	}
 
+	for _, target := range routes {
 		var fs pb.FlowStream
 		fs.FlowId = pb.NewULID()
 		fs.HubId = h.id
@@ -92,12 +142,21 @@ func (h *Hub) handleAgentStream(ctx context.Context, ai *agentConn, stream *yamu
 		fs.StartedAt = pb.NewTimestamp(time.Now())
 
 		err = h.bridgeToTarget(ctx, ai, &fs, target, &req, wctx)
-		if err != nil {
-			var resp pb.Response
-			resp.Error = err.Error()
-			wctx.WriteMarshal(255, &resp)
+		if err == nil {
 			return
 		}
+
+		// Nothing has been sent to the opener yet if the route is stale, so
+		// the next best route can still be tried.
+		if err == ErrNoSuchSession {
+			L.Debug("route has no session, trying next", "service", target.Id, "hub", target.Hub)
+			continue
+		}
+
+		var resp pb.Response
+		resp.Error = err.Error()
+		wctx.WriteMarshal(255, &resp)
+		return
 	}
 
 	var resp pb.Response
@@ -105,8 +164,38 @@ func (h *Hub) handleAgentStream(ctx context.Context, ai *agentConn, stream *yamu
 	wctx.WriteMarshal(255, &resp)
 }
 
-func (h *Hub) pickRoute(routes []*pb.ServiceRoute) (*pb.ServiceRoute, []*pb.ServiceRoute, error) {
-	return routes[0], routes[1:], nil
+// Tracks a connection to a route so the hub's route selector learns how
+// long it took and whether it failed.
+type routeAttempt struct {
+	selector control.RouteSelector
+	route    *pb.ServiceRoute
+	start    time.Time
+	done     func()
+}
+
+func (h *Hub) beginRoute(route *pb.ServiceRoute) *routeAttempt {
+	return &routeAttempt{
+		selector: h.selector,
+		route:    route,
+		start:    time.Now(),
+	}
+}
+
+// Records that the stream to the route is open.
+func (ra *routeAttempt) connected() {
+	ra.done = ra.selector.Connected(ra.route, time.Since(ra.start))
+}
+
+// Records the outcome of the attempt once the stream has ended or failed.
+func (ra *routeAttempt) finish(err error) {
+	if ra.done != nil {
+		ra.done()
+		return
+	}
+
+	if err != nil {
+		ra.selector.ConnectFailed(ra.route, err)
+	}
 }
 
 var ErrNoSuchSession = errors.New("no session found")
@@ -118,14 +207,19 @@ func (h *Hub) bridgeToTarget(
 	target *pb.ServiceRoute,
 	req *pb.ConnectRequest,
 	wctx wire.Context,
-) error {
+) (err error) {
 	L := h.L
 
 	L.Trace("bridging connection to hub", "from", h.id, "to", target.Hub)
 
+	ra := h.beginRoute(target)
+	defer func() {
+		ra.finish(err)
+	}()
+
 	// Oh look it's for me!
 	if !target.Hub.Equal(h.id) {
-		return h.forwardToTarget(ctx, ai, fs, target, req, wctx)
+		return h.forwardToTarget(ctx, ai, fs, target, req, wctx, ra)
 	}
 
 	h.mu.RLock()
@@ -142,7 +236,7 @@ func (h *Hub) bridgeToTarget(
 	var conack pb.ConnectAck
 	conack.ServiceId = target.Id
 
-	err := wctx.WriteMarshal(1, &conack)
+	err = wctx.WriteMarshal(1, &conack)
 	if err != nil {
 		return err
 	}
@@ -189,6 +283,8 @@ func (h *Hub) bridgeToTarget(
 
 	dsctx := wire.NewContext(wctx.Account(), fr, fw)
 
+	ra.connected()
+
 	return h.copyBetweenContexts(ctx, wctx, dsctx, fs, ai)
 }
 
@@ -313,53 +409,28 @@ func (h *Hub) forwardToTarget(
 	target *pb.ServiceRoute,
 	req *pb.ConnectRequest,
 	wctx wire.Context,
+	ra *routeAttempt,
 ) error {
-	L := h.L
-
-	locs, err := h.cc.GetHubAddresses(ctx, target.Hub)
-	if err != nil {
-		L.Error("error fetching locations for target hub", "hub", target.Hub)
-		return err
-	}
-
-	if len(locs) == 0 {
-		L.Error("no locations for target hub", "hub", target.Hub)
-		return ErrNoSuchSession
-	}
-
-	L.Trace("locations for target hub", "hub", target.Hub, "locations", locs)
-
-	addr, err := h.pickAddress(locs)
-	if err != nil {
-		return err
-	}
-
-	host, port, err := net.SplitHostPort(addr)
-	if err != nil {
-		host = addr
-		port = "443"
-	}
-
-	addr = net.JoinHostPort(host, port)
-
-	L.Trace("spawning connection to peer hub", "hub", target.Hub, "addr", addr)
-
-	session, err := connect.Connect(L, addr, ai.stoken)
-	if err != nil {
-		return err
-	}
-
 	// We're allowing the target hub to do it's own lookup again rather than
 	// passing the service id we calculated here. The advantage is that things
 	// might have changed and the target has a better target (which would result
-	// in multiple relays).
-	conn, err := session.ConnecToService(req.Target)
+	// in multiple relays). A request for a specific service keeps it's id so
+	// the target hub connects to exactly that one.
+	conn, release, err := h.pool.connect(ctx, target.Hub, ai.stoken, &pb.ConnectRequest{
+		Target:     req.Target,
+		ProtocolId: req.ProtocolId,
+		ServiceId:  req.ServiceId,
+	})
 	if err != nil {
 		return err
 	}
 
+	defer release()
+
 	dsctx := conn.WireContext(wctx.Account())
 
+	ra.connected()
+
 	// transmit a ack back to the opener that the service was found and is
 	// about to start.
 
@@ -0,0 +1,416 @@
+package hub
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/armon/go-metrics"
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/horizon/pkg/connect"
+	"github.com/hashicorp/horizon/pkg/pb"
+	"github.com/hashicorp/horizon/pkg/wire"
+	"github.com/pkg/errors"
+)
+
+const (
+	// The most sessions kept open to a hub for a single token.
+	DefaultPoolSessionsPerHub = 4
+
+	// The most sessions kept open to other hubs in total.
+	DefaultPoolSize = 256
+
+	// Once every session to a hub has this many streams, another session is
+	// opened, up to DefaultPoolSessionsPerHub.
+	DefaultPoolStreamsPerSession = 64
+
+	// Sessions without streams for this long are closed.
+	DefaultPoolIdleTimeout = 5 * time.Minute
+
+	// How often pooled sessions are checked.
+	DefaultPoolCheckInterval = 30 * time.Second
+)
+
+// Sessions to another hub are authenticated with a token, so they can only
+// be shared by connections using the same one.
+type poolKey struct {
+	hub   string
+	token string
+}
+
+type pooledSession struct {
+	key      poolKey
+	session  *connect.Session
+	streams  int
+	lastUsed time.Time
+
+	// Set for sessions opened while the pool was full. They're closed once
+	// their stream ends instead of being kept.
+	unpooled bool
+
+	removed bool
+}
+
+// Keeps sessions to other hubs open so that relaying a connection to a hub
+// doesn't require a new TLS handshake and preamble every time.
+type sessionPool struct {
+	L    hclog.Logger
+	dial func(ctx context.Context, hub *pb.ULID, token string) (*connect.Session, error)
+
+	maxPerHub   int
+	maxSessions int
+	maxStreams  int
+	idleTimeout time.Duration
+
+	mu       sync.Mutex
+	sessions map[poolKey][]*pooledSession
+	dialing  map[poolKey]int
+	total    int
+
+	// Unpooled and retired sessions, which aren't in sessions but still
+	// need closing when the pool shuts down.
+	detached map[*pooledSession]struct{}
+}
+
+func newSessionPool(L hclog.Logger, dial func(ctx context.Context, hub *pb.ULID, token string) (*connect.Session, error)) *sessionPool {
+	return &sessionPool{
+		L:           L,
+		dial:        dial,
+		maxPerHub:   DefaultPoolSessionsPerHub,
+		maxSessions: DefaultPoolSize,
+		maxStreams:  DefaultPoolStreamsPerSession,
+		idleTimeout: DefaultPoolIdleTimeout,
+		sessions:    make(map[poolKey][]*pooledSession),
+		dialing:     make(map[poolKey]int),
+		detached:    make(map[*pooledSession]struct{}),
+	}
+}
+
+// Opens a stream to the service matching req on the given hub. The
+// returned function closes the stream and must be called when the connection
+// is done. If the pooled session turns out to be broken, it is dropped and
+// the connection is tried again on a new one.
+func (p *sessionPool) connect(
+	ctx context.Context,
+	hub *pb.ULID,
+	token string,
+	req *pb.ConnectRequest,
+) (*connect.Conn, func() error, error) {
+	for {
+		ps, fresh, err := p.acquire(ctx, hub, token)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		conn, err := ps.session.ConnectWithRequest(req)
+		if err == nil {
+			return conn, func() error {
+				err := conn.Close()
+				p.release(ps)
+				return err
+			}, nil
+		}
+
+		p.release(ps)
+
+		// The session worked but the hub refused the connection, so the
+		// error is for the caller.
+		if !ps.session.IsClosed() {
+			// A draining hub refuses new streams but lets the ones it has
+			// finish, so stop using the session without cutting them off.
+			var remoteErr *wire.RemoteError
+
+			if errors.As(err, &remoteErr) && remoteErr.Message == ErrDraining.Error() {
+				p.retire(ps)
+			}
+
+			return nil, nil, err
+		}
+
+		p.L.Debug("pooled session to hub failed", "hub", hub, "error", err)
+
+		metrics.IncrCounter([]string{"hub", "pool", "broken"}, 1)
+
+		p.remove(ps)
+
+		// A session that was just made isn't retried so that a hub that drops
+		// every session doesn't keep us dialing.
+		if fresh {
+			return nil, nil, err
+		}
+	}
+}
+
+// Returns a session to the hub, opening one if needed. The boolean is true if
+// the session was just opened.
+func (p *sessionPool) acquire(ctx context.Context, hub *pb.ULID, token string) (*pooledSession, bool, error) {
+	key := poolKey{hub: hub.SpecString(), token: token}
+
+	p.mu.Lock()
+
+	var best *pooledSession
+
+	for _, ps := range p.sessions[key] {
+		if ps.session.IsClosed() {
+			continue
+		}
+
+		if best == nil || ps.streams < best.streams {
+			best = ps
+		}
+	}
+
+	open := len(p.sessions[key]) + p.dialing[key]
+
+	if best != nil && (best.streams < p.maxStreams || open >= p.maxPerHub) {
+		best.streams++
+		best.lastUsed = time.Now()
+		p.mu.Unlock()
+
+		metrics.IncrCounter([]string{"hub", "pool", "hits"}, 1)
+		return best, false, nil
+	}
+
+	if p.total >= p.maxSessions {
+		p.evictIdleLocked()
+	}
+
+	unpooled := p.total >= p.maxSessions
+
+	if !unpooled {
+		p.dialing[key]++
+		p.total++
+	}
+
+	p.mu.Unlock()
+
+	metrics.IncrCounter([]string{"hub", "pool", "misses"}, 1)
+
+	start := time.Now()
+
+	sess, err := p.dial(ctx, hub, token)
+
+	metrics.MeasureSince([]string{"hub", "pool", "dial_latency"}, start)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !unpooled {
+		p.dialing[key]--
+		if p.dialing[key] == 0 {
+			delete(p.dialing, key)
+		}
+	}
+
+	if err != nil {
+		if !unpooled {
+			p.total--
+		}
+
+		metrics.IncrCounter([]string{"hub", "pool", "dial_errors"}, 1)
+		return nil, false, err
+	}
+
+	ps := &pooledSession{
+		key:      key,
+		session:  sess,
+		streams:  1,
+		lastUsed: time.Now(),
+		unpooled: unpooled,
+	}
+
+	if unpooled {
+		p.detached[ps] = struct{}{}
+	} else {
+		p.sessions[key] = append(p.sessions[key], ps)
+	}
+
+	p.emitSize()
+
+	return ps, true, nil
+}
+
+func (p *sessionPool) release(ps *pooledSession) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	ps.streams--
+	ps.lastUsed = time.Now()
+
+	if ps.unpooled && ps.streams == 0 {
+		delete(p.detached, ps)
+		go ps.session.Close()
+	}
+}
+
+// Drops a session from the pool and closes it.
+func (p *sessionPool) remove(ps *pooledSession) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.removeLocked(ps)
+}
+
+func (p *sessionPool) removeLocked(ps *pooledSession) {
+	if ps.unpooled || ps.removed {
+		return
+	}
+
+	ps.removed = true
+
+	p.unlistLocked(ps)
+
+	go ps.session.Close()
+
+	p.emitSize()
+}
+
+// Takes a session out of the pool's list. Must be called with p.mu held.
+func (p *sessionPool) unlistLocked(ps *pooledSession) {
+	list := p.sessions[ps.key]
+
+	for i, o := range list {
+		if o == ps {
+			list = append(list[:i], list[i+1:]...)
+			p.total--
+			break
+		}
+	}
+
+	if len(list) == 0 {
+		delete(p.sessions, ps.key)
+	} else {
+		p.sessions[ps.key] = list
+	}
+}
+
+// Drops a session from the pool, leaving it open until its streams finish.
+func (p *sessionPool) retire(ps *pooledSession) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if ps.unpooled || ps.removed {
+		return
+	}
+
+	p.L.Debug("retiring session to draining hub", "hub", ps.key.hub)
+
+	p.unlistLocked(ps)
+
+	// From here on it's treated like a session opened while the pool was
+	// full and closed by release once it has no streams.
+	ps.unpooled = true
+
+	if ps.streams == 0 {
+		go ps.session.Close()
+	} else {
+		p.detached[ps] = struct{}{}
+	}
+
+	p.emitSize()
+}
+
+// Closes the session that has been idle the longest to make room for a new
+// one. Must be called with p.mu held.
+func (p *sessionPool) evictIdleLocked() {
+	var oldest *pooledSession
+
+	for _, list := range p.sessions {
+		for _, ps := range list {
+			if ps.streams == 0 && (oldest == nil || ps.lastUsed.Before(oldest.lastUsed)) {
+				oldest = ps
+			}
+		}
+	}
+
+	if oldest != nil {
+		metrics.IncrCounter([]string{"hub", "pool", "evictions"}, 1)
+		p.removeLocked(oldest)
+	}
+}
+
+// Must be called with p.mu held.
+func (p *sessionPool) emitSize() {
+	metrics.SetGauge([]string{"hub", "pool", "sessions"}, float32(p.total))
+}
+
+// Periodically pings the pooled sessions, dropping the ones that are broken
+// or have been idle for too long. When ctx is done, all sessions are closed.
+func (p *sessionPool) run(ctx context.Context) {
+	ticker := time.NewTicker(DefaultPoolCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			p.closeAll()
+			return
+		case <-ticker.C:
+			p.check()
+		}
+	}
+}
+
+func (p *sessionPool) check() {
+	var (
+		dead []*pooledSession
+		live []*pooledSession
+	)
+
+	p.mu.Lock()
+
+	for _, list := range p.sessions {
+		for _, ps := range list {
+			switch {
+			case ps.session.IsClosed():
+				p.L.Debug("dropping closed session to hub", "hub", ps.key.hub)
+				metrics.IncrCounter([]string{"hub", "pool", "broken"}, 1)
+				dead = append(dead, ps)
+			case ps.streams == 0 && time.Since(ps.lastUsed) > p.idleTimeout:
+				p.L.Trace("closing idle session to hub", "hub", ps.key.hub)
+				dead = append(dead, ps)
+			default:
+				live = append(live, ps)
+			}
+		}
+	}
+
+	for _, ps := range dead {
+		p.removeLocked(ps)
+	}
+
+	p.mu.Unlock()
+
+	for _, ps := range live {
+		rtt, err := ps.session.Ping()
+		if err != nil {
+			p.L.Warn("pooled session to hub failed keepalive", "hub", ps.key.hub, "error", err)
+			metrics.IncrCounter([]string{"hub", "pool", "broken"}, 1)
+			p.remove(ps)
+			continue
+		}
+
+		metrics.AddSample([]string{"hub", "pool", "rtt"}, float32(rtt.Seconds()*1000))
+	}
+}
+
+func (p *sessionPool) closeAll() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var all []*pooledSession
+
+	for _, list := range p.sessions {
+		all = append(all, list...)
+	}
+
+	for _, ps := range all {
+		p.removeLocked(ps)
+	}
+
+	// Their streams are cut off too, the hub is going away.
+	for ps := range p.detached {
+		delete(p.detached, ps)
+		go ps.session.Close()
+	}
+}
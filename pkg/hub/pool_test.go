@@ -0,0 +1,252 @@
+package hub
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/horizon/pkg/agent"
+	"github.com/hashicorp/horizon/pkg/control"
+	"github.com/hashicorp/horizon/pkg/discovery"
+	"github.com/hashicorp/horizon/pkg/pb"
+	"github.com/hashicorp/horizon/pkg/testutils/central"
+	"github.com/hashicorp/horizon/pkg/wire"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Runs an echo service behind one hub and calls f with a second hub that
+// relays to it.
+func withRemoteEcho(t testing.TB, f func(setup *central.DevSetup, hub *Hub, route *pb.ServiceRoute)) {
+	central.Dev(t, func(setup *central.DevSetup) {
+		L := hclog.New(&hclog.LoggerOptions{
+			Name:  "pool",
+			Level: hclog.Info,
+		})
+
+		hub1, err := NewHub(L.Named("hub1"), setup.ControlClient, setup.HubServToken)
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		setup.ControlClient.SetLocations([]*pb.NetworkLocation{
+			{
+				Addresses: []string{
+					fmt.Sprintf("127.0.0.1:%d", setup.ClientListener.Addr().(*net.TCPAddr).Port),
+				},
+				Labels: pb.ParseLabelSet("dc=test"),
+			},
+		})
+
+		setup.ControlClient.BootstrapConfig(ctx)
+
+		go setup.ControlClient.Run(ctx)
+
+		time.Sleep(time.Second)
+
+		go hub1.Run(ctx, setup.ClientListener)
+
+		time.Sleep(time.Second)
+
+		g, err := agent.NewAgent(L.Named("agent"))
+		require.NoError(t, err)
+
+		g.Token = setup.AgentToken
+
+		serviceId, err := g.AddService(&agent.Service{
+			Type:    "test",
+			Labels:  pb.ParseLabelSet("env=test,service=echo"),
+			Handler: agent.EchoHandler(),
+		})
+		require.NoError(t, err)
+
+		err = g.Start(ctx, discovery.HubConfigs(discovery.HubConfig{
+			Addr:     setup.HubAddr,
+			Insecure: true,
+		}))
+		require.NoError(t, err)
+
+		go g.Wait(ctx)
+
+		time.Sleep(time.Second)
+
+		setup.NewControlClient(t, func(nc *control.Client, li net.Listener) {
+			hub2, err := NewHub(L.Named("hub2"), nc, setup.HubServToken)
+			require.NoError(t, err)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			go nc.Run(ctx)
+
+			time.Sleep(time.Second)
+
+			go hub2.Run(ctx, li)
+
+			f(setup, hub2, &pb.ServiceRoute{
+				Hub:    hub1.id,
+				Id:     serviceId,
+				Type:   "test",
+				Labels: pb.ParseLabelSet("service=echo"),
+			})
+		})
+	})
+}
+
+func echoOnce(t testing.TB, wctx wire.Context) {
+	mb := wire.MarshalBytes("hello hzn from fed")
+
+	err := wctx.WriteMarshal(30, &mb)
+	require.NoError(t, err)
+
+	var mb2 wire.MarshalBytes
+
+	tag, err := wctx.ReadMarshal(&mb2)
+	require.NoError(t, err)
+
+	assert.Equal(t, byte(30), tag)
+	assert.Equal(t, mb, mb2)
+}
+
+func TestSessionPool(t *testing.T) {
+	t.Run("reuses sessions to other hubs", func(t *testing.T) {
+		withRemoteEcho(t, func(setup *central.DevSetup, hub *Hub, route *pb.ServiceRoute) {
+			ctx := context.Background()
+
+			var durations []time.Duration
+
+			for i := 0; i < 5; i++ {
+				start := time.Now()
+
+				wctx, err := hub.ConnectToService(ctx, route, setup.Account, "echo", setup.HubServToken)
+				require.NoError(t, err)
+
+				durations = append(durations, time.Since(start))
+
+				echoOnce(t, wctx)
+
+				wctx.Close()
+			}
+
+			t.Logf("connect latency: first %s, reused %v", durations[0], durations[1:])
+
+			hub.pool.mu.Lock()
+			defer hub.pool.mu.Unlock()
+
+			assert.Equal(t, 1, hub.pool.total)
+
+			for _, list := range hub.pool.sessions {
+				for _, ps := range list {
+					assert.Equal(t, 0, ps.streams)
+				}
+			}
+		})
+	})
+
+	t.Run("rebuilds sessions that fail", func(t *testing.T) {
+		withRemoteEcho(t, func(setup *central.DevSetup, hub *Hub, route *pb.ServiceRoute) {
+			ctx := context.Background()
+
+			wctx, err := hub.ConnectToService(ctx, route, setup.Account, "echo", setup.HubServToken)
+			require.NoError(t, err)
+
+			echoOnce(t, wctx)
+			wctx.Close()
+
+			hub.pool.mu.Lock()
+			var broken *pooledSession
+			for _, list := range hub.pool.sessions {
+				broken = list[0]
+			}
+			hub.pool.mu.Unlock()
+
+			require.NotNil(t, broken)
+
+			broken.session.Close()
+
+			wctx, err = hub.ConnectToService(ctx, route, setup.Account, "echo", setup.HubServToken)
+			require.NoError(t, err)
+
+			echoOnce(t, wctx)
+			wctx.Close()
+
+			hub.pool.mu.Lock()
+			defer hub.pool.mu.Unlock()
+
+			assert.Equal(t, 1, hub.pool.total)
+
+			for _, list := range hub.pool.sessions {
+				assert.NotEqual(t, broken, list[0])
+			}
+		})
+	})
+
+	t.Run("closes idle sessions", func(t *testing.T) {
+		withRemoteEcho(t, func(setup *central.DevSetup, hub *Hub, route *pb.ServiceRoute) {
+			ctx := context.Background()
+
+			hub.pool.idleTimeout = 10 * time.Millisecond
+
+			wctx, err := hub.ConnectToService(ctx, route, setup.Account, "echo", setup.HubServToken)
+			require.NoError(t, err)
+
+			echoOnce(t, wctx)
+
+			time.Sleep(50 * time.Millisecond)
+
+			size := func() int {
+				hub.pool.mu.Lock()
+				defer hub.pool.mu.Unlock()
+
+				return hub.pool.total
+			}
+
+			// Still in use, so it's kept.
+			hub.pool.check()
+			assert.Equal(t, 1, size())
+
+			wctx.Close()
+
+			time.Sleep(50 * time.Millisecond)
+
+			hub.pool.check()
+			assert.Equal(t, 0, size())
+		})
+	})
+}
+
+func BenchmarkRemoteConnect(b *testing.B) {
+	withRemoteEcho(b, func(setup *central.DevSetup, hub *Hub, route *pb.ServiceRoute) {
+		ctx := context.Background()
+
+		b.Run("pooled", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				wctx, err := hub.ConnectToService(ctx, route, setup.Account, "echo", setup.HubServToken)
+				require.NoError(b, err)
+
+				echoOnce(b, wctx)
+
+				wctx.Close()
+			}
+		})
+
+		// What every relayed connection cost before sessions were pooled.
+		b.Run("unpooled", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				sess, err := hub.dialHub(ctx, route.Hub, setup.HubServToken)
+				require.NoError(b, err)
+
+				conn, err := sess.ConnecToAccountService(setup.Account, route.Labels)
+				require.NoError(b, err)
+
+				echoOnce(b, conn.WireContext(setup.Account))
+
+				sess.Close()
+			}
+		})
+	})
+}
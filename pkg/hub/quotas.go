@@ -0,0 +1,133 @@
+package hub
+
+import (
+	"github.com/armon/go-metrics"
+	"github.com/hashicorp/horizon/pkg/control"
+	"github.com/hashicorp/horizon/pkg/pb"
+	"github.com/pkg/errors"
+)
+
+var ErrOverQuota = errors.New("account over quota")
+
+// What an account is currently using on this hub.
+type accountUsage struct {
+	agents   int64
+	services int64
+	streams  int64
+}
+
+// Reserves an agent session advertising services for the account, failing if
+// that would put the account over its quotas.
+func (h *Hub) reserveAgent(account *pb.Account, quotas control.AccountQuotas, services int) error {
+	h.usageMu.Lock()
+	defer h.usageMu.Unlock()
+
+	u := h.usageLocked(account)
+
+	if quotas.Agents > 0 && u.agents+1 > quotas.Agents {
+		return h.overQuota(account, "agents", quotas.Agents)
+	}
+
+	if quotas.Services > 0 && u.services+int64(services) > quotas.Services {
+		return h.overQuota(account, "services", quotas.Services)
+	}
+
+	u.agents++
+	u.services += int64(services)
+
+	return nil
+}
+
+// Releases an agent session and the services it still advertised.
+func (h *Hub) releaseAgent(account *pb.Account, services int) {
+	h.usageMu.Lock()
+	defer h.usageMu.Unlock()
+
+	u := h.usageLocked(account)
+	u.agents--
+	u.services -= int64(services)
+
+	h.pruneUsageLocked(account, u)
+}
+
+// Reserves a service advertised on an existing agent session.
+func (h *Hub) reserveService(account *pb.Account, quotas control.AccountQuotas) error {
+	h.usageMu.Lock()
+	defer h.usageMu.Unlock()
+
+	u := h.usageLocked(account)
+
+	if quotas.Services > 0 && u.services+1 > quotas.Services {
+		return h.overQuota(account, "services", quotas.Services)
+	}
+
+	u.services++
+
+	return nil
+}
+
+func (h *Hub) releaseService(account *pb.Account) {
+	h.usageMu.Lock()
+	defer h.usageMu.Unlock()
+
+	u := h.usageLocked(account)
+	u.services--
+
+	h.pruneUsageLocked(account, u)
+}
+
+func (h *Hub) reserveStream(account *pb.Account, quotas control.AccountQuotas) error {
+	h.usageMu.Lock()
+	defer h.usageMu.Unlock()
+
+	u := h.usageLocked(account)
+
+	if quotas.Streams > 0 && u.streams+1 > quotas.Streams {
+		return h.overQuota(account, "streams", quotas.Streams)
+	}
+
+	u.streams++
+
+	return nil
+}
+
+func (h *Hub) releaseStream(account *pb.Account) {
+	h.usageMu.Lock()
+	defer h.usageMu.Unlock()
+
+	u := h.usageLocked(account)
+	u.streams--
+
+	h.pruneUsageLocked(account, u)
+}
+
+// Must be called with h.usageMu held.
+func (h *Hub) usageLocked(account *pb.Account) *accountUsage {
+	key := account.StringKey()
+
+	u, ok := h.usage[key]
+	if !ok {
+		u = &accountUsage{}
+		h.usage[key] = u
+	}
+
+	return u
+}
+
+// Forgets accounts that no longer use anything. Must be called with
+// h.usageMu held.
+func (h *Hub) pruneUsageLocked(account *pb.Account, u *accountUsage) {
+	if u.agents <= 0 && u.services <= 0 && u.streams <= 0 {
+		delete(h.usage, account.StringKey())
+	}
+}
+
+func (h *Hub) overQuota(account *pb.Account, kind string, quota int64) error {
+	h.L.Warn("account over quota", "account", account, "quota", kind, "limit", quota)
+
+	metrics.IncrCounterWithLabels([]string{"hub", "quota", "rejected"}, 1, []metrics.Label{
+		{Name: "quota", Value: kind},
+	})
+
+	return errors.Wrapf(ErrOverQuota, "%s quota of %d reached", kind, quota)
+}
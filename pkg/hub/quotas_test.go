@@ -0,0 +1,74 @@
+package hub
+
+import (
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/horizon/pkg/control"
+	"github.com/hashicorp/horizon/pkg/pb"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuotas(t *testing.T) {
+	newHub := func() *Hub {
+		return &Hub{
+			L:     hclog.NewNullLogger(),
+			usage: make(map[string]*accountUsage),
+		}
+	}
+
+	account := &pb.Account{
+		Namespace: "/",
+		AccountId: pb.NewULID(),
+	}
+
+	t.Run("limits concurrent streams", func(t *testing.T) {
+		h := newHub()
+
+		quotas := control.AccountQuotas{Streams: 2}
+
+		require.NoError(t, h.reserveStream(account, quotas))
+		require.NoError(t, h.reserveStream(account, quotas))
+
+		err := h.reserveStream(account, quotas)
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, ErrOverQuota))
+
+		h.releaseStream(account)
+
+		require.NoError(t, h.reserveStream(account, quotas))
+	})
+
+	t.Run("counts services across an account's agents", func(t *testing.T) {
+		h := newHub()
+
+		quotas := control.AccountQuotas{Services: 3}
+
+		require.NoError(t, h.reserveAgent(account, quotas, 2))
+
+		err := h.reserveAgent(account, quotas, 2)
+		assert.True(t, errors.Is(err, ErrOverQuota))
+
+		require.NoError(t, h.reserveService(account, quotas))
+
+		err = h.reserveService(account, quotas)
+		assert.True(t, errors.Is(err, ErrOverQuota))
+
+		h.releaseAgent(account, 3)
+
+		assert.Equal(t, 0, len(h.usage))
+	})
+
+	t.Run("has no limits without quotas", func(t *testing.T) {
+		h := newHub()
+
+		var quotas control.AccountQuotas
+
+		for i := 0; i < 100; i++ {
+			require.NoError(t, h.reserveAgent(account, quotas, 10))
+			require.NoError(t, h.reserveStream(account, quotas))
+		}
+	})
+}
@@ -0,0 +1,131 @@
+package hub
+
+import (
+	"context"
+	"time"
+
+	"github.com/hashicorp/horizon/pkg/pb"
+	"github.com/hashicorp/horizon/pkg/wire"
+	"github.com/pkg/errors"
+)
+
+var ErrNoSuchService = errors.New("no such service")
+
+type agentConnKey struct{}
+
+func (h *Hub) registerRPC() {
+	h.rpc.AddMethod(wire.RPCQueryPeers, wire.RPCHandlerFunc(h.queryPeers))
+	h.rpc.AddMethod(wire.RPCConnectPeer, wire.RPCHandlerFunc(h.connectPeer))
+}
+
+// Handles a stream an agent opened to call an RPC method.
+func (h *Hub) handleRPC(ctx context.Context, ai *agentConn, msg []byte, wctx wire.Context) {
+	var req pb.Request
+
+	err := req.Unmarshal(msg)
+	if err == nil {
+		ctx = context.WithValue(ctx, agentConnKey{}, ai)
+		err = h.rpc.HandleRequest(ctx, h.L, wctx, &req)
+	}
+
+	if err != nil {
+		h.L.Error("error handling rpc", "error", err, "agent", ai.ID, "method", req.Path)
+
+		var resp pb.Response
+		resp.Error = err.Error()
+		wctx.WriteMarshal(255, &resp)
+	}
+}
+
+func (h *Hub) queryPeers(ctx context.Context, wctx wire.RPCContext) error {
+	var req pb.ConnectRequest
+
+	err := wctx.ReadRequest(&req)
+	if err != nil {
+		return err
+	}
+
+	target := req.Target
+	if target == nil {
+		target = &pb.LabelSet{}
+	}
+
+	calc, err := h.cc.LookupService(ctx, wctx.Account(), target)
+	if err != nil {
+		return err
+	}
+
+	var resp pb.ListServicesResponse
+
+	for _, route := range calc.All {
+		if req.Type != "" && route.Type != req.Type {
+			continue
+		}
+
+		resp.Services = append(resp.Services, &pb.Service{
+			Id:       route.Id,
+			Hub:      route.Hub,
+			Type:     route.Type,
+			Labels:   route.Labels,
+			Metadata: h.cc.ServiceMetadata(route.Id),
+		})
+	}
+
+	return wctx.WriteResponse(&resp)
+}
+
+func (h *Hub) connectPeer(ctx context.Context, wctx wire.RPCContext) error {
+	ai, ok := ctx.Value(agentConnKey{}).(*agentConn)
+	if !ok {
+		return errors.Wrapf(ErrProtocolError, "peer connections must come from an agent")
+	}
+
+	var req pb.SessionIdentification
+
+	err := wctx.ReadRequest(&req)
+	if err != nil {
+		return err
+	}
+
+	if req.ServiceId == nil {
+		return errors.Wrapf(ErrProtocolError, "service id missing")
+	}
+
+	calc, err := h.cc.LookupService(ctx, wctx.Account(), &pb.LabelSet{})
+	if err != nil {
+		return err
+	}
+
+	var target *pb.ServiceRoute
+
+	for _, route := range calc.All {
+		if route.Id.Equal(req.ServiceId) {
+			target = route
+			break
+		}
+	}
+
+	if target == nil {
+		return errors.Wrapf(ErrNoSuchService, "service %s", req.ServiceId.SpecString())
+	}
+
+	var fs pb.FlowStream
+	fs.FlowId = pb.NewULID()
+	fs.HubId = h.id
+	fs.AgentId = ai.ID
+	fs.ServiceId = target.Id
+	fs.Account = wctx.Account()
+	fs.Labels = target.Labels
+	fs.StartedAt = pb.NewTimestamp(time.Now())
+
+	// The service id goes along when the connection is relayed so that
+	// another hub connects to this exact service rather than one that
+	// happens to share it's labels.
+	connReq := &pb.ConnectRequest{
+		Target:     target.Labels,
+		ProtocolId: req.ProtocolId,
+		ServiceId:  target.Id,
+	}
+
+	return h.bridgeToTarget(ctx, ai, &fs, target, connReq, wctx)
+}
@@ -0,0 +1,197 @@
+package hub
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/hashicorp/horizon/pkg/pb"
+	"github.com/hashicorp/horizon/pkg/wire"
+	"github.com/pkg/errors"
+)
+
+// Handles a request from an agent to add or remove a service on it's
+// existing session.
+func (h *Hub) handleServiceUpdate(ctx context.Context, ai *agentConn, tag byte, msg []byte, wctx wire.Context) {
+	var serv pb.ServiceInfo
+
+	err := serv.Unmarshal(msg)
+	if err == nil {
+		switch tag {
+		case wire.TagServiceAdd:
+			err = h.addAgentService(ctx, ai, &serv)
+		case wire.TagServiceRemove:
+			err = h.removeAgentService(ctx, ai, &serv)
+		}
+	}
+
+	if err != nil {
+		h.L.Error("error updating agent service", "error", err, "agent", ai.ID, "service", serv.ServiceId)
+
+		var resp pb.Response
+		resp.Error = err.Error()
+		wctx.WriteMarshal(255, &resp)
+		return
+	}
+
+	var resp pb.Response
+	resp.Code = 200
+	wctx.WriteMarshal(1, &resp)
+}
+
+func (h *Hub) addAgentService(ctx context.Context, ai *agentConn, serv *pb.ServiceInfo) error {
+	if serv.ServiceId == nil {
+		return errors.Wrapf(ErrProtocolError, "service id missing")
+	}
+
+	ok, _ := ai.token.HasCapability(pb.SERVE)
+	if !ok {
+		return errors.Wrapf(ErrProtocolError, "token not authorized to serve")
+	}
+
+	ai.updates.Lock()
+	defer ai.updates.Unlock()
+
+	ai.mu.Lock()
+	register := ai.connectOnly
+	dup := ai.advertises(serv.ServiceId)
+	ai.mu.Unlock()
+
+	// An agent can send a service again when it reconciles it's services
+	// after connecting, which leaves nothing to do.
+	if dup {
+		h.L.Debug("service already advertised by agent", "agent", ai.ID, "service", serv.ServiceId)
+		return nil
+	}
+
+	// A connect-only session starts counting against the agents quota when
+	// it advertises its first service.
+	quotas := h.cc.AccountQuotas(ai.Account)
+
+	var err error
+
+	if register {
+		err = h.reserveAgent(ai.Account, quotas, 1)
+	} else {
+		err = h.reserveService(ai.Account, quotas)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	err = h.cc.AddService(ctx, &pb.ServiceRequest{
+		Account:  ai.Account,
+		Hub:      h.id,
+		Id:       serv.ServiceId,
+		Type:     serv.Type,
+		Labels:   serv.Labels,
+		Metadata: serv.Metadata,
+	})
+
+	if err != nil {
+		if register {
+			h.releaseAgent(ai.Account, 1)
+		} else {
+			h.releaseService(ai.Account)
+		}
+
+		return errors.Wrapf(err, "error adding service")
+	}
+
+	h.L.Debug("adding service",
+		"hub", h.id,
+		"service", serv.ServiceId,
+		"labels", serv.Labels.SpecString(),
+		"account", ai.Account,
+	)
+
+	ai.mu.Lock()
+	ai.preamble.Services = append(ai.preamble.Services, serv)
+	atomic.StoreInt32(&ai.Services, int32(len(ai.preamble.Services)))
+	ai.connectOnly = false
+	ai.mu.Unlock()
+
+	// An agent that connected without any services hasn't been registered
+	// yet, so do that now which will also make the new service active.
+	if register {
+		err = h.registerAgent(ai)
+		if err != nil {
+			// Put the session back to connect-only, which had no services.
+			ai.mu.Lock()
+			ai.preamble.Services = nil
+			atomic.StoreInt32(&ai.Services, 0)
+			ai.connectOnly = true
+			ai.mu.Unlock()
+
+			h.releaseAgent(ai.Account, 1)
+
+			rerr := h.cc.RemoveService(ctx, &pb.ServiceRequest{
+				Account:  ai.Account,
+				Hub:      h.id,
+				Id:       serv.ServiceId,
+				Type:     serv.Type,
+				Labels:   serv.Labels,
+				Metadata: serv.Metadata,
+			})
+			if rerr != nil {
+				h.L.Error("error removing service after failed registration", "error", rerr, "service", serv.ServiceId)
+			}
+
+			return errors.Wrapf(err, "error registering agent")
+		}
+
+		return nil
+	}
+
+	h.mu.Lock()
+	h.active[serv.ServiceId.SpecString()] = &agentConnection{
+		useLZ4:  ai.useLZ4,
+		session: ai.sess,
+	}
+	h.mu.Unlock()
+
+	return nil
+}
+
+func (h *Hub) removeAgentService(ctx context.Context, ai *agentConn, serv *pb.ServiceInfo) error {
+	ai.updates.Lock()
+	defer ai.updates.Unlock()
+
+	var found *pb.ServiceInfo
+
+	ai.mu.Lock()
+	for i, s := range ai.preamble.Services {
+		if s.ServiceId.Equal(serv.ServiceId) {
+			found = s
+			ai.preamble.Services = append(ai.preamble.Services[:i:i], ai.preamble.Services[i+1:]...)
+			break
+		}
+	}
+	atomic.StoreInt32(&ai.Services, int32(len(ai.preamble.Services)))
+	ai.mu.Unlock()
+
+	if found == nil {
+		return errors.Wrapf(ErrWrongService, "service not advertised by agent: %s", serv.ServiceId)
+	}
+
+	h.releaseService(ai.Account)
+
+	h.mu.Lock()
+	delete(h.active, found.ServiceId.SpecString())
+	h.mu.Unlock()
+
+	h.L.Debug("removing service",
+		"hub", h.id,
+		"service", found.ServiceId,
+		"account", ai.Account,
+	)
+
+	return h.cc.RemoveService(ctx, &pb.ServiceRequest{
+		Account:  ai.Account,
+		Hub:      h.id,
+		Id:       found.ServiceId,
+		Type:     found.Type,
+		Labels:   found.Labels,
+		Metadata: found.Metadata,
+	})
+}
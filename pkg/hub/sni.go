@@ -0,0 +1,221 @@
+package hub
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/armon/go-metrics"
+	"github.com/hashicorp/horizon/pkg/control"
+	"github.com/hashicorp/horizon/pkg/pb"
+	"github.com/pkg/errors"
+)
+
+// How long a client has to send its ClientHello.
+var helloTimeout = 10 * time.Second
+
+var errHelloRead = errors.New("client hello read")
+
+// Wraps the ingress listener so that TLS connections for a server name with
+// a :sni label link are bridged, still encrypted, to the linked services.
+// All other connections, including agents and hostnames the hub serves
+// itself, are returned by Accept to be handled by the HTTPS frontend.
+type sniListener struct {
+	net.Listener
+
+	h     *Hub
+	ctx   context.Context
+	conns chan net.Conn
+
+	mu   sync.Mutex
+	err  error
+	done chan struct{}
+}
+
+func (h *Hub) passthroughListener(ctx context.Context, li net.Listener) net.Listener {
+	sl := &sniListener{
+		Listener: li,
+		h:        h,
+		ctx:      ctx,
+		conns:    make(chan net.Conn),
+		done:     make(chan struct{}),
+	}
+
+	go sl.run()
+
+	return sl
+}
+
+func (sl *sniListener) run() {
+	for {
+		conn, err := sl.Listener.Accept()
+		if err != nil {
+			if ne, ok := err.(net.Error); ok && ne.Temporary() {
+				time.Sleep(10 * time.Millisecond)
+				continue
+			}
+
+			sl.mu.Lock()
+			sl.err = err
+			sl.mu.Unlock()
+
+			close(sl.done)
+			return
+		}
+
+		go sl.route(conn)
+	}
+}
+
+func (sl *sniListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-sl.conns:
+		return conn, nil
+	case <-sl.done:
+		sl.mu.Lock()
+		defer sl.mu.Unlock()
+
+		return nil, sl.err
+	}
+}
+
+// Reads the ClientHello from conn and either bridges it to a passthrough
+// service or hands it to Accept with the ClientHello replayed.
+func (sl *sniListener) route(conn net.Conn) {
+	conn.SetReadDeadline(time.Now().Add(helloTimeout))
+
+	name, hello := peekServerName(conn)
+
+	conn.SetReadDeadline(time.Time{})
+
+	pc := &prefixConn{
+		Conn: conn,
+		r:    io.MultiReader(bytes.NewReader(hello), conn),
+	}
+
+	if name != "" && !sl.ownsName(name) {
+		account, target, limits, err := sl.h.cc.ResolveLabelLink(sniLabels(name))
+		if err == nil && target != nil && sl.sameOwner(name, account) {
+			sl.passthrough(pc, name, account, target, limits)
+			return
+		}
+	}
+
+	select {
+	case sl.conns <- pc:
+	case <-sl.done:
+		conn.Close()
+	}
+}
+
+// Reports if name belongs to the hub itself, and so is never passed through.
+func (sl *sniListener) ownsName(name string) bool {
+	domain := sl.h.cc.HubDomain()
+
+	return domain != "" && (name == domain || strings.HasSuffix(name, "."+domain))
+}
+
+// Reports if account, which linked name with :sni, is also the account that
+// linked it with :hostname, if any did. Control rejects links that would
+// split a name between accounts, so this only guards against older links.
+func (sl *sniListener) sameOwner(name string, account *pb.Account) bool {
+	owner, target, _, err := sl.h.cc.ResolveLabelLink(&pb.LabelSet{
+		Labels: []*pb.Label{
+			{
+				Name:  control.HostnameLabel,
+				Value: strings.ToLower(name),
+			},
+		},
+	})
+
+	if err != nil || target == nil {
+		return true
+	}
+
+	if owner.StringKey() != account.StringKey() {
+		sl.h.L.Warn("not passing through a server name linked by another account's hostname",
+			"server-name", name, "sni-account", account, "hostname-account", owner)
+		return false
+	}
+
+	return true
+}
+
+func (sl *sniListener) passthrough(
+	conn net.Conn,
+	name string,
+	account *pb.Account,
+	target *pb.LabelSet,
+	limits *pb.Account_Limits,
+) {
+	defer conn.Close()
+
+	L := sl.h.L.With("server-name", name, "remote", conn.RemoteAddr().String())
+
+	metrics.IncrCounter([]string{"hub", "sni", "connections"}, 1)
+
+	sl.h.bridgeTCP(sl.ctx, L, conn, account, target, limits)
+}
+
+func sniLabels(name string) *pb.LabelSet {
+	return &pb.LabelSet{
+		Labels: []*pb.Label{
+			{
+				Name:  control.SNILabel,
+				Value: strings.ToLower(name),
+			},
+		},
+	}
+}
+
+// Returns the server name requested by the ClientHello read from conn along
+// with the bytes read. The name is empty if the client didn't send one or
+// didn't speak TLS.
+func peekServerName(conn net.Conn) (string, []byte) {
+	var (
+		buf  bytes.Buffer
+		name string
+	)
+
+	tls.Server(&readOnlyConn{Conn: conn, r: io.TeeReader(conn, &buf)}, &tls.Config{
+		GetConfigForClient: func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+			name = hello.ServerName
+			return nil, errHelloRead
+		},
+	}).Handshake()
+
+	return name, buf.Bytes()
+}
+
+// Lets crypto/tls parse a ClientHello without answering it.
+type readOnlyConn struct {
+	net.Conn
+	r io.Reader
+}
+
+func (c *readOnlyConn) Read(b []byte) (int, error) {
+	return c.r.Read(b)
+}
+
+func (c *readOnlyConn) Write(b []byte) (int, error) {
+	return 0, io.ErrClosedPipe
+}
+
+func (c *readOnlyConn) Close() error {
+	return nil
+}
+
+// A connection that first returns data already read from it.
+type prefixConn struct {
+	net.Conn
+	r io.Reader
+}
+
+func (c *prefixConn) Read(b []byte) (int, error) {
+	return c.r.Read(b)
+}
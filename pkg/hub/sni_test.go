@@ -0,0 +1,165 @@
+package hub
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/horizon/pkg/agent"
+	"github.com/hashicorp/horizon/pkg/control"
+	"github.com/hashicorp/horizon/pkg/discovery"
+	"github.com/hashicorp/horizon/pkg/pb"
+	"github.com/hashicorp/horizon/pkg/testutils"
+	"github.com/hashicorp/horizon/pkg/testutils/central"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSNIPassthrough(t *testing.T) {
+	central.Dev(t, func(setup *central.DevSetup) {
+		L := hclog.L()
+
+		hub, err := NewHub(L, setup.ControlClient, setup.HubServToken)
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		go hub.Run(ctx, setup.ClientListener)
+
+		time.Sleep(time.Second)
+
+		certPEM, keyPEM, err := testutils.SelfSignedCert()
+		require.NoError(t, err)
+
+		cert, err := tls.X509KeyPair(certPEM, keyPEM)
+		require.NoError(t, err)
+
+		leaf, err := x509.ParseCertificate(cert.Certificate[0])
+		require.NoError(t, err)
+
+		upstream, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+			Certificates: []tls.Certificate{cert},
+		})
+		require.NoError(t, err)
+
+		defer upstream.Close()
+
+		go func() {
+			for {
+				c, err := upstream.Accept()
+				if err != nil {
+					return
+				}
+
+				go func() {
+					defer c.Close()
+					io.Copy(c, c)
+				}()
+			}
+		}()
+
+		g, err := agent.NewAgent(L.Named("agent"))
+		require.NoError(t, err)
+
+		g.Token = setup.AgentToken
+
+		_, err = g.AddService(&agent.Service{
+			Type:    "tcp",
+			Labels:  pb.ParseLabelSet("service=tls-echo"),
+			Handler: agent.TCPHandler(upstream.Addr().String()),
+		})
+		require.NoError(t, err)
+
+		err = g.Start(ctx, discovery.HubConfigs(discovery.HubConfig{
+			Addr:     setup.HubAddr,
+			Insecure: true,
+		}))
+		require.NoError(t, err)
+
+		go g.Wait(ctx)
+
+		time.Sleep(time.Second)
+
+		_, err = setup.ControlServer.AddLabelLink(setup.MgmtCtx,
+			&pb.AddLabelLinkRequest{
+				Labels:  pb.ParseLabelSet(control.SNILabel + "=passthrough.test"),
+				Account: setup.Account,
+				Target:  pb.ParseLabelSet("service=tls-echo"),
+			})
+		require.NoError(t, err)
+
+		time.Sleep(time.Second)
+
+		require.NoError(t, setup.ControlClient.ForceLabelLinkUpdate(ctx, L))
+
+		t.Run("passes linked server names through to the service", func(t *testing.T) {
+			conn, err := tls.Dial("tcp", setup.HubAddr, &tls.Config{
+				ServerName:         "passthrough.test",
+				InsecureSkipVerify: true,
+			})
+			require.NoError(t, err)
+
+			defer conn.Close()
+
+			// The handshake was with the upstream, not the hub.
+			peer := conn.ConnectionState().PeerCertificates
+			require.True(t, len(peer) > 0)
+			assert.True(t, bytes.Equal(leaf.Raw, peer[0].Raw))
+
+			_, err = conn.Write([]byte("hello hzn over tls"))
+			require.NoError(t, err)
+
+			buf := make([]byte, len("hello hzn over tls"))
+
+			_, err = io.ReadFull(conn, buf)
+			require.NoError(t, err)
+
+			assert.Equal(t, "hello hzn over tls", string(buf))
+		})
+
+		t.Run("leaves other server names to the hub", func(t *testing.T) {
+			tr := &http.Transport{
+				TLSClientConfig: &tls.Config{
+					ServerName:         "other.test",
+					InsecureSkipVerify: true,
+				},
+			}
+
+			defer tr.CloseIdleConnections()
+
+			hc := &http.Client{Transport: tr}
+
+			resp, err := hc.Get("https://" + setup.HubAddr + "/__hzn/healthz")
+			require.NoError(t, err)
+
+			defer resp.Body.Close()
+
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+			peer := resp.TLS.PeerCertificates
+			require.True(t, len(peer) > 0)
+			assert.False(t, bytes.Equal(leaf.Raw, peer[0].Raw))
+		})
+
+		t.Run("leaves connections without a server name to the hub", func(t *testing.T) {
+			// Dialing an IP address sends no server name.
+			conn, err := tls.Dial("tcp", setup.HubAddr, &tls.Config{
+				InsecureSkipVerify: true,
+			})
+			require.NoError(t, err)
+
+			defer conn.Close()
+
+			peer := conn.ConnectionState().PeerCertificates
+			require.True(t, len(peer) > 0)
+			assert.False(t, bytes.Equal(leaf.Raw, peer[0].Raw))
+		})
+	})
+}
@@ -15,7 +15,7 @@ func (h *Hub) sendAgentInfoFlow(ai *agentConn) {
 		Account:       ai.Account,
 		StartedAt:     ai.Start,
 		EndedAt:       ai.End,
-		NumServices:   ai.Services,
+		NumServices:   atomic.LoadInt32(&ai.Services),
 		ActiveStreams: atomic.LoadInt64(ai.ActiveStreams),
 	}
 
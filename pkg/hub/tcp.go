@@ -0,0 +1,287 @@
+package hub
+
+import (
+	"context"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/armon/go-metrics"
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/horizon/pkg/control"
+	"github.com/hashicorp/horizon/pkg/pb"
+	"github.com/hashicorp/horizon/pkg/wire"
+	"github.com/pkg/errors"
+	"golang.org/x/time/rate"
+)
+
+// How often the TCP ports linked in control are checked for changes.
+var tcpPortRefresh = 10 * time.Second
+
+// Accepts raw TCP connections on the ports control has allocated to accounts
+// and bridges them to the account's tcp services.
+type tcpIngress struct {
+	h           *Hub
+	host        string
+	first, last int
+
+	mu        sync.Mutex
+	listeners map[int]net.Listener
+}
+
+// RunTCPIngress listens on host for every TCP port linked to an account with
+// a :tcp-port label link, following the links as they're added and removed,
+// until ctx is done. Only ports from first through last are listened on, which
+// should match the range control allocates ports from.
+func (h *Hub) RunTCPIngress(ctx context.Context, host string, first, last int) error {
+	ti := &tcpIngress{
+		h:         h,
+		host:      host,
+		first:     first,
+		last:      last,
+		listeners: make(map[int]net.Listener),
+	}
+
+	defer ti.closeAll()
+
+	ticker := time.NewTicker(tcpPortRefresh)
+	defer ticker.Stop()
+
+	for {
+		ti.refresh(ctx)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Opens listeners for newly linked ports and closes the ones whose links are
+// gone. Connections already made on a closed port are left running.
+func (ti *tcpIngress) refresh(ctx context.Context) {
+	L := ti.h.L
+
+	want := make(map[int]bool)
+
+	for _, ll := range ti.h.cc.LabelLinksWithLabel(control.TCPPortLabel) {
+		val, _ := ll.Labels.GetLabel(control.TCPPortLabel)
+
+		port, err := strconv.Atoi(val)
+		if err != nil || port < ti.first || port > ti.last {
+			L.Warn("ignoring tcp port label link outside of the port range",
+				"labels", ll.Labels.SpecString(), "first", ti.first, "last", ti.last)
+			continue
+		}
+
+		want[port] = true
+	}
+
+	ti.mu.Lock()
+	defer ti.mu.Unlock()
+
+	for port, ln := range ti.listeners {
+		if !want[port] {
+			L.Info("closing tcp ingress port", "port", port)
+			ln.Close()
+			delete(ti.listeners, port)
+		}
+	}
+
+	for port := range want {
+		if _, ok := ti.listeners[port]; ok {
+			continue
+		}
+
+		ln, err := net.Listen("tcp", net.JoinHostPort(ti.host, strconv.Itoa(port)))
+		if err != nil {
+			L.Error("error listening on tcp ingress port", "port", port, "error", err)
+			continue
+		}
+
+		L.Info("listening on tcp ingress port", "port", port)
+
+		ti.listeners[port] = ln
+
+		go ti.accept(ctx, port, ln)
+	}
+
+	metrics.SetGauge([]string{"hub", "tcp", "ports"}, float32(len(ti.listeners)))
+}
+
+func (ti *tcpIngress) accept(ctx context.Context, port int, ln net.Listener) {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+
+		go ti.handle(ctx, port, conn)
+	}
+}
+
+func (ti *tcpIngress) closeAll() {
+	ti.mu.Lock()
+	defer ti.mu.Unlock()
+
+	for port, ln := range ti.listeners {
+		ln.Close()
+		delete(ti.listeners, port)
+	}
+}
+
+func (ti *tcpIngress) handle(ctx context.Context, port int, conn net.Conn) {
+	defer conn.Close()
+
+	h := ti.h
+	L := h.L.With("port", port, "remote", conn.RemoteAddr().String())
+
+	metrics.IncrCounter([]string{"hub", "tcp", "connections"}, 1)
+
+	account, target, limits, err := h.cc.ResolveLabelLink(control.TCPPortLabels(port))
+	if err != nil || target == nil {
+		L.Error("unable to resolve tcp port label link", "error", err)
+		return
+	}
+
+	h.bridgeTCP(ctx, L, conn, account, target, limits)
+}
+
+// Bridges conn to a tcp service of account matching target until either
+// side closes, applying the account's bandwidth limit.
+func (h *Hub) bridgeTCP(
+	ctx context.Context,
+	L hclog.Logger,
+	conn net.Conn,
+	account *pb.Account,
+	target *pb.LabelSet,
+	limits *pb.Account_Limits,
+) {
+	// Refused before trying any routes so they aren't counted as failing.
+	if h.isDraining() {
+		L.Debug("refusing tcp connection while draining")
+		return
+	}
+
+	calc, err := h.cc.LookupService(ctx, account, target)
+	if err != nil {
+		L.Error("error resolving labels to services", "error", err, "labels", target)
+		return
+	}
+
+	var wctx wire.Context
+
+	for _, rs := range h.selector.SelectRoutes(ctx, calc) {
+		if rs.Type != "tcp" {
+			L.Warn("service was not type tcp", "service-id", rs.Id, "type", rs.Type)
+			continue
+		}
+
+		connStart := time.Now()
+
+		wctx, err = h.ConnectToService(ctx, rs, account, "tcp", h.feToken)
+		if err == nil {
+			defer h.selector.Connected(rs, time.Since(connStart))()
+			break
+		}
+
+		h.selector.ConnectFailed(rs, err)
+
+		L.Warn("error connecting to service", "error", err, "labels", target, "service", rs.Id, "hub", rs.Hub)
+	}
+
+	if wctx == nil {
+		L.Error("no viable tcp service found", "labels", target)
+		metrics.IncrCounter([]string{"hub", "tcp", "unroutable"}, 1)
+		return
+	}
+
+	defer wctx.Close()
+
+	L.Trace("bridging tcp connection", "account", account, "labels", target)
+
+	lim := h.bandwidthLimiter(account, limits)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+
+		w := wire.AccountedWriter(wctx)
+		defer w.Close()
+
+		io.Copy(w, &limitedReader{ctx: ctx, r: conn, lim: lim})
+	}()
+
+	io.Copy(conn, &limitedReader{ctx: ctx, r: wire.AccountedReader(wctx), lim: lim})
+
+	// The service is done, so stop reading from the client too.
+	conn.Close()
+
+	wg.Wait()
+
+	L.Trace("tcp connection finished")
+}
+
+// Returns the bandwidth limiter shared by the connections of account, or nil
+// if its bandwidth isn't limited.
+func (h *Hub) bandwidthLimiter(account *pb.Account, limits *pb.Account_Limits) *rate.Limiter {
+	if limits == nil || limits.Bandwidth < 0.00001 {
+		return nil
+	}
+
+	key := account.SpecString()
+
+	if v, ok := h.tcpRates.Get(key); ok {
+		return v.(*rate.Limiter)
+	}
+
+	// Like the web frontend, the burst is a tenth of a second's worth.
+	burst := int(limits.Bandwidth / 10)
+	if burst < 1 {
+		burst = 1
+	}
+
+	lim := rate.NewLimiter(rate.Limit(limits.Bandwidth), burst)
+
+	h.tcpRates.Add(key, lim)
+
+	return lim
+}
+
+// Reads at most the bandwidth allowed by lim, which is in KB/s.
+type limitedReader struct {
+	ctx context.Context
+	r   io.Reader
+	lim *rate.Limiter
+}
+
+func (l *limitedReader) Read(b []byte) (int, error) {
+	if l.lim == nil {
+		return l.r.Read(b)
+	}
+
+	if limit := l.lim.Burst() * 1024; len(b) > limit {
+		b = b[:limit]
+	}
+
+	n, err := l.r.Read(b)
+	if n == 0 {
+		return n, err
+	}
+
+	tokens := n / 1024
+	if tokens == 0 {
+		tokens = 1
+	}
+
+	if werr := l.lim.WaitN(l.ctx, tokens); werr != nil && err == nil {
+		err = errors.Wrapf(werr, "waiting for bandwidth")
+	}
+
+	return n, err
+}
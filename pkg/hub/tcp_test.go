@@ -0,0 +1,131 @@
+package hub
+
+import (
+	"context"
+	"io"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/horizon/pkg/agent"
+	"github.com/hashicorp/horizon/pkg/control"
+	"github.com/hashicorp/horizon/pkg/discovery"
+	"github.com/hashicorp/horizon/pkg/pb"
+	"github.com/hashicorp/horizon/pkg/testutils/central"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTCPIngress(t *testing.T) {
+	central.Dev(t, func(setup *central.DevSetup) {
+		L := hclog.L()
+
+		hub, err := NewHub(L, setup.ControlClient, setup.HubServToken)
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		go hub.Run(ctx, setup.ClientListener)
+
+		time.Sleep(time.Second)
+
+		upstream, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+
+		defer upstream.Close()
+
+		go func() {
+			for {
+				c, err := upstream.Accept()
+				if err != nil {
+					return
+				}
+
+				go func() {
+					defer c.Close()
+					io.Copy(c, c)
+				}()
+			}
+		}()
+
+		g, err := agent.NewAgent(L.Named("agent"))
+		require.NoError(t, err)
+
+		g.Token = setup.AgentToken
+
+		_, err = g.AddService(&agent.Service{
+			Type:    "tcp",
+			Labels:  pb.ParseLabelSet("service=tcp-echo"),
+			Handler: agent.TCPHandler(upstream.Addr().String()),
+		})
+		require.NoError(t, err)
+
+		err = g.Start(ctx, discovery.HubConfigs(discovery.HubConfig{
+			Addr:     setup.HubAddr,
+			Insecure: true,
+		}))
+		require.NoError(t, err)
+
+		go g.Wait(ctx)
+
+		time.Sleep(time.Second)
+
+		resp, err := setup.MgmtClient.AllocateTCPPort(ctx, &pb.AllocateTCPPortRequest{
+			Account: setup.Account,
+			Target:  pb.ParseLabelSet("service=tcp-echo"),
+		})
+		require.NoError(t, err)
+
+		port := int(resp.Port)
+
+		resp, err = setup.MgmtClient.AllocateTCPPort(ctx, &pb.AllocateTCPPortRequest{
+			Account: setup.Account,
+			Target:  pb.ParseLabelSet("service=other"),
+		})
+		require.NoError(t, err)
+
+		other := int(resp.Port)
+
+		assert.NotEqual(t, port, other)
+
+		time.Sleep(time.Second)
+
+		require.NoError(t, setup.ControlClient.ForceLabelLinkUpdate(ctx, L))
+
+		go hub.RunTCPIngress(ctx, "127.0.0.1", control.DefaultTCPPortMin, control.DefaultTCPPortMax)
+
+		time.Sleep(time.Second)
+
+		t.Run("bridges connections to the linked service", func(t *testing.T) {
+			conn, err := net.Dial("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(port)))
+			require.NoError(t, err)
+
+			defer conn.Close()
+
+			_, err = conn.Write([]byte("hello hzn over tcp"))
+			require.NoError(t, err)
+
+			buf := make([]byte, len("hello hzn over tcp"))
+
+			_, err = io.ReadFull(conn, buf)
+			require.NoError(t, err)
+
+			assert.Equal(t, "hello hzn over tcp", string(buf))
+		})
+
+		t.Run("closes connections to ports without a service", func(t *testing.T) {
+			conn, err := net.Dial("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(other)))
+			require.NoError(t, err)
+
+			defer conn.Close()
+
+			conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+			_, err = conn.Read(make([]byte, 1))
+			assert.Equal(t, io.EOF, err)
+		})
+	})
+}
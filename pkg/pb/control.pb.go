@@ -1970,6 +1970,210 @@ func (m *ListAccountsResponse) GetNextMarker() []byte {
 	return nil
 }
 
+type AllocateTCPPortRequest struct {
+	Account *Account  `protobuf:"bytes,1,opt,name=account,proto3" json:"account,omitempty"`
+	Target  *LabelSet `protobuf:"bytes,2,opt,name=target,proto3" json:"target,omitempty"`
+}
+
+func (m *AllocateTCPPortRequest) Reset()      { *m = AllocateTCPPortRequest{} }
+func (*AllocateTCPPortRequest) ProtoMessage() {}
+func (*AllocateTCPPortRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0c5120591600887d, []int{34}
+}
+func (m *AllocateTCPPortRequest) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *AllocateTCPPortRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_AllocateTCPPortRequest.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *AllocateTCPPortRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AllocateTCPPortRequest.Merge(m, src)
+}
+func (m *AllocateTCPPortRequest) XXX_Size() int {
+	return m.Size()
+}
+func (m *AllocateTCPPortRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_AllocateTCPPortRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AllocateTCPPortRequest proto.InternalMessageInfo
+
+func (m *AllocateTCPPortRequest) GetAccount() *Account {
+	if m != nil {
+		return m.Account
+	}
+	return nil
+}
+
+func (m *AllocateTCPPortRequest) GetTarget() *LabelSet {
+	if m != nil {
+		return m.Target
+	}
+	return nil
+}
+
+type AllocateTCPPortResponse struct {
+	Port int32 `protobuf:"varint,1,opt,name=port,proto3" json:"port,omitempty"`
+}
+
+func (m *AllocateTCPPortResponse) Reset()      { *m = AllocateTCPPortResponse{} }
+func (*AllocateTCPPortResponse) ProtoMessage() {}
+func (*AllocateTCPPortResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0c5120591600887d, []int{35}
+}
+func (m *AllocateTCPPortResponse) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *AllocateTCPPortResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_AllocateTCPPortResponse.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *AllocateTCPPortResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AllocateTCPPortResponse.Merge(m, src)
+}
+func (m *AllocateTCPPortResponse) XXX_Size() int {
+	return m.Size()
+}
+func (m *AllocateTCPPortResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_AllocateTCPPortResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AllocateTCPPortResponse proto.InternalMessageInfo
+
+func (m *AllocateTCPPortResponse) GetPort() int32 {
+	if m != nil {
+		return m.Port
+	}
+	return 0
+}
+
+type AccountQuotas struct {
+	Account  *Account `protobuf:"bytes,1,opt,name=account,proto3" json:"account,omitempty"`
+	Agents   int64    `protobuf:"varint,2,opt,name=agents,proto3" json:"agents,omitempty"`
+	Services int64    `protobuf:"varint,3,opt,name=services,proto3" json:"services,omitempty"`
+	Streams  int64    `protobuf:"varint,4,opt,name=streams,proto3" json:"streams,omitempty"`
+}
+
+func (m *AccountQuotas) Reset()      { *m = AccountQuotas{} }
+func (*AccountQuotas) ProtoMessage() {}
+func (*AccountQuotas) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0c5120591600887d, []int{36}
+}
+func (m *AccountQuotas) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *AccountQuotas) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_AccountQuotas.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *AccountQuotas) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AccountQuotas.Merge(m, src)
+}
+func (m *AccountQuotas) XXX_Size() int {
+	return m.Size()
+}
+func (m *AccountQuotas) XXX_DiscardUnknown() {
+	xxx_messageInfo_AccountQuotas.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AccountQuotas proto.InternalMessageInfo
+
+func (m *AccountQuotas) GetAccount() *Account {
+	if m != nil {
+		return m.Account
+	}
+	return nil
+}
+
+func (m *AccountQuotas) GetAgents() int64 {
+	if m != nil {
+		return m.Agents
+	}
+	return 0
+}
+
+func (m *AccountQuotas) GetServices() int64 {
+	if m != nil {
+		return m.Services
+	}
+	return 0
+}
+
+func (m *AccountQuotas) GetStreams() int64 {
+	if m != nil {
+		return m.Streams
+	}
+	return 0
+}
+
+type AccountQuotasList struct {
+	Quotas []*AccountQuotas `protobuf:"bytes,1,rep,name=quotas,proto3" json:"quotas,omitempty"`
+}
+
+func (m *AccountQuotasList) Reset()      { *m = AccountQuotasList{} }
+func (*AccountQuotasList) ProtoMessage() {}
+func (*AccountQuotasList) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0c5120591600887d, []int{37}
+}
+func (m *AccountQuotasList) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *AccountQuotasList) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_AccountQuotasList.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *AccountQuotasList) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AccountQuotasList.Merge(m, src)
+}
+func (m *AccountQuotasList) XXX_Size() int {
+	return m.Size()
+}
+func (m *AccountQuotasList) XXX_DiscardUnknown() {
+	xxx_messageInfo_AccountQuotasList.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AccountQuotasList proto.InternalMessageInfo
+
+func (m *AccountQuotasList) GetQuotas() []*AccountQuotas {
+	if m != nil {
+		return m.Quotas
+	}
+	return nil
+}
+
 func init() {
 	proto.RegisterType((*ServiceRequest)(nil), "pb.ServiceRequest")
 	proto.RegisterType((*ServiceResponse)(nil), "pb.ServiceResponse")
@@ -2007,127 +2211,138 @@ func init() {
 	proto.RegisterType((*TokenInfo)(nil), "pb.TokenInfo")
 	proto.RegisterType((*ListAccountsRequest)(nil), "pb.ListAccountsRequest")
 	proto.RegisterType((*ListAccountsResponse)(nil), "pb.ListAccountsResponse")
+	proto.RegisterType((*AllocateTCPPortRequest)(nil), "pb.AllocateTCPPortRequest")
+	proto.RegisterType((*AllocateTCPPortResponse)(nil), "pb.AllocateTCPPortResponse")
+	proto.RegisterType((*AccountQuotas)(nil), "pb.AccountQuotas")
+	proto.RegisterType((*AccountQuotasList)(nil), "pb.AccountQuotasList")
 }
 
 func init() { proto.RegisterFile("control.proto", fileDescriptor_0c5120591600887d) }
 
 var fileDescriptor_0c5120591600887d = []byte{
-	// 1828 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xcc, 0x58, 0x4b, 0x73, 0x1b, 0xc7,
-	0x11, 0xc6, 0x02, 0x20, 0x08, 0x34, 0x00, 0x42, 0x1c, 0xd0, 0x12, 0x02, 0x27, 0x90, 0x32, 0x56,
-	0x2c, 0xc5, 0xb6, 0x28, 0x87, 0x54, 0x94, 0x47, 0x29, 0x0f, 0x08, 0x8a, 0x4d, 0x46, 0xb4, 0xe3,
-	0x5a, 0xca, 0xbe, 0x6e, 0x66, 0x77, 0x87, 0xe0, 0x16, 0x17, 0xbb, 0xc8, 0xce, 0x2c, 0x19, 0xe4,
-	0x90, 0x4a, 0xe5, 0x94, 0xdc, 0x72, 0xc8, 0x25, 0xb9, 0xe5, 0x96, 0xca, 0x21, 0xe5, 0x9f, 0xe1,
-	0x5b, 0x74, 0x4a, 0xf9, 0x94, 0x8a, 0xa8, 0x4b, 0x8e, 0xfe, 0x09, 0xa9, 0x79, 0xec, 0x93, 0x10,
-	0x24, 0xab, 0x4a, 0x55, 0xbe, 0x61, 0xba, 0xbf, 0xe9, 0xe9, 0x9e, 0xe9, 0xfe, 0xba, 0x17, 0xd0,
-	0x75, 0xc2, 0x80, 0x47, 0xa1, 0xbf, 0x3d, 0x8f, 0x42, 0x1e, 0xa2, 0xea, 0xdc, 0x1e, 0xf6, 0x5c,
-	0x7a, 0xc4, 0x6e, 0x4f, 0xc3, 0x69, 0xa8, 0x84, 0xc3, 0xe6, 0xc9, 0xa9, 0xfe, 0xd5, 0xf6, 0x89,
-	0x4d, 0x35, 0x76, 0xd8, 0x25, 0x8e, 0x13, 0xc6, 0x01, 0xd7, 0x4b, 0x88, 0x7d, 0xcf, 0x4d, 0x70,
-	0x3c, 0x3c, 0xa1, 0x81, 0x5e, 0xf4, 0xb8, 0x37, 0xa3, 0x8c, 0x93, 0xd9, 0x3c, 0x41, 0x1e, 0xf9,
-	0xe1, 0x59, 0x62, 0x24, 0xa0, 0xfc, 0x2c, 0x8c, 0x4e, 0xd4, 0x12, 0xff, 0xcb, 0x80, 0x8d, 0x43,
-	0x1a, 0x9d, 0x7a, 0x0e, 0x35, 0xe9, 0xaf, 0x62, 0xca, 0x38, 0xfa, 0x16, 0xac, 0xeb, 0x83, 0x06,
-	0xc6, 0x35, 0xe3, 0x66, 0x7b, 0xa7, 0xbd, 0x3d, 0xb7, 0xb7, 0xc7, 0x4a, 0x64, 0x26, 0x3a, 0x34,
-	0x84, 0xda, 0x71, 0x6c, 0x0f, 0xaa, 0x12, 0xd2, 0x14, 0x90, 0x8f, 0x0f, 0xf6, 0x1f, 0x98, 0x42,
-	0x88, 0x06, 0x50, 0xf5, 0xdc, 0x41, 0xad, 0xa4, 0xaa, 0x7a, 0x2e, 0x42, 0x50, 0xe7, 0x8b, 0x39,
-	0x1d, 0xd4, 0xaf, 0x19, 0x37, 0x5b, 0xa6, 0xfc, 0x8d, 0xae, 0x43, 0x43, 0x86, 0xc9, 0x06, 0x6b,
-	0x72, 0x47, 0x47, 0xec, 0x38, 0x10, 0x92, 0x43, 0xca, 0x4d, 0xad, 0x43, 0x6f, 0x42, 0x73, 0x46,
-	0x39, 0x71, 0x09, 0x27, 0x83, 0xc6, 0xb5, 0xda, 0xcd, 0xf6, 0x0e, 0x08, 0xdc, 0xc3, 0x4f, 0x3e,
-	0x22, 0x5e, 0x64, 0xa6, 0x3a, 0xbc, 0x09, 0xbd, 0x34, 0x20, 0x36, 0x0f, 0x03, 0x46, 0xf1, 0x3f,
-	0x0c, 0x68, 0x49, 0x7b, 0x07, 0x5e, 0x70, 0xf2, 0xa2, 0xf1, 0x65, 0x5e, 0x55, 0x57, 0x78, 0x75,
-	0x1d, 0x1a, 0x9c, 0x44, 0x53, 0xca, 0x75, 0xb4, 0x25, 0x94, 0xd2, 0xa1, 0xb7, 0xa0, 0xe1, 0x7b,
-	0x33, 0x8f, 0x33, 0x19, 0x77, 0x7b, 0x07, 0xe5, 0x4e, 0xdc, 0x3e, 0x90, 0x1a, 0x53, 0x23, 0xf0,
-	0x3d, 0x80, 0xd4, 0x57, 0x86, 0xb6, 0x41, 0xa5, 0x80, 0xe5, 0x8b, 0xe5, 0xc0, 0x90, 0x81, 0x77,
-	0xd3, 0x43, 0x04, 0xc8, 0x04, 0x3f, 0xc5, 0xe3, 0xdf, 0x42, 0x27, 0x89, 0x3e, 0x8c, 0x39, 0x4d,
-	0x5e, 0xc9, 0x78, 0xf6, 0x2b, 0x55, 0x57, 0xbc, 0x52, 0x6d, 0xe9, 0x2b, 0xd5, 0x9f, 0x7d, 0x1f,
-	0xf8, 0x08, 0x7a, 0x3a, 0x2e, 0xed, 0x06, 0x7b, 0xd1, 0xfb, 0x7e, 0x07, 0x9a, 0x4c, 0x6f, 0x19,
-	0x54, 0x65, 0x98, 0x97, 0x04, 0x2e, 0x1f, 0x8d, 0x99, 0x22, 0x30, 0x87, 0xee, 0xd8, 0xe1, 0xde,
-	0xa9, 0xc7, 0x17, 0x3f, 0x0b, 0x78, 0xb4, 0x40, 0x77, 0xa0, 0x1d, 0x09, 0x8c, 0x45, 0x5c, 0x97,
-	0xba, 0xfa, 0xa4, 0x7e, 0xee, 0xa4, 0xc4, 0x1f, 0x13, 0x24, 0x6e, 0x2c, 0x60, 0xe8, 0x16, 0x74,
-	0xd5, 0xae, 0x88, 0xce, 0xc2, 0x53, 0x7a, 0xf1, 0x36, 0x3a, 0x52, 0x6d, 0x2a, 0x2d, 0xfe, 0xb3,
-	0x01, 0xdd, 0x49, 0x18, 0x1c, 0x79, 0xd3, 0xac, 0x58, 0x5a, 0x8c, 0x13, 0xdb, 0xa7, 0x96, 0xe7,
-	0x5e, 0xb8, 0xe5, 0xa6, 0x52, 0xed, 0xbb, 0xe8, 0xdb, 0xd0, 0xf6, 0x02, 0xc6, 0x49, 0xe0, 0x48,
-	0x60, 0xf9, 0x14, 0x48, 0x94, 0xfb, 0x2e, 0xfa, 0x0e, 0xb4, 0xfc, 0xd0, 0x21, 0xdc, 0x0b, 0x03,
-	0x36, 0xa8, 0xc9, 0x8b, 0x90, 0x61, 0x7c, 0xa8, 0xea, 0xf6, 0x40, 0xeb, 0xcc, 0x0c, 0x85, 0x9f,
-	0x1a, 0xb0, 0x91, 0xb8, 0xa5, 0x52, 0x1e, 0x5d, 0x81, 0x75, 0xee, 0x33, 0xeb, 0x84, 0x2e, 0xa4,
-	0x57, 0x1d, 0xb3, 0xc1, 0x7d, 0xf6, 0x90, 0x2e, 0xd0, 0xd7, 0xa0, 0x29, 0x14, 0x0e, 0x8d, 0xb8,
-	0x74, 0xa3, 0x63, 0x0a, 0xe0, 0x84, 0x46, 0x1c, 0xbd, 0x0e, 0x2d, 0x49, 0x23, 0xd6, 0x3c, 0xb6,
-	0xe5, 0xd3, 0x77, 0xcc, 0xa6, 0x14, 0x7c, 0x14, 0xdb, 0x08, 0x43, 0x97, 0xed, 0x5a, 0xc4, 0x71,
-	0x28, 0x53, 0x66, 0x55, 0x05, 0xb7, 0xd9, 0xee, 0x58, 0xca, 0x84, 0x6d, 0x85, 0x61, 0xd4, 0x89,
-	0x28, 0x97, 0x98, 0xb5, 0x04, 0x73, 0x28, 0x65, 0x02, 0xf3, 0x3a, 0xb4, 0xd8, 0xae, 0x65, 0xc7,
-	0xce, 0x09, 0xe5, 0x83, 0x86, 0xd4, 0x37, 0xd9, 0xee, 0x7d, 0xb9, 0x16, 0x4a, 0x6f, 0x46, 0xa6,
-	0xd4, 0xe2, 0x64, 0x3a, 0x58, 0x57, 0x4a, 0x29, 0x78, 0x44, 0xa6, 0xf8, 0x9f, 0x06, 0xf4, 0x26,
-	0x34, 0xe0, 0x11, 0xf1, 0x93, 0xa7, 0x47, 0x3f, 0x86, 0x4b, 0x3a, 0x7f, 0xac, 0x34, 0x79, 0x8c,
-	0xec, 0xce, 0xca, 0x4f, 0xdf, 0x23, 0xa5, 0xdc, 0x7c, 0x03, 0xba, 0x91, 0x7a, 0x49, 0x8b, 0x71,
-	0xc2, 0x55, 0xad, 0x37, 0xcd, 0x8e, 0x16, 0x1e, 0x0a, 0x19, 0xba, 0x0b, 0xbd, 0x80, 0x9e, 0x59,
-	0xf9, 0x3a, 0x54, 0xc5, 0xbe, 0x51, 0xa8, 0x43, 0x66, 0x76, 0x03, 0x7a, 0x96, 0x2d, 0xf1, 0xef,
-	0xd7, 0xa0, 0xbd, 0x17, 0xdb, 0xa9, 0xb3, 0xdf, 0x87, 0xf5, 0xe3, 0xd8, 0xb6, 0x22, 0x3a, 0xd5,
-	0x99, 0x72, 0x55, 0xec, 0xcf, 0x21, 0xc4, 0x6f, 0x93, 0x4e, 0x3d, 0xc6, 0x23, 0xf5, 0xc6, 0x8d,
-	0x63, 0x29, 0x40, 0x6f, 0xc2, 0x3a, 0xa3, 0x01, 0xb7, 0x08, 0xd7, 0xa9, 0x23, 0x19, 0xe0, 0x51,
-	0x42, 0xf3, 0x66, 0x43, 0x68, 0xc7, 0x1c, 0x6d, 0xc3, 0x9a, 0x0a, 0x43, 0xf9, 0x37, 0x58, 0x62,
-	0x5f, 0x86, 0x64, 0x2a, 0x18, 0xc2, 0x50, 0x17, 0xad, 0x61, 0x50, 0x97, 0x57, 0x26, 0xc3, 0x79,
-	0xcf, 0x0f, 0xcf, 0x4c, 0xea, 0x84, 0x91, 0x6b, 0x4a, 0xdd, 0xf0, 0x8f, 0x06, 0xf4, 0x4a, 0x7e,
-	0xad, 0x64, 0x95, 0x1b, 0x00, 0xba, 0x22, 0x96, 0xb5, 0x07, 0x5d, 0x2d, 0x7b, 0xb1, 0xfd, 0x12,
-	0x89, 0x3e, 0xfc, 0xb4, 0x0a, 0xcd, 0x24, 0x06, 0xf4, 0x36, 0x6c, 0x92, 0xa9, 0xb8, 0x15, 0x27,
-	0x0c, 0x02, 0xea, 0x28, 0x3b, 0xc2, 0xa5, 0x9a, 0x79, 0x49, 0x2a, 0x26, 0x99, 0x5c, 0x3c, 0xb4,
-	0x7e, 0x7b, 0x66, 0x31, 0x4a, 0x03, 0xe9, 0x58, 0xcd, 0xec, 0x24, 0xc2, 0x43, 0x4a, 0x03, 0x74,
-	0x03, 0x7a, 0x29, 0xc8, 0x21, 0xce, 0x31, 0x55, 0x3d, 0xac, 0x66, 0x6e, 0x24, 0xe2, 0x89, 0x94,
-	0xa2, 0x6f, 0x42, 0x47, 0xe9, 0x2d, 0x7b, 0xc1, 0xa9, 0x62, 0xc4, 0x9a, 0xd9, 0x56, 0xb2, 0xfb,
-	0x42, 0x84, 0x26, 0x70, 0xd9, 0x27, 0x22, 0xad, 0x62, 0x59, 0x1e, 0x47, 0xb1, 0x6f, 0xc5, 0x73,
-	0x97, 0x70, 0xaa, 0x9b, 0x5c, 0xe9, 0x05, 0xb7, 0x04, 0xf8, 0x30, 0xc5, 0x7e, 0x2c, 0xa1, 0x68,
-	0x0c, 0xaf, 0x49, 0x23, 0x84, 0x73, 0x3a, 0x9b, 0x73, 0xea, 0x26, 0x36, 0x1a, 0xcb, 0x6c, 0xf4,
-	0x05, 0x76, 0x9c, 0x40, 0x95, 0x09, 0xfc, 0x09, 0xac, 0xef, 0xc5, 0xf6, 0x7e, 0x70, 0x14, 0x6a,
-	0xbe, 0x37, 0x96, 0xf0, 0x7d, 0xe1, 0x29, 0xaa, 0x2f, 0xc4, 0x39, 0xb7, 0x00, 0x0e, 0x3c, 0xc6,
-	0x7f, 0x71, 0xb4, 0x17, 0xdb, 0x0c, 0x5d, 0x85, 0xfa, 0x71, 0x6c, 0x27, 0xb5, 0xd7, 0xd6, 0x79,
-	0x27, 0x4e, 0x35, 0xa5, 0x02, 0xff, 0x46, 0xba, 0x71, 0xb8, 0x08, 0x9c, 0x15, 0x6e, 0x14, 0xc8,
-	0xb4, 0xfa, 0x4c, 0x32, 0xdd, 0xce, 0x75, 0x0a, 0x95, 0x37, 0x28, 0xdf, 0x29, 0x54, 0xe9, 0xe6,
-	0x7a, 0xc5, 0x5d, 0x99, 0xc0, 0xe2, 0xec, 0x94, 0x1e, 0xdf, 0x80, 0xae, 0x56, 0x5b, 0x59, 0x67,
-	0xaa, 0x99, 0x1d, 0x2d, 0x9c, 0x08, 0x19, 0xfe, 0x8b, 0x01, 0x28, 0xcd, 0x7c, 0x1a, 0x7d, 0xa5,
-	0x28, 0xff, 0x7d, 0xe8, 0x17, 0x5c, 0xd3, 0x71, 0xbd, 0x0b, 0x1d, 0x3d, 0x5f, 0x5a, 0x62, 0x08,
-	0xd4, 0xee, 0x95, 0xf2, 0xa4, 0xad, 0x21, 0x42, 0x82, 0x8f, 0x61, 0x6b, 0x2f, 0xb6, 0x1f, 0x78,
-	0x4c, 0x57, 0xd1, 0x2b, 0x8b, 0x12, 0xef, 0x42, 0x5f, 0x3f, 0xd1, 0x23, 0xd1, 0x54, 0x92, 0x83,
-	0xbe, 0x0e, 0xad, 0x80, 0xcc, 0x28, 0x9b, 0x13, 0x47, 0xf9, 0xdb, 0x32, 0x33, 0x01, 0x7e, 0x07,
-	0xb6, 0x8a, 0x9b, 0x74, 0xa0, 0x5b, 0xb0, 0x26, 0x5b, 0x93, 0xde, 0xa1, 0x16, 0xf8, 0x1e, 0xf4,
-	0x45, 0x52, 0xa6, 0x7c, 0xff, 0xa5, 0x26, 0x5a, 0xfc, 0x13, 0xd8, 0x2a, 0xee, 0xd6, 0x67, 0xdd,
-	0xc8, 0xe5, 0x5b, 0x2e, 0xc1, 0x93, 0x7c, 0xcb, 0x12, 0xed, 0x6f, 0x06, 0xac, 0x6b, 0xe9, 0x8a,
-	0x2c, 0x5f, 0x35, 0x38, 0xbf, 0xf4, 0xe0, 0x55, 0x18, 0x8f, 0xd7, 0x56, 0x8c, 0xc7, 0x47, 0xb0,
-	0x39, 0x76, 0xdd, 0x24, 0xf6, 0x2f, 0x37, 0xf2, 0x67, 0x63, 0x6c, 0xf5, 0xb9, 0x63, 0xec, 0x1f,
-	0x0c, 0xe8, 0x8f, 0x5d, 0x37, 0x9b, 0x52, 0xf5, 0x51, 0x59, 0x34, 0xc6, 0x8a, 0x68, 0x72, 0x0e,
-	0x55, 0x57, 0xcf, 0xe8, 0xcf, 0x9f, 0xbe, 0x71, 0x03, 0xea, 0x1f, 0x86, 0xe1, 0x1c, 0x53, 0xb8,
-	0xac, 0x06, 0xb9, 0x57, 0xea, 0x14, 0xfe, 0xd4, 0x00, 0x34, 0x89, 0x28, 0xe1, 0xc5, 0x3c, 0x7f,
-	0xc1, 0x3b, 0xfe, 0x91, 0x68, 0x2d, 0x73, 0x62, 0x7b, 0xbe, 0xc7, 0x3d, 0x5a, 0x60, 0x63, 0x69,
-	0x6e, 0x92, 0x28, 0x17, 0xf7, 0xeb, 0x9f, 0xfd, 0xe7, 0x6a, 0xc5, 0x2c, 0xc0, 0xd1, 0x1d, 0xd8,
-	0x38, 0x25, 0xbe, 0xe7, 0x5a, 0x6e, 0xac, 0x7a, 0xb5, 0xbe, 0x99, 0x12, 0x05, 0x74, 0x25, 0xe8,
-	0x81, 0xc6, 0xe0, 0xb7, 0xa1, 0x5f, 0xf0, 0x78, 0x65, 0x91, 0xdd, 0x86, 0xde, 0x44, 0x11, 0x48,
-	0x42, 0x3f, 0xcf, 0xa9, 0xe1, 0xeb, 0xd0, 0xd1, 0x1b, 0xa4, 0xf9, 0x67, 0x98, 0x7d, 0x0b, 0x5a,
-	0x52, 0x2d, 0x5b, 0xd5, 0x37, 0x00, 0xe6, 0xb1, 0xed, 0x7b, 0x4e, 0x6e, 0x82, 0x6d, 0x29, 0xc9,
-	0x43, 0xba, 0xc0, 0x13, 0x55, 0xe7, 0xfa, 0xf2, 0xd2, 0x3a, 0xdf, 0x82, 0x35, 0x99, 0x7d, 0x72,
-	0xc3, 0x9a, 0xa9, 0x16, 0xe8, 0x32, 0x34, 0x66, 0x24, 0x3a, 0xa1, 0x91, 0x9e, 0x77, 0xf5, 0x0a,
-	0xff, 0x52, 0x95, 0x7b, 0x66, 0x24, 0x2b, 0xf7, 0xa4, 0xdd, 0xe7, 0xcb, 0x3d, 0x79, 0xa9, 0x54,
-	0x89, 0xae, 0x42, 0x3b, 0xa0, 0xbf, 0xe6, 0x56, 0xc1, 0x3a, 0x08, 0xd1, 0x07, 0x52, 0xb2, 0xf3,
-	0xd7, 0x7a, 0x7a, 0x55, 0xe9, 0xc4, 0xf9, 0x3d, 0x80, 0xb1, 0xeb, 0x26, 0x2c, 0xb1, 0xa4, 0x71,
-	0x0d, 0xfb, 0x05, 0x99, 0xfe, 0x84, 0xad, 0xa0, 0x1f, 0x42, 0x57, 0x65, 0xef, 0x4b, 0xec, 0x9d,
-	0x40, 0x27, 0xcf, 0x6c, 0xe8, 0x8a, 0xcc, 0xef, 0x8b, 0x4c, 0x39, 0x1c, 0x5c, 0x54, 0xa4, 0x46,
-	0xee, 0x42, 0xfb, 0x3d, 0xca, 0x9d, 0x63, 0xf5, 0xa5, 0x81, 0x36, 0x05, 0xb4, 0xf0, 0x31, 0x34,
-	0x44, 0x79, 0x51, 0xba, 0xef, 0x1e, 0x6c, 0x1c, 0xf2, 0x88, 0x92, 0x59, 0x3a, 0x08, 0xf7, 0x4a,
-	0x73, 0xa9, 0x72, 0xbb, 0x34, 0xdb, 0xe3, 0xca, 0x4d, 0xe3, 0x5d, 0x03, 0xdd, 0x82, 0x75, 0xd1,
-	0xb9, 0xc5, 0xc0, 0x98, 0x8c, 0x15, 0x62, 0xad, 0xb6, 0x94, 0xda, 0x3a, 0xae, 0xa0, 0xef, 0x42,
-	0xb7, 0xd0, 0xce, 0x50, 0x32, 0x03, 0x5f, 0xe8, 0x70, 0x43, 0x49, 0xbd, 0x92, 0x18, 0x2a, 0xa2,
-	0x38, 0xc7, 0xbe, 0x2f, 0x47, 0x99, 0x54, 0x3c, 0xdc, 0x48, 0x2e, 0x43, 0x0d, 0x39, 0xb8, 0x82,
-	0x7e, 0x0e, 0x7d, 0xbd, 0x3b, 0xdf, 0x94, 0xd4, 0x75, 0x2e, 0xe9, 0x6d, 0xea, 0x3a, 0x97, 0xf5,
-	0x2f, 0x5c, 0xd9, 0xf9, 0x77, 0x0d, 0x36, 0x75, 0x72, 0x7c, 0x40, 0x02, 0x32, 0xa5, 0x33, 0x1a,
-	0x70, 0xb4, 0x0b, 0xcd, 0xb4, 0xaa, 0xfa, 0xfa, 0x3a, 0xf3, 0xa5, 0x36, 0xbc, 0x94, 0x13, 0x4a,
-	0x93, 0xb8, 0x82, 0x6e, 0xcb, 0x9c, 0xd2, 0x09, 0x8a, 0x5e, 0x93, 0xd9, 0x5a, 0xe6, 0xf8, 0x42,
-	0xb8, 0xbb, 0xd0, 0xc9, 0x73, 0xb3, 0x0a, 0x60, 0x09, 0x5b, 0x17, 0x36, 0xfd, 0x00, 0x7a, 0x25,
-	0xfa, 0x44, 0x43, 0xa1, 0x5e, 0xce, 0xa9, 0x85, 0xad, 0x3f, 0x85, 0x76, 0x8e, 0x5f, 0xd0, 0x65,
-	0x19, 0xc3, 0x05, 0x8a, 0x1c, 0x5e, 0xb9, 0x20, 0x4f, 0xdf, 0xf5, 0x0e, 0x74, 0xf7, 0x19, 0x8b,
-	0xc5, 0x87, 0x83, 0xb2, 0x91, 0x3d, 0xd3, 0x8a, 0x5d, 0xdb, 0xb0, 0xf9, 0x3e, 0xe5, 0x8f, 0xf4,
-	0x37, 0xac, 0x22, 0x8f, 0xdc, 0xce, 0x6e, 0xca, 0xaa, 0x82, 0x74, 0xb2, 0x3a, 0x49, 0x28, 0x21,
-	0xab, 0x93, 0x12, 0xd3, 0x64, 0x75, 0x52, 0x66, 0x0f, 0x5c, 0xb9, 0x7f, 0xe7, 0xf1, 0x93, 0x51,
-	0xe5, 0xf3, 0x27, 0xa3, 0xca, 0x17, 0x4f, 0x46, 0xc6, 0xef, 0xce, 0x47, 0xc6, 0xdf, 0xcf, 0x47,
-	0xc6, 0x67, 0xe7, 0x23, 0xe3, 0xf1, 0xf9, 0xc8, 0xf8, 0xef, 0xf9, 0xc8, 0xf8, 0xdf, 0xf9, 0xa8,
-	0xf2, 0xc5, 0xf9, 0xc8, 0xf8, 0xd3, 0xd3, 0x51, 0xe5, 0xf1, 0xd3, 0x51, 0xe5, 0xf3, 0xa7, 0xa3,
-	0x8a, 0xdd, 0x90, 0xff, 0xc7, 0xed, 0xfe, 0x3f, 0x00, 0x00, 0xff, 0xff, 0x1d, 0xf9, 0x4e, 0x4d,
-	0x20, 0x14, 0x00, 0x00,
+	// 1948 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xcc, 0x58, 0x4b, 0x73, 0xdb, 0xd6,
+	0xf5, 0x27, 0xf8, 0xe6, 0x21, 0x29, 0x4a, 0x97, 0x8a, 0x8c, 0x3f, 0xfc, 0x2f, 0xad, 0x22, 0x6e,
+	0xec, 0x3c, 0x2c, 0x27, 0x92, 0xeb, 0x3e, 0xc6, 0x4d, 0x4b, 0xd3, 0x4d, 0xa4, 0x5a, 0x49, 0x5d,
+	0x50, 0xc9, 0x16, 0xc5, 0xe3, 0x8a, 0xc2, 0x08, 0x04, 0x18, 0xe0, 0x42, 0x2a, 0xbb, 0xe8, 0x74,
+	0x3a, 0x5d, 0xb4, 0xbb, 0x2e, 0xba, 0x69, 0x77, 0xdd, 0x75, 0xba, 0xe8, 0xe4, 0x63, 0x64, 0x57,
+	0xef, 0x9a, 0x55, 0xa7, 0x96, 0x37, 0x5d, 0xe6, 0x23, 0x74, 0xee, 0x0b, 0x04, 0x48, 0x8a, 0x96,
+	0x3d, 0x93, 0x99, 0xee, 0x78, 0xcf, 0xf9, 0x9d, 0x73, 0xcf, 0xb9, 0xe7, 0x09, 0x42, 0xdb, 0x09,
+	0x03, 0x12, 0x85, 0xfe, 0xce, 0x24, 0x0a, 0x49, 0x88, 0x8a, 0x13, 0x5b, 0xeb, 0xb8, 0xf8, 0x38,
+	0xbe, 0x3b, 0x0a, 0x47, 0x21, 0x27, 0x6a, 0xf5, 0xd3, 0x33, 0xf1, 0xab, 0xe9, 0x5b, 0x36, 0x16,
+	0x58, 0xad, 0x6d, 0x39, 0x4e, 0x98, 0x04, 0x44, 0x1c, 0x21, 0xf1, 0x3d, 0x57, 0xe2, 0x48, 0x78,
+	0x8a, 0x03, 0x71, 0xe8, 0x10, 0x6f, 0x8c, 0x63, 0x62, 0x8d, 0x27, 0x12, 0x79, 0xec, 0x87, 0xe7,
+	0x52, 0x49, 0x80, 0xc9, 0x79, 0x18, 0x9d, 0xf2, 0xa3, 0xfe, 0x0f, 0x05, 0xd6, 0x86, 0x38, 0x3a,
+	0xf3, 0x1c, 0x6c, 0xe0, 0xcf, 0x12, 0x1c, 0x13, 0xf4, 0x2d, 0xa8, 0x89, 0x8b, 0x54, 0x65, 0x5b,
+	0xb9, 0xdd, 0xdc, 0x6d, 0xee, 0x4c, 0xec, 0x9d, 0x3e, 0x27, 0x19, 0x92, 0x87, 0x34, 0x28, 0x9d,
+	0x24, 0xb6, 0x5a, 0x64, 0x90, 0x3a, 0x85, 0x7c, 0x72, 0x78, 0xf0, 0xc8, 0xa0, 0x44, 0xa4, 0x42,
+	0xd1, 0x73, 0xd5, 0xd2, 0x1c, 0xab, 0xe8, 0xb9, 0x08, 0x41, 0x99, 0x4c, 0x27, 0x58, 0x2d, 0x6f,
+	0x2b, 0xb7, 0x1b, 0x06, 0xfb, 0x8d, 0x6e, 0x42, 0x95, 0xb9, 0x19, 0xab, 0x15, 0x26, 0xd1, 0xa2,
+	0x12, 0x87, 0x94, 0x32, 0xc4, 0xc4, 0x10, 0x3c, 0xf4, 0x06, 0xd4, 0xc7, 0x98, 0x58, 0xae, 0x45,
+	0x2c, 0xb5, 0xba, 0x5d, 0xba, 0xdd, 0xdc, 0x05, 0x8a, 0x7b, 0xfc, 0xe9, 0x13, 0xcb, 0x8b, 0x8c,
+	0x94, 0xa7, 0x6f, 0x40, 0x27, 0x75, 0x28, 0x9e, 0x84, 0x41, 0x8c, 0xf5, 0xbf, 0x29, 0xd0, 0x60,
+	0xfa, 0x0e, 0xbd, 0xe0, 0xf4, 0xaa, 0xfe, 0xcd, 0xac, 0x2a, 0xae, 0xb0, 0xea, 0x26, 0x54, 0x89,
+	0x15, 0x8d, 0x30, 0x51, 0x4b, 0xcb, 0x50, 0x9c, 0x87, 0xde, 0x82, 0xaa, 0xef, 0x8d, 0x3d, 0x12,
+	0x33, 0xbf, 0x9b, 0xbb, 0x28, 0x73, 0xe3, 0xce, 0x21, 0xe3, 0x18, 0x02, 0xa1, 0x3f, 0x00, 0x48,
+	0x6d, 0x8d, 0xd1, 0x0e, 0xf0, 0x14, 0x30, 0x7d, 0x7a, 0x54, 0x15, 0xe6, 0x78, 0x3b, 0xbd, 0x84,
+	0x82, 0x0c, 0xf0, 0x53, 0xbc, 0xfe, 0x2b, 0x68, 0x49, 0xef, 0xc3, 0x84, 0x60, 0x19, 0x25, 0xe5,
+	0xf2, 0x28, 0x15, 0x57, 0x44, 0xa9, 0xb4, 0x34, 0x4a, 0xe5, 0xcb, 0xdf, 0x43, 0x3f, 0x86, 0x8e,
+	0xf0, 0x4b, 0x98, 0x11, 0x5f, 0xf5, 0xbd, 0xdf, 0x81, 0x7a, 0x2c, 0x44, 0xd4, 0x22, 0x73, 0x73,
+	0x9d, 0xe2, 0xb2, 0xde, 0x18, 0x29, 0x42, 0x27, 0xd0, 0xee, 0x3b, 0xc4, 0x3b, 0xf3, 0xc8, 0xf4,
+	0xc7, 0x01, 0x89, 0xa6, 0xe8, 0x1e, 0x34, 0x23, 0x8a, 0x31, 0x2d, 0xd7, 0xc5, 0xae, 0xb8, 0xa9,
+	0x9b, 0xb9, 0x49, 0xda, 0x63, 0x00, 0xc3, 0xf5, 0x29, 0x0c, 0xdd, 0x81, 0x36, 0x97, 0x8a, 0xf0,
+	0x38, 0x3c, 0xc3, 0x8b, 0xaf, 0xd1, 0x62, 0x6c, 0x83, 0x73, 0xf5, 0x3f, 0x2a, 0xd0, 0x1e, 0x84,
+	0xc1, 0xb1, 0x37, 0x9a, 0x15, 0x4b, 0x23, 0x26, 0x96, 0xed, 0x63, 0xd3, 0x73, 0x17, 0x5e, 0xb9,
+	0xce, 0x59, 0x07, 0x2e, 0x7a, 0x13, 0x9a, 0x5e, 0x10, 0x13, 0x2b, 0x70, 0x18, 0x70, 0xfe, 0x16,
+	0x90, 0xcc, 0x03, 0x17, 0xbd, 0x07, 0x0d, 0x3f, 0x74, 0x2c, 0xe2, 0x85, 0x41, 0xac, 0x96, 0xb6,
+	0x4b, 0xd2, 0x8d, 0x8f, 0x79, 0xdd, 0x1e, 0x0a, 0x9e, 0x31, 0x43, 0xe9, 0xcf, 0x15, 0x58, 0x93,
+	0x66, 0xf1, 0x94, 0x47, 0xd7, 0xa0, 0x46, 0xfc, 0xd8, 0x3c, 0xc5, 0x53, 0x66, 0x55, 0xcb, 0xa8,
+	0x12, 0x3f, 0x7e, 0x8c, 0xa7, 0xe8, 0xff, 0xa0, 0x4e, 0x19, 0x0e, 0x8e, 0x08, 0x33, 0xa3, 0x65,
+	0x50, 0xe0, 0x00, 0x47, 0x04, 0x5d, 0x87, 0x06, 0x6b, 0x23, 0xe6, 0x24, 0xb1, 0x59, 0xe8, 0x5b,
+	0x46, 0x9d, 0x11, 0x9e, 0x24, 0x36, 0xd2, 0xa1, 0x1d, 0xef, 0x99, 0x96, 0xe3, 0xe0, 0x98, 0xab,
+	0xe5, 0x15, 0xdc, 0x8c, 0xf7, 0xfa, 0x8c, 0x46, 0x75, 0x73, 0x4c, 0x8c, 0x9d, 0x08, 0x13, 0x86,
+	0xa9, 0x48, 0xcc, 0x90, 0xd1, 0x28, 0xe6, 0x3a, 0x34, 0xe2, 0x3d, 0xd3, 0x4e, 0x9c, 0x53, 0x4c,
+	0xd4, 0x2a, 0xe3, 0xd7, 0xe3, 0xbd, 0x87, 0xec, 0x4c, 0x99, 0xde, 0xd8, 0x1a, 0x61, 0x93, 0x58,
+	0x23, 0xb5, 0xc6, 0x99, 0x8c, 0x70, 0x64, 0x8d, 0xf4, 0xbf, 0x2b, 0xd0, 0x19, 0xe0, 0x80, 0x44,
+	0x96, 0x2f, 0x43, 0x8f, 0xde, 0x87, 0x75, 0x91, 0x3f, 0x66, 0x9a, 0x3c, 0xca, 0x76, 0xe9, 0xb2,
+	0xd0, 0x77, 0xac, 0x3c, 0x01, 0xbd, 0x0e, 0xed, 0x88, 0x47, 0xd2, 0x8c, 0x89, 0x45, 0x78, 0xad,
+	0xd7, 0x8d, 0x96, 0x20, 0x0e, 0x29, 0x0d, 0xdd, 0x87, 0x4e, 0x80, 0xcf, 0xcd, 0x6c, 0x1d, 0xf2,
+	0x62, 0x5f, 0xcb, 0xd5, 0x61, 0x6c, 0xb4, 0x03, 0x7c, 0x3e, 0x3b, 0xea, 0xbf, 0xa9, 0x40, 0x73,
+	0x3f, 0xb1, 0x53, 0x63, 0xbf, 0x0b, 0xb5, 0x93, 0xc4, 0x36, 0x23, 0x3c, 0x12, 0x99, 0x72, 0x83,
+	0xca, 0x67, 0x10, 0xf4, 0xb7, 0x81, 0x47, 0x5e, 0x4c, 0x22, 0x1e, 0xe3, 0xea, 0x09, 0x23, 0xa0,
+	0x37, 0xa0, 0x16, 0xe3, 0x80, 0x98, 0x16, 0x11, 0xa9, 0xc3, 0x3a, 0xc0, 0x91, 0x6c, 0xf3, 0x46,
+	0x95, 0x72, 0xfb, 0x04, 0xed, 0x40, 0x85, 0xbb, 0xc1, 0xed, 0x53, 0x97, 0xe8, 0x67, 0x2e, 0x19,
+	0x1c, 0x86, 0x74, 0x28, 0xd3, 0xd1, 0xa0, 0x96, 0xb7, 0x4b, 0xd2, 0x9d, 0x0f, 0xfc, 0xf0, 0xdc,
+	0xc0, 0x4e, 0x18, 0xb9, 0x06, 0xe3, 0x69, 0xbf, 0x57, 0xa0, 0x33, 0x67, 0xd7, 0xca, 0xae, 0x72,
+	0x0b, 0x40, 0x54, 0xc4, 0xb2, 0xf1, 0x20, 0xaa, 0x65, 0x3f, 0xb1, 0x5f, 0x21, 0xd1, 0xb5, 0xcf,
+	0x8b, 0x50, 0x97, 0x3e, 0xa0, 0xb7, 0x61, 0xc3, 0x1a, 0xd1, 0x57, 0x71, 0xc2, 0x20, 0xc0, 0x0e,
+	0xd7, 0x43, 0x4d, 0x2a, 0x19, 0xeb, 0x8c, 0x31, 0x98, 0xd1, 0x69, 0xa0, 0x45, 0xec, 0x63, 0x33,
+	0xc6, 0x38, 0x60, 0x86, 0x95, 0x8c, 0x96, 0x24, 0x0e, 0x31, 0x0e, 0xd0, 0x2d, 0xe8, 0xa4, 0x20,
+	0xc7, 0x72, 0x4e, 0x30, 0x9f, 0x61, 0x25, 0x63, 0x4d, 0x92, 0x07, 0x8c, 0x8a, 0xbe, 0x09, 0x2d,
+	0xce, 0x37, 0xed, 0x29, 0xc1, 0xbc, 0x23, 0x96, 0x8c, 0x26, 0xa7, 0x3d, 0xa4, 0x24, 0x34, 0x80,
+	0x2d, 0xdf, 0xa2, 0x69, 0x95, 0xb0, 0xf2, 0x38, 0x4e, 0x7c, 0x33, 0x99, 0xb8, 0x16, 0xc1, 0x6a,
+	0x65, 0x59, 0x04, 0x37, 0x29, 0x78, 0x98, 0x62, 0x3f, 0x61, 0x50, 0xd4, 0x87, 0xd7, 0x98, 0x12,
+	0x8b, 0x10, 0x3c, 0x9e, 0x10, 0xec, 0x4a, 0x1d, 0xd5, 0x65, 0x3a, 0xba, 0x14, 0xdb, 0x97, 0x50,
+	0xae, 0x42, 0xff, 0x14, 0x6a, 0xfb, 0x89, 0x7d, 0x10, 0x1c, 0x87, 0xa2, 0xdf, 0x2b, 0x4b, 0xfa,
+	0x7d, 0x2e, 0x14, 0xc5, 0x2b, 0xf5, 0x9c, 0x3b, 0x00, 0x87, 0x5e, 0x4c, 0x7e, 0x7a, 0xbc, 0x9f,
+	0xd8, 0x31, 0xba, 0x01, 0xe5, 0x93, 0xc4, 0x96, 0xb5, 0xd7, 0x14, 0x79, 0x47, 0x6f, 0x35, 0x18,
+	0x43, 0xff, 0x25, 0x33, 0x63, 0x38, 0x0d, 0x9c, 0x15, 0x66, 0xe4, 0x9a, 0x69, 0xf1, 0xd2, 0x66,
+	0xba, 0x93, 0x99, 0x14, 0x3c, 0x6f, 0x50, 0x76, 0x52, 0xf0, 0xd2, 0xcd, 0xcc, 0x8a, 0xfb, 0xd0,
+	0x11, 0x77, 0xa7, 0xed, 0xf1, 0x75, 0x68, 0x0b, 0xb6, 0x39, 0x9b, 0x4c, 0x25, 0xa3, 0x25, 0x88,
+	0x03, 0x4a, 0xd3, 0xff, 0xa4, 0x00, 0x4a, 0x33, 0x1f, 0x47, 0xff, 0x53, 0x2d, 0xff, 0x43, 0xe8,
+	0xe6, 0x4c, 0x13, 0x7e, 0xbd, 0x0b, 0x2d, 0xb1, 0x5f, 0x9a, 0x74, 0x09, 0x54, 0x95, 0x65, 0x79,
+	0xd2, 0x14, 0x10, 0x4a, 0xd1, 0x4f, 0x60, 0x73, 0x3f, 0xb1, 0x1f, 0x79, 0xb1, 0xa8, 0xa2, 0xaf,
+	0xcd, 0x4b, 0x7d, 0x0f, 0xba, 0x22, 0x44, 0x47, 0x74, 0xa8, 0xc8, 0x8b, 0xfe, 0x1f, 0x1a, 0x81,
+	0x35, 0xc6, 0xf1, 0xc4, 0x72, 0xb8, 0xbd, 0x0d, 0x63, 0x46, 0xd0, 0xdf, 0x81, 0xcd, 0xbc, 0x90,
+	0x70, 0x74, 0x13, 0x2a, 0x6c, 0x34, 0x09, 0x09, 0x7e, 0xd0, 0x1f, 0x40, 0x97, 0x26, 0x65, 0xda,
+	0xef, 0x5f, 0x6a, 0xa3, 0xd5, 0x7f, 0x08, 0x9b, 0x79, 0x69, 0x71, 0xd7, 0xad, 0x4c, 0xbe, 0x65,
+	0x12, 0x5c, 0xe6, 0xdb, 0x2c, 0xd1, 0xfe, 0xa2, 0x40, 0x4d, 0x50, 0x57, 0x64, 0xf9, 0xaa, 0xc5,
+	0xf9, 0x95, 0x17, 0xaf, 0xdc, 0x7a, 0x5c, 0x59, 0xb1, 0x1e, 0x1f, 0xc3, 0x46, 0xdf, 0x75, 0xa5,
+	0xef, 0x2f, 0xb7, 0xf2, 0xcf, 0xd6, 0xd8, 0xe2, 0x0b, 0xd7, 0xd8, 0xdf, 0x29, 0xd0, 0xed, 0xbb,
+	0xee, 0x6c, 0x4b, 0x15, 0x57, 0xcd, 0xbc, 0x51, 0x56, 0x78, 0x93, 0x31, 0xa8, 0xb8, 0x7a, 0x47,
+	0x7f, 0xf1, 0xf6, 0xad, 0x57, 0xa1, 0xfc, 0x71, 0x18, 0x4e, 0x74, 0x0c, 0x5b, 0x7c, 0x91, 0xfb,
+	0x5a, 0x8d, 0xd2, 0x3f, 0x57, 0x00, 0x0d, 0x22, 0x6c, 0x91, 0x7c, 0x9e, 0x5f, 0xf1, 0x8d, 0x7f,
+	0x40, 0x47, 0xcb, 0xc4, 0xb2, 0x3d, 0xdf, 0x23, 0x1e, 0xce, 0x75, 0x63, 0xa6, 0x6e, 0x20, 0x99,
+	0xd3, 0x87, 0xe5, 0x2f, 0xfe, 0x75, 0xa3, 0x60, 0xe4, 0xe0, 0xe8, 0x1e, 0xac, 0x9d, 0x59, 0xbe,
+	0xe7, 0x9a, 0x6e, 0xc2, 0x67, 0xb5, 0x5a, 0x5a, 0xd6, 0x02, 0xda, 0x0c, 0xf4, 0x48, 0x60, 0xf4,
+	0xb7, 0xa1, 0x9b, 0xb3, 0x78, 0x65, 0x91, 0xdd, 0x85, 0xce, 0x80, 0x37, 0x10, 0xd9, 0x7e, 0x5e,
+	0x50, 0xc3, 0x37, 0xa1, 0x25, 0x04, 0x98, 0xfa, 0x4b, 0xd4, 0xbe, 0x05, 0x0d, 0xc6, 0x66, 0xa3,
+	0xea, 0x1b, 0x00, 0x93, 0xc4, 0xf6, 0x3d, 0x27, 0xb3, 0xc1, 0x36, 0x38, 0xe5, 0x31, 0x9e, 0xea,
+	0x03, 0x5e, 0xe7, 0xe2, 0xf1, 0xd2, 0x3a, 0xdf, 0x84, 0x0a, 0xcb, 0x3e, 0x26, 0x50, 0x31, 0xf8,
+	0x01, 0x6d, 0x41, 0x75, 0x6c, 0x45, 0xa7, 0x38, 0x12, 0xfb, 0xae, 0x38, 0xe9, 0x3f, 0x87, 0xcd,
+	0xbc, 0x92, 0x59, 0xb9, 0xcb, 0x71, 0x9f, 0x2d, 0x77, 0x19, 0xa9, 0x94, 0x89, 0x6e, 0x40, 0x33,
+	0xc0, 0xbf, 0x20, 0x66, 0x4e, 0x3b, 0x50, 0xd2, 0x47, 0xfc, 0x06, 0x0c, 0x5b, 0x7d, 0x9f, 0xf5,
+	0x6c, 0x7c, 0x34, 0x78, 0xf2, 0x24, 0x8c, 0x5e, 0xb6, 0xe0, 0x66, 0xf9, 0x5d, 0x5c, 0x91, 0xdf,
+	0x77, 0xe0, 0xda, 0xc2, 0x35, 0xc2, 0x17, 0x04, 0xe5, 0x49, 0x18, 0xc9, 0x07, 0x61, 0xbf, 0xf5,
+	0xdf, 0x2a, 0xf4, 0xdb, 0x89, 0x5d, 0xf0, 0xb3, 0x24, 0x24, 0xd6, 0x95, 0xbf, 0xd0, 0xb6, 0xa0,
+	0xca, 0xf6, 0xaa, 0x58, 0x2c, 0x4f, 0xe2, 0x84, 0xb4, 0xdc, 0x3c, 0xa6, 0x9c, 0xf4, 0x8c, 0x54,
+	0xa8, 0xc5, 0x24, 0xc2, 0xd6, 0x58, 0x2e, 0x49, 0xf2, 0xa8, 0xbf, 0x0f, 0x1b, 0x39, 0x2b, 0x68,
+	0x2c, 0xd0, 0x9b, 0x50, 0xfd, 0x8c, 0x9d, 0xc4, 0xcb, 0x6f, 0x64, 0x0c, 0xe1, 0x30, 0x43, 0x00,
+	0x76, 0xff, 0x5c, 0x4e, 0xf3, 0x30, 0x5d, 0xe7, 0xbf, 0x03, 0xd0, 0x77, 0x5d, 0x71, 0x44, 0x4b,
+	0xb6, 0x02, 0xad, 0x9b, 0xa3, 0x89, 0xff, 0x07, 0x0a, 0xe8, 0xfb, 0xd0, 0xe6, 0xad, 0xe1, 0x15,
+	0x64, 0x07, 0xd0, 0xca, 0x8e, 0x0d, 0x74, 0x8d, 0x05, 0x69, 0x71, 0x0c, 0x69, 0xea, 0x22, 0x23,
+	0x55, 0x72, 0x1f, 0x9a, 0x1f, 0x60, 0xe2, 0x9c, 0xf0, 0xcf, 0x38, 0xc4, 0xfc, 0xce, 0x7d, 0x69,
+	0x6a, 0x28, 0x4b, 0x4a, 0xe5, 0x1e, 0xc0, 0xda, 0x90, 0x3d, 0x68, 0xfa, 0x95, 0xd1, 0x99, 0x5b,
+	0xfa, 0xb9, 0xd9, 0x73, 0x1f, 0x4e, 0x7a, 0xe1, 0xb6, 0xf2, 0xae, 0x82, 0xee, 0x40, 0x8d, 0xae,
+	0x45, 0x74, 0x1b, 0x97, 0x3b, 0x1b, 0x3d, 0x6b, 0xdd, 0xcc, 0x21, 0x73, 0xd9, 0xb7, 0xa1, 0x9d,
+	0xdb, 0x15, 0x90, 0xfc, 0xc0, 0x58, 0x58, 0x1f, 0x34, 0x36, 0xd7, 0x58, 0xd7, 0x2d, 0xd0, 0xf4,
+	0xea, 0xfb, 0x3e, 0xdb, 0x13, 0x53, 0xb2, 0xb6, 0x26, 0x1f, 0x83, 0x6f, 0x90, 0x7a, 0x01, 0xfd,
+	0x04, 0xba, 0x42, 0x3a, 0x3b, 0xf1, 0xf9, 0x73, 0x2e, 0x59, 0x1c, 0x34, 0x75, 0x91, 0x21, 0x2d,
+	0xdd, 0xfd, 0x67, 0x19, 0x36, 0x44, 0x72, 0x7c, 0x64, 0x05, 0xd6, 0x08, 0x8f, 0x71, 0x40, 0xd0,
+	0x1e, 0xd4, 0xd3, 0x96, 0xd5, 0x15, 0xcf, 0x99, 0xed, 0x63, 0xda, 0x7a, 0x86, 0xc8, 0x54, 0xea,
+	0x05, 0x74, 0x97, 0xe5, 0x94, 0xc8, 0x41, 0xf4, 0x1a, 0x4b, 0xc8, 0xf9, 0x01, 0x9a, 0x73, 0x77,
+	0x0f, 0x5a, 0xd9, 0xc1, 0xc7, 0x1d, 0x58, 0x32, 0x0a, 0x73, 0x42, 0xdf, 0x83, 0xce, 0xdc, 0x6c,
+	0x42, 0x1a, 0x65, 0x2f, 0x1f, 0x58, 0x39, 0xd1, 0x1f, 0x41, 0x33, 0xd3, 0xbc, 0xd1, 0x16, 0xf3,
+	0x61, 0x61, 0xfe, 0x68, 0xd7, 0x16, 0xe8, 0x69, 0x5c, 0xef, 0x41, 0xfb, 0x20, 0x8e, 0x13, 0xfa,
+	0x55, 0xc6, 0x75, 0xcc, 0xc2, 0xb4, 0x42, 0x6a, 0x07, 0x36, 0x3e, 0xc4, 0xe4, 0x48, 0xfc, 0x41,
+	0xc0, 0x3b, 0x73, 0x46, 0xb2, 0x9d, 0x8e, 0x2c, 0xda, 0xd1, 0x67, 0x75, 0x22, 0xfb, 0xed, 0xac,
+	0x4e, 0xe6, 0xda, 0xb8, 0xa6, 0x2e, 0x32, 0xd2, 0x4b, 0x0f, 0xa1, 0x33, 0xd7, 0xeb, 0xf8, 0x3b,
+	0x2d, 0xef, 0xb3, 0xda, 0xf5, 0xa5, 0xbc, 0x54, 0xdb, 0x7b, 0xb0, 0x3e, 0xc4, 0x24, 0xdf, 0x0c,
+	0x17, 0x5b, 0x4e, 0xf6, 0xb5, 0x1f, 0xde, 0x7b, 0xfa, 0xac, 0x57, 0xf8, 0xf2, 0x59, 0xaf, 0xf0,
+	0xd5, 0xb3, 0x9e, 0xf2, 0xeb, 0x8b, 0x9e, 0xf2, 0xd7, 0x8b, 0x9e, 0xf2, 0xc5, 0x45, 0x4f, 0x79,
+	0x7a, 0xd1, 0x53, 0xfe, 0x7d, 0xd1, 0x53, 0xfe, 0x73, 0xd1, 0x2b, 0x7c, 0x75, 0xd1, 0x53, 0xfe,
+	0xf0, 0xbc, 0x57, 0x78, 0xfa, 0xbc, 0x57, 0xf8, 0xf2, 0x79, 0xaf, 0x60, 0x57, 0xd9, 0xbf, 0xad,
+	0x7b, 0xff, 0x1d, 0x00, 0x83, 0xde, 0x22, 0x4a, 0xfe, 0x15, 0x00, 0x00,
 }
 
 func (this *ServiceRequest) Equal(that interface{}) bool {
@@ -3216,6 +3431,119 @@ func (this *ListAccountsResponse) Equal(that interface{}) bool {
 	}
 	return true
 }
+func (this *AllocateTCPPortRequest) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*AllocateTCPPortRequest)
+	if !ok {
+		that2, ok := that.(AllocateTCPPortRequest)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if !this.Account.Equal(that1.Account) {
+		return false
+	}
+	if !this.Target.Equal(that1.Target) {
+		return false
+	}
+	return true
+}
+func (this *AllocateTCPPortResponse) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*AllocateTCPPortResponse)
+	if !ok {
+		that2, ok := that.(AllocateTCPPortResponse)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.Port != that1.Port {
+		return false
+	}
+	return true
+}
+func (this *AccountQuotas) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*AccountQuotas)
+	if !ok {
+		that2, ok := that.(AccountQuotas)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if !this.Account.Equal(that1.Account) {
+		return false
+	}
+	if this.Agents != that1.Agents {
+		return false
+	}
+	if this.Services != that1.Services {
+		return false
+	}
+	if this.Streams != that1.Streams {
+		return false
+	}
+	return true
+}
+func (this *AccountQuotasList) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*AccountQuotasList)
+	if !ok {
+		that2, ok := that.(AccountQuotasList)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if len(this.Quotas) != len(that1.Quotas) {
+		return false
+	}
+	for i := range this.Quotas {
+		if !this.Quotas[i].Equal(that1.Quotas[i]) {
+			return false
+		}
+	}
+	return true
+}
 func (this *ServiceRequest) GoString() string {
 	if this == nil {
 		return "nil"
@@ -3749,33 +4077,85 @@ func (this *ListAccountsResponse) GoString() string {
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
-func valueToGoStringControl(v interface{}, typ string) string {
-	rv := reflect.ValueOf(v)
-	if rv.IsNil() {
+func (this *AllocateTCPPortRequest) GoString() string {
+	if this == nil {
 		return "nil"
 	}
-	pv := reflect.Indirect(rv).Interface()
-	return fmt.Sprintf("func(v %v) *%v { return &v } ( %#v )", typ, typ, pv)
+	s := make([]string, 0, 6)
+	s = append(s, "&pb.AllocateTCPPortRequest{")
+	if this.Account != nil {
+		s = append(s, "Account: "+fmt.Sprintf("%#v", this.Account)+",\n")
+	}
+	if this.Target != nil {
+		s = append(s, "Target: "+fmt.Sprintf("%#v", this.Target)+",\n")
+	}
+	s = append(s, "}")
+	return strings.Join(s, "")
 }
-
-// Reference imports to suppress errors if they are not otherwise used.
-var _ context.Context
-var _ grpc.ClientConn
-
-// This is a compile-time assertion to ensure that this generated file
-// is compatible with the grpc package it is being compiled against.
-const _ = grpc.SupportPackageIsVersion4
-
-// ControlServicesClient is the client API for ControlServices service.
-//
-// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
-type ControlServicesClient interface {
-	AddService(ctx context.Context, in *ServiceRequest, opts ...grpc.CallOption) (*ServiceResponse, error)
-	RemoveService(ctx context.Context, in *ServiceRequest, opts ...grpc.CallOption) (*ServiceResponse, error)
-	ListServices(ctx context.Context, in *ListServicesRequest, opts ...grpc.CallOption) (*ListServicesResponse, error)
-	FetchConfig(ctx context.Context, in *ConfigRequest, opts ...grpc.CallOption) (*ConfigResponse, error)
-	StreamActivity(ctx context.Context, opts ...grpc.CallOption) (ControlServices_StreamActivityClient, error)
-	SyncHub(ctx context.Context, in *HubSync, opts ...grpc.CallOption) (*HubSyncResponse, error)
+func (this *AllocateTCPPortResponse) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 5)
+	s = append(s, "&pb.AllocateTCPPortResponse{")
+	s = append(s, "Port: "+fmt.Sprintf("%#v", this.Port)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *AccountQuotas) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 8)
+	s = append(s, "&pb.AccountQuotas{")
+	if this.Account != nil {
+		s = append(s, "Account: "+fmt.Sprintf("%#v", this.Account)+",\n")
+	}
+	s = append(s, "Agents: "+fmt.Sprintf("%#v", this.Agents)+",\n")
+	s = append(s, "Services: "+fmt.Sprintf("%#v", this.Services)+",\n")
+	s = append(s, "Streams: "+fmt.Sprintf("%#v", this.Streams)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *AccountQuotasList) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 5)
+	s = append(s, "&pb.AccountQuotasList{")
+	if this.Quotas != nil {
+		s = append(s, "Quotas: "+fmt.Sprintf("%#v", this.Quotas)+",\n")
+	}
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func valueToGoStringControl(v interface{}, typ string) string {
+	rv := reflect.ValueOf(v)
+	if rv.IsNil() {
+		return "nil"
+	}
+	pv := reflect.Indirect(rv).Interface()
+	return fmt.Sprintf("func(v %v) *%v { return &v } ( %#v )", typ, typ, pv)
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// ControlServicesClient is the client API for ControlServices service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type ControlServicesClient interface {
+	AddService(ctx context.Context, in *ServiceRequest, opts ...grpc.CallOption) (*ServiceResponse, error)
+	RemoveService(ctx context.Context, in *ServiceRequest, opts ...grpc.CallOption) (*ServiceResponse, error)
+	ListServices(ctx context.Context, in *ListServicesRequest, opts ...grpc.CallOption) (*ListServicesResponse, error)
+	FetchConfig(ctx context.Context, in *ConfigRequest, opts ...grpc.CallOption) (*ConfigResponse, error)
+	StreamActivity(ctx context.Context, opts ...grpc.CallOption) (ControlServices_StreamActivityClient, error)
+	SyncHub(ctx context.Context, in *HubSync, opts ...grpc.CallOption) (*HubSyncResponse, error)
 	HubDisconnect(ctx context.Context, in *HubDisconnectRequest, opts ...grpc.CallOption) (*Noop, error)
 	AllHubs(ctx context.Context, in *Noop, opts ...grpc.CallOption) (*ListOfHubs, error)
 	RequestServiceToken(ctx context.Context, in *ServiceTokenRequest, opts ...grpc.CallOption) (*ServiceTokenResponse, error)
@@ -4171,6 +4551,8 @@ type ControlManagementClient interface {
 	IssueHubToken(ctx context.Context, in *Noop, opts ...grpc.CallOption) (*CreateTokenResponse, error)
 	GetTokenPublicKey(ctx context.Context, in *Noop, opts ...grpc.CallOption) (*TokenInfo, error)
 	ListAccounts(ctx context.Context, in *ListAccountsRequest, opts ...grpc.CallOption) (*ListAccountsResponse, error)
+	AllocateTCPPort(ctx context.Context, in *AllocateTCPPortRequest, opts ...grpc.CallOption) (*AllocateTCPPortResponse, error)
+	SetAccountQuotas(ctx context.Context, in *AccountQuotas, opts ...grpc.CallOption) (*Noop, error)
 }
 
 type controlManagementClient struct {
@@ -4253,6 +4635,24 @@ func (c *controlManagementClient) ListAccounts(ctx context.Context, in *ListAcco
 	return out, nil
 }
 
+func (c *controlManagementClient) AllocateTCPPort(ctx context.Context, in *AllocateTCPPortRequest, opts ...grpc.CallOption) (*AllocateTCPPortResponse, error) {
+	out := new(AllocateTCPPortResponse)
+	err := c.cc.Invoke(ctx, "/pb.ControlManagement/AllocateTCPPort", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlManagementClient) SetAccountQuotas(ctx context.Context, in *AccountQuotas, opts ...grpc.CallOption) (*Noop, error) {
+	out := new(Noop)
+	err := c.cc.Invoke(ctx, "/pb.ControlManagement/SetAccountQuotas", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // ControlManagementServer is the server API for ControlManagement service.
 type ControlManagementServer interface {
 	Register(context.Context, *ControlRegister) (*ControlToken, error)
@@ -4263,6 +4663,8 @@ type ControlManagementServer interface {
 	IssueHubToken(context.Context, *Noop) (*CreateTokenResponse, error)
 	GetTokenPublicKey(context.Context, *Noop) (*TokenInfo, error)
 	ListAccounts(context.Context, *ListAccountsRequest) (*ListAccountsResponse, error)
+	AllocateTCPPort(context.Context, *AllocateTCPPortRequest) (*AllocateTCPPortResponse, error)
+	SetAccountQuotas(context.Context, *AccountQuotas) (*Noop, error)
 }
 
 // UnimplementedControlManagementServer can be embedded to have forward compatible implementations.
@@ -4293,6 +4695,12 @@ func (*UnimplementedControlManagementServer) GetTokenPublicKey(ctx context.Conte
 func (*UnimplementedControlManagementServer) ListAccounts(ctx context.Context, req *ListAccountsRequest) (*ListAccountsResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ListAccounts not implemented")
 }
+func (*UnimplementedControlManagementServer) AllocateTCPPort(ctx context.Context, req *AllocateTCPPortRequest) (*AllocateTCPPortResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AllocateTCPPort not implemented")
+}
+func (*UnimplementedControlManagementServer) SetAccountQuotas(ctx context.Context, req *AccountQuotas) (*Noop, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetAccountQuotas not implemented")
+}
 
 func RegisterControlManagementServer(s *grpc.Server, srv ControlManagementServer) {
 	s.RegisterService(&_ControlManagement_serviceDesc, srv)
@@ -4442,6 +4850,42 @@ func _ControlManagement_ListAccounts_Handler(srv interface{}, ctx context.Contex
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ControlManagement_AllocateTCPPort_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AllocateTCPPortRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControlManagementServer).AllocateTCPPort(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.ControlManagement/AllocateTCPPort",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControlManagementServer).AllocateTCPPort(ctx, req.(*AllocateTCPPortRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ControlManagement_SetAccountQuotas_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AccountQuotas)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControlManagementServer).SetAccountQuotas(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.ControlManagement/SetAccountQuotas",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControlManagementServer).SetAccountQuotas(ctx, req.(*AccountQuotas))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _ControlManagement_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "pb.ControlManagement",
 	HandlerType: (*ControlManagementServer)(nil),
@@ -4478,6 +4922,14 @@ var _ControlManagement_serviceDesc = grpc.ServiceDesc{
 			MethodName: "ListAccounts",
 			Handler:    _ControlManagement_ListAccounts_Handler,
 		},
+		{
+			MethodName: "AllocateTCPPort",
+			Handler:    _ControlManagement_AllocateTCPPort_Handler,
+		},
+		{
+			MethodName: "SetAccountQuotas",
+			Handler:    _ControlManagement_SetAccountQuotas_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "control.proto",
@@ -6227,6 +6679,168 @@ func (m *ListAccountsResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	return len(dAtA) - i, nil
 }
 
+func (m *AllocateTCPPortRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *AllocateTCPPortRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *AllocateTCPPortRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Target != nil {
+		{
+			size, err := m.Target.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintControl(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x12
+	}
+	if m.Account != nil {
+		{
+			size, err := m.Account.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintControl(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *AllocateTCPPortResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *AllocateTCPPortResponse) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *AllocateTCPPortResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Port != 0 {
+		i = encodeVarintControl(dAtA, i, uint64(m.Port))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *AccountQuotas) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *AccountQuotas) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *AccountQuotas) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Streams != 0 {
+		i = encodeVarintControl(dAtA, i, uint64(m.Streams))
+		i--
+		dAtA[i] = 0x20
+	}
+	if m.Services != 0 {
+		i = encodeVarintControl(dAtA, i, uint64(m.Services))
+		i--
+		dAtA[i] = 0x18
+	}
+	if m.Agents != 0 {
+		i = encodeVarintControl(dAtA, i, uint64(m.Agents))
+		i--
+		dAtA[i] = 0x10
+	}
+	if m.Account != nil {
+		{
+			size, err := m.Account.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintControl(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *AccountQuotasList) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *AccountQuotasList) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *AccountQuotasList) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Quotas) > 0 {
+		for iNdEx := len(m.Quotas) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Quotas[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintControl(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0xa
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
 func encodeVarintControl(dAtA []byte, offset int, v uint64) int {
 	offset -= sovControl(v)
 	base := offset
@@ -6925,21 +7539,87 @@ func (m *ListAccountsResponse) Size() (n int) {
 	return n
 }
 
-func sovControl(x uint64) (n int) {
-	return (math_bits.Len64(x|1) + 6) / 7
-}
-func sozControl(x uint64) (n int) {
-	return sovControl(uint64((x << 1) ^ uint64((int64(x) >> 63))))
-}
-func (this *ServiceRequest) String() string {
-	if this == nil {
-		return "nil"
+func (m *AllocateTCPPortRequest) Size() (n int) {
+	if m == nil {
+		return 0
 	}
-	repeatedStringForMetadata := "[]*KVPair{"
-	for _, f := range this.Metadata {
-		repeatedStringForMetadata += strings.Replace(fmt.Sprintf("%v", f), "KVPair", "KVPair", 1) + ","
+	var l int
+	_ = l
+	if m.Account != nil {
+		l = m.Account.Size()
+		n += 1 + l + sovControl(uint64(l))
 	}
-	repeatedStringForMetadata += "}"
+	if m.Target != nil {
+		l = m.Target.Size()
+		n += 1 + l + sovControl(uint64(l))
+	}
+	return n
+}
+
+func (m *AllocateTCPPortResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Port != 0 {
+		n += 1 + sovControl(uint64(m.Port))
+	}
+	return n
+}
+
+func (m *AccountQuotas) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Account != nil {
+		l = m.Account.Size()
+		n += 1 + l + sovControl(uint64(l))
+	}
+	if m.Agents != 0 {
+		n += 1 + sovControl(uint64(m.Agents))
+	}
+	if m.Services != 0 {
+		n += 1 + sovControl(uint64(m.Services))
+	}
+	if m.Streams != 0 {
+		n += 1 + sovControl(uint64(m.Streams))
+	}
+	return n
+}
+
+func (m *AccountQuotasList) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Quotas) > 0 {
+		for _, e := range m.Quotas {
+			l = e.Size()
+			n += 1 + l + sovControl(uint64(l))
+		}
+	}
+	return n
+}
+
+func sovControl(x uint64) (n int) {
+	return (math_bits.Len64(x|1) + 6) / 7
+}
+func sozControl(x uint64) (n int) {
+	return sovControl(uint64((x << 1) ^ uint64((int64(x) >> 63))))
+}
+func (this *ServiceRequest) String() string {
+	if this == nil {
+		return "nil"
+	}
+	repeatedStringForMetadata := "[]*KVPair{"
+	for _, f := range this.Metadata {
+		repeatedStringForMetadata += strings.Replace(fmt.Sprintf("%v", f), "KVPair", "KVPair", 1) + ","
+	}
+	repeatedStringForMetadata += "}"
 	s := strings.Join([]string{`&ServiceRequest{`,
 		`Account:` + strings.Replace(fmt.Sprintf("%v", this.Account), "Account", "Account", 1) + `,`,
 		`Hub:` + strings.Replace(fmt.Sprintf("%v", this.Hub), "ULID", "ULID", 1) + `,`,
@@ -7415,6 +8095,55 @@ func (this *ListAccountsResponse) String() string {
 	}, "")
 	return s
 }
+func (this *AllocateTCPPortRequest) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&AllocateTCPPortRequest{`,
+		`Account:` + strings.Replace(fmt.Sprintf("%v", this.Account), "Account", "Account", 1) + `,`,
+		`Target:` + strings.Replace(fmt.Sprintf("%v", this.Target), "LabelSet", "LabelSet", 1) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *AllocateTCPPortResponse) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&AllocateTCPPortResponse{`,
+		`Port:` + fmt.Sprintf("%v", this.Port) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *AccountQuotas) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&AccountQuotas{`,
+		`Account:` + strings.Replace(fmt.Sprintf("%v", this.Account), "Account", "Account", 1) + `,`,
+		`Agents:` + fmt.Sprintf("%v", this.Agents) + `,`,
+		`Services:` + fmt.Sprintf("%v", this.Services) + `,`,
+		`Streams:` + fmt.Sprintf("%v", this.Streams) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *AccountQuotasList) String() string {
+	if this == nil {
+		return "nil"
+	}
+	repeatedStringForQuotas := "[]*AccountQuotas{"
+	for _, f := range this.Quotas {
+		repeatedStringForQuotas += strings.Replace(f.String(), "AccountQuotas", "AccountQuotas", 1) + ","
+	}
+	repeatedStringForQuotas += "}"
+	s := strings.Join([]string{`&AccountQuotasList{`,
+		`Quotas:` + repeatedStringForQuotas + `,`,
+		`}`,
+	}, "")
+	return s
+}
 func valueToStringControl(v interface{}) string {
 	rv := reflect.ValueOf(v)
 	if rv.IsNil() {
@@ -12183,6 +12912,436 @@ func (m *ListAccountsResponse) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
+func (m *AllocateTCPPortRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowControl
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: AllocateTCPPortRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: AllocateTCPPortRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Account", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowControl
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthControl
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthControl
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Account == nil {
+				m.Account = &Account{}
+			}
+			if err := m.Account.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Target", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowControl
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthControl
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthControl
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Target == nil {
+				m.Target = &LabelSet{}
+			}
+			if err := m.Target.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipControl(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthControl
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthControl
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *AllocateTCPPortResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowControl
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: AllocateTCPPortResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: AllocateTCPPortResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Port", wireType)
+			}
+			m.Port = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowControl
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Port |= int32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipControl(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthControl
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthControl
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *AccountQuotas) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowControl
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: AccountQuotas: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: AccountQuotas: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Account", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowControl
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthControl
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthControl
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Account == nil {
+				m.Account = &Account{}
+			}
+			if err := m.Account.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Agents", wireType)
+			}
+			m.Agents = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowControl
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Agents |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Services", wireType)
+			}
+			m.Services = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowControl
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Services |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Streams", wireType)
+			}
+			m.Streams = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowControl
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Streams |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipControl(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthControl
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthControl
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *AccountQuotasList) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowControl
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: AccountQuotasList: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: AccountQuotasList: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Quotas", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowControl
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthControl
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthControl
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Quotas = append(m.Quotas, &AccountQuotas{})
+			if err := m.Quotas[len(m.Quotas)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipControl(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthControl
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthControl
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
 func skipControl(dAtA []byte) (n int, err error) {
 	l := len(dAtA)
 	iNdEx := 0
@@ -416,6 +416,7 @@ type ConnectRequest struct {
 	PivotAccount *Account  `protobuf:"bytes,3,opt,name=pivot_account,json=pivotAccount,proto3" json:"pivot_account,omitempty"`
 	ProtocolId   string    `protobuf:"bytes,4,opt,name=protocol_id,json=protocolId,proto3" json:"protocol_id,omitempty"`
 	SourceAddr   []byte    `protobuf:"bytes,5,opt,name=source_addr,json=sourceAddr,proto3" json:"source_addr,omitempty"`
+	ServiceId    *ULID     `protobuf:"bytes,6,opt,name=service_id,json=serviceId,proto3" json:"service_id,omitempty"`
 }
 
 func (m *ConnectRequest) Reset()      { *m = ConnectRequest{} }
@@ -485,6 +486,13 @@ func (m *ConnectRequest) GetSourceAddr() []byte {
 	return nil
 }
 
+func (m *ConnectRequest) GetServiceId() *ULID {
+	if m != nil {
+		return m.ServiceId
+	}
+	return nil
+}
+
 type ConnectAck struct {
 	ServiceId *ULID `protobuf:"bytes,1,opt,name=service_id,json=serviceId,proto3" json:"service_id,omitempty"`
 }
@@ -787,60 +795,60 @@ func init() {
 func init() { proto.RegisterFile("wire.proto", fileDescriptor_f2dcdddcdf68d8e0) }
 
 var fileDescriptor_f2dcdddcdf68d8e0 = []byte{
-	// 836 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x53, 0xcd, 0x6e, 0x1b, 0x37,
-	0x10, 0xd6, 0x5a, 0x6b, 0x69, 0x35, 0x92, 0x1c, 0x95, 0x68, 0x83, 0x85, 0xd1, 0x6e, 0xd5, 0x45,
-	0xda, 0x1a, 0x28, 0x60, 0x14, 0xee, 0xcf, 0x5d, 0x51, 0x8c, 0x46, 0x48, 0xea, 0x08, 0xb4, 0xd2,
-	0x02, 0xbd, 0x08, 0xd4, 0x2e, 0x6d, 0x2d, 0xac, 0x5d, 0x6e, 0x48, 0xae, 0x03, 0xdf, 0xfa, 0x08,
-	0x3d, 0xf6, 0x09, 0x8a, 0x3e, 0x45, 0xcf, 0x39, 0xfa, 0x98, 0x63, 0x2d, 0x5f, 0x7a, 0xcc, 0x23,
-	0x14, 0x43, 0x72, 0x6d, 0x43, 0x69, 0x91, 0xdc, 0xe6, 0x9b, 0x21, 0x87, 0xdf, 0xcc, 0xf7, 0x11,
-	0xe0, 0x65, 0x26, 0xf9, 0x7e, 0x29, 0x85, 0x16, 0x64, 0xab, 0x5c, 0xec, 0xde, 0xd3, 0x59, 0xce,
-	0x95, 0x66, 0x79, 0x69, 0x93, 0xbb, 0xc1, 0xd9, 0xb9, 0x8b, 0xa0, 0x5a, 0x65, 0xa9, 0x8b, 0xfb,
-	0x2c, 0x49, 0x44, 0x55, 0x68, 0x07, 0xbb, 0x2b, 0xb6, 0xe0, 0x2b, 0x0b, 0xe2, 0x08, 0x5a, 0x4f,
-	0x11, 0x2a, 0xf2, 0x21, 0x6c, 0x9b, 0x42, 0xe8, 0x0d, 0x9b, 0x7b, 0x1d, 0x6a, 0x41, 0xfc, 0xbb,
-	0x07, 0xdd, 0x63, 0x2e, 0xcf, 0xb3, 0x84, 0x4f, 0x8a, 0x13, 0x41, 0xbe, 0x04, 0x50, 0x16, 0xce,
-	0xb3, 0x34, 0xf4, 0x86, 0xde, 0x5e, 0xf7, 0x20, 0xd8, 0x2f, 0x17, 0xfb, 0xcf, 0x9f, 0x4e, 0x1e,
-	0xd1, 0x8e, 0xab, 0x4d, 0x52, 0x42, 0xc0, 0xd7, 0x17, 0x25, 0x0f, 0xb7, 0x86, 0xde, 0x5e, 0x87,
-	0x9a, 0x98, 0x3c, 0x80, 0x96, 0xe9, 0xaa, 0xc2, 0xa6, 0xb9, 0xd8, 0xc3, 0x8b, 0xe6, 0xf9, 0x63,
-	0xae, 0xa9, 0xab, 0x91, 0x2f, 0x20, 0xc8, 0xb9, 0x66, 0x29, 0xd3, 0x2c, 0xf4, 0x87, 0xcd, 0xbd,
-	0xee, 0x01, 0xe0, 0xb9, 0x27, 0x3f, 0x4d, 0x59, 0x26, 0xe9, 0x4d, 0x2d, 0xfe, 0xc3, 0x83, 0x60,
-	0x2a, 0x39, 0xcb, 0x17, 0x2b, 0x4e, 0x3e, 0x41, 0x5e, 0x4a, 0x65, 0xa2, 0xa8, 0x79, 0x75, 0x90,
-	0x8d, 0xc9, 0x4c, 0x52, 0x1c, 0x4e, 0x8b, 0x33, 0x5e, 0x38, 0x3a, 0x16, 0x90, 0xfb, 0x77, 0xf8,
-	0xe0, 0xcc, 0x35, 0x83, 0xaf, 0x20, 0x70, 0x83, 0x28, 0xc7, 0xe0, 0x1e, 0x32, 0xb8, 0xb3, 0x07,
-	0x7a, 0x73, 0x80, 0x0c, 0xa1, 0x9b, 0x88, 0xbc, 0x94, 0xf6, 0xad, 0x70, 0xdb, 0x3c, 0x70, 0x37,
-	0x15, 0x9f, 0x41, 0x6f, 0x2c, 0x8a, 0x93, 0x4c, 0xe6, 0x4c, 0x67, 0xa2, 0x20, 0x9f, 0x81, 0x8f,
-	0xc2, 0xb9, 0xed, 0xf5, 0xb1, 0xf5, 0xac, 0x16, 0x92, 0x9a, 0x12, 0x32, 0x53, 0x9a, 0xe9, 0x4a,
-	0x39, 0xc2, 0x0e, 0x6d, 0x3e, 0xd6, 0x7c, 0xfb, 0xb1, 0x03, 0x68, 0x3d, 0xe6, 0x2c, 0xe5, 0x12,
-	0x15, 0x28, 0x98, 0x7b, 0xa6, 0x43, 0x4d, 0x8c, 0x7b, 0x38, 0x67, 0xab, 0x0a, 0x65, 0x31, 0x22,
-	0x1b, 0x10, 0x7f, 0x0f, 0xfe, 0xa8, 0xd2, 0x4b, 0xbc, 0x51, 0x29, 0x2e, 0xeb, 0x1b, 0x18, 0x93,
-	0x5d, 0x08, 0x4a, 0xa6, 0xd4, 0x4b, 0x21, 0x53, 0xc7, 0xe5, 0x06, 0xc7, 0x7f, 0x79, 0xb0, 0x33,
-	0x16, 0x45, 0xc1, 0x13, 0x4d, 0xf9, 0x8b, 0x8a, 0x2b, 0x8d, 0x12, 0x6b, 0x26, 0x4f, 0xb9, 0x76,
-	0xd3, 0x6d, 0x48, 0x6c, 0x6b, 0xff, 0x69, 0x8e, 0xaf, 0xa1, 0x5f, 0x66, 0xe7, 0x42, 0xcf, 0x9d,
-	0x5b, 0x9d, 0x47, 0xba, 0xd8, 0x60, 0x64, 0x53, 0xb4, 0x67, 0x4e, 0x38, 0x44, 0x3e, 0x85, 0xae,
-	0x31, 0x71, 0x22, 0x56, 0x28, 0xba, 0x6f, 0x9a, 0x41, 0x9d, 0x9a, 0xa4, 0x78, 0x40, 0x89, 0x4a,
-	0x26, 0x7c, 0xce, 0xd2, 0x54, 0x1a, 0x69, 0x7a, 0x14, 0x6c, 0x6a, 0x94, 0xa6, 0x32, 0xfe, 0x0e,
-	0xc0, 0xf1, 0x1f, 0x25, 0x67, 0xef, 0xed, 0xed, 0x98, 0xc1, 0x47, 0xc7, 0xb5, 0xb5, 0x78, 0xa1,
-	0xb3, 0x93, 0x2c, 0xb1, 0xca, 0xbe, 0xf7, 0xef, 0xd8, 0xa0, 0xbe, 0xb5, 0x49, 0x3d, 0x7e, 0xd5,
-	0x84, 0xf6, 0xed, 0x4e, 0xed, 0xb6, 0xb0, 0xdf, 0xce, 0xc1, 0x00, 0xfb, 0xb9, 0xd2, 0xfe, 0xec,
-	0xa2, 0xe4, 0x6e, 0x7f, 0xf7, 0xa1, 0x95, 0x73, 0xbd, 0x14, 0x75, 0x37, 0x87, 0x70, 0xd7, 0x25,
-	0xd3, 0x4b, 0xe7, 0x15, 0x13, 0xa3, 0x0d, 0x5e, 0x54, 0x5c, 0x5e, 0xb8, 0x9d, 0x59, 0x80, 0x52,
-	0x9f, 0x48, 0x76, 0x9a, 0xf3, 0x42, 0x3b, 0x1b, 0xdf, 0x60, 0xf2, 0x31, 0xf8, 0xac, 0xd2, 0xcb,
-	0xb0, 0x75, 0x3b, 0x13, 0x5a, 0x86, 0x9a, 0x2c, 0x79, 0x00, 0xed, 0xa5, 0x31, 0x9d, 0x0a, 0xdb,
-	0xb7, 0x3f, 0xd6, 0xfa, 0x90, 0xd6, 0x25, 0x1c, 0x5a, 0xf2, 0x5c, 0x68, 0x27, 0x47, 0x60, 0x87,
-	0xb6, 0x29, 0x94, 0x03, 0xa9, 0x2e, 0x85, 0xd2, 0x61, 0xc7, 0x52, 0xc5, 0x98, 0x84, 0xd0, 0x66,
-	0xa7, 0xbc, 0xd0, 0x93, 0x34, 0x04, 0xa3, 0x5f, 0x0d, 0xc9, 0xe7, 0xb0, 0x63, 0xed, 0x34, 0x77,
-	0x7b, 0x0d, 0xbb, 0xe6, 0x5e, 0xdf, 0x66, 0xdd, 0x6f, 0x7d, 0xdb, 0x57, 0xbd, 0x77, 0xf8, 0x2a,
-	0xfe, 0x11, 0x7c, 0xdc, 0x2b, 0x09, 0xc0, 0x7f, 0x3c, 0x9b, 0x4d, 0x07, 0x0d, 0xd2, 0x87, 0xce,
-	0xcf, 0x87, 0x0f, 0x8f, 0x9f, 0x8d, 0x9f, 0x1c, 0xce, 0x06, 0x1e, 0x69, 0x43, 0x73, 0x36, 0x9e,
-	0x0e, 0xb6, 0x30, 0x78, 0xfe, 0x68, 0x3a, 0x68, 0x62, 0x40, 0xa7, 0xe3, 0x81, 0x4f, 0x3e, 0x80,
-	0xfe, 0xe8, 0x87, 0xc3, 0xa3, 0xd9, 0x7c, 0xfc, 0xec, 0xe8, 0xe8, 0x70, 0x3c, 0x1b, 0x6c, 0xc7,
-	0xbf, 0x40, 0x40, 0xb9, 0x2a, 0x45, 0xa1, 0xcc, 0xff, 0xe3, 0x52, 0x8a, 0xfa, 0x8b, 0x59, 0x80,
-	0x73, 0x27, 0x22, 0xb5, 0xdf, 0x61, 0x9b, 0x9a, 0xf8, 0xee, 0x4a, 0x9b, 0xff, 0xbb, 0xd2, 0x87,
-	0xdf, 0x5e, 0x5e, 0x45, 0x8d, 0xd7, 0x57, 0x51, 0xe3, 0xcd, 0x55, 0xe4, 0xfd, 0xba, 0x8e, 0xbc,
-	0x3f, 0xd7, 0x91, 0xf7, 0x6a, 0x1d, 0x79, 0x97, 0xeb, 0xc8, 0xfb, 0x7b, 0x1d, 0x79, 0xff, 0xac,
-	0xa3, 0xc6, 0x9b, 0x75, 0xe4, 0xfd, 0x76, 0x1d, 0x35, 0x2e, 0xaf, 0xa3, 0xc6, 0xeb, 0xeb, 0xa8,
-	0xb1, 0x68, 0x19, 0xa3, 0x7d, 0xf3, 0x6f, 0x00, 0x00, 0x00, 0xff, 0xff, 0x19, 0xca, 0xf1, 0xa9,
-	0x50, 0x06, 0x00, 0x00,
+	// 835 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x53, 0xcd, 0x6e, 0xdb, 0x46,
+	0x10, 0xd6, 0x5a, 0xb4, 0x44, 0x0d, 0x25, 0x47, 0x5d, 0xb4, 0x01, 0x61, 0xb4, 0xac, 0x4a, 0xa4,
+	0xad, 0x81, 0x02, 0x46, 0xe1, 0xfe, 0xdc, 0x15, 0xc5, 0x68, 0x84, 0xa4, 0x8e, 0xb0, 0x66, 0x5a,
+	0xa0, 0x17, 0x61, 0x45, 0xae, 0x2d, 0xc2, 0x22, 0x97, 0xd9, 0x5d, 0x3a, 0xf0, 0xad, 0x8f, 0xd0,
+	0x63, 0x9f, 0xa0, 0xe8, 0xa3, 0xe4, 0xe8, 0x63, 0x8e, 0xb5, 0x7c, 0x68, 0x8f, 0x79, 0x84, 0x62,
+	0x7f, 0xe8, 0x08, 0x4e, 0x82, 0xe4, 0x36, 0xdf, 0xcc, 0xee, 0xfc, 0x7d, 0xdf, 0x00, 0x3c, 0xcf,
+	0x05, 0xdb, 0xaf, 0x04, 0x57, 0x1c, 0x6f, 0x55, 0x8b, 0xdd, 0x3b, 0x2a, 0x2f, 0x98, 0x54, 0xb4,
+	0xa8, 0xac, 0x73, 0xd7, 0x3f, 0x3b, 0x77, 0x16, 0xd4, 0xab, 0x3c, 0x73, 0xf6, 0x80, 0xa6, 0x29,
+	0xaf, 0x4b, 0xe5, 0x60, 0xb0, 0xa2, 0x0b, 0xb6, 0xb2, 0x20, 0x8e, 0xa0, 0xf3, 0x58, 0x43, 0x89,
+	0x3f, 0x86, 0x6d, 0x13, 0x08, 0xd1, 0xa8, 0xbd, 0xd7, 0x23, 0x16, 0xc4, 0x7f, 0x22, 0x08, 0x8e,
+	0x99, 0x38, 0xcf, 0x53, 0x36, 0x2d, 0x4f, 0x38, 0xfe, 0x1a, 0x40, 0x5a, 0x38, 0xcf, 0xb3, 0x10,
+	0x8d, 0xd0, 0x5e, 0x70, 0xe0, 0xef, 0x57, 0x8b, 0xfd, 0xa7, 0x8f, 0xa7, 0x0f, 0x48, 0xcf, 0xc5,
+	0xa6, 0x19, 0xc6, 0xe0, 0xa9, 0x8b, 0x8a, 0x85, 0x5b, 0x23, 0xb4, 0xd7, 0x23, 0xc6, 0xc6, 0xf7,
+	0xa0, 0x63, 0xb2, 0xca, 0xb0, 0x6d, 0x3e, 0xf6, 0xf5, 0x47, 0x53, 0xfe, 0x98, 0x29, 0xe2, 0x62,
+	0xf8, 0x2b, 0xf0, 0x0b, 0xa6, 0x68, 0x46, 0x15, 0x0d, 0xbd, 0x51, 0x7b, 0x2f, 0x38, 0x00, 0xfd,
+	0xee, 0xd1, 0x2f, 0x33, 0x9a, 0x0b, 0x72, 0x13, 0x8b, 0xff, 0x42, 0xe0, 0xcf, 0x04, 0xa3, 0xc5,
+	0x62, 0xc5, 0xf0, 0x67, 0xba, 0x2f, 0x29, 0x73, 0x5e, 0x36, 0x7d, 0xf5, 0x48, 0xcf, 0x79, 0xa6,
+	0x99, 0x1e, 0x4e, 0xf1, 0x33, 0x56, 0xba, 0x76, 0x2c, 0xc0, 0x77, 0x37, 0xfa, 0xd1, 0x33, 0x37,
+	0x1d, 0x7c, 0x03, 0xbe, 0x1b, 0x44, 0xba, 0x0e, 0xee, 0xe8, 0x0e, 0x36, 0xf6, 0x40, 0x6e, 0x1e,
+	0xe0, 0x11, 0x04, 0x29, 0x2f, 0x2a, 0x61, 0x6b, 0x85, 0xdb, 0xa6, 0xc0, 0xa6, 0x2b, 0x3e, 0x83,
+	0xfe, 0x84, 0x97, 0x27, 0xb9, 0x28, 0xa8, 0xca, 0x79, 0x89, 0xbf, 0x00, 0x4f, 0x13, 0xe7, 0xb6,
+	0x37, 0xd0, 0xa9, 0x93, 0x86, 0x48, 0x62, 0x42, 0xba, 0x33, 0xa9, 0xa8, 0xaa, 0xa5, 0x6b, 0xd8,
+	0xa1, 0xdb, 0xc5, 0xda, 0x6f, 0x16, 0x3b, 0x80, 0xce, 0x43, 0x46, 0x33, 0x26, 0x34, 0x03, 0x25,
+	0x75, 0x65, 0x7a, 0xc4, 0xd8, 0x7a, 0x0f, 0xe7, 0x74, 0x55, 0x6b, 0x5a, 0x0c, 0xc9, 0x06, 0xc4,
+	0x3f, 0x82, 0x37, 0xae, 0xd5, 0x52, 0xff, 0xa8, 0x25, 0x13, 0xcd, 0x0f, 0x6d, 0xe3, 0x5d, 0xf0,
+	0x2b, 0x2a, 0xe5, 0x73, 0x2e, 0x32, 0xd7, 0xcb, 0x0d, 0x8e, 0xff, 0x45, 0xb0, 0x33, 0xe1, 0x65,
+	0xc9, 0x52, 0x45, 0xd8, 0xb3, 0x9a, 0x49, 0xa5, 0x29, 0x56, 0x54, 0x9c, 0x32, 0x15, 0xa2, 0xb7,
+	0x51, 0x6c, 0x63, 0x6f, 0x15, 0xc7, 0xb7, 0x30, 0xa8, 0xf2, 0x73, 0xae, 0xe6, 0x4e, 0xad, 0x4e,
+	0x23, 0x81, 0x4e, 0x30, 0xb6, 0x2e, 0xd2, 0x37, 0x2f, 0x1c, 0xc2, 0x9f, 0x43, 0x60, 0x44, 0x9c,
+	0xf2, 0x95, 0x26, 0xdd, 0x33, 0xc9, 0xa0, 0x71, 0x4d, 0x33, 0xfd, 0x40, 0xf2, 0x5a, 0xa4, 0x6c,
+	0x4e, 0xb3, 0x4c, 0x18, 0x6a, 0xfa, 0x04, 0xac, 0x6b, 0x9c, 0x65, 0xe2, 0x96, 0x9a, 0x3b, 0xef,
+	0x54, 0x73, 0xfc, 0x03, 0x80, 0x1b, 0x74, 0x9c, 0x9e, 0x7d, 0xf0, 0x11, 0xc4, 0x14, 0x3e, 0x39,
+	0x6e, 0x34, 0xc8, 0x4a, 0x95, 0x9f, 0xe4, 0xa9, 0x95, 0xc0, 0x07, 0x9f, 0xd1, 0xad, 0x19, 0xb7,
+	0x6e, 0xcf, 0x18, 0xbf, 0x68, 0x43, 0xf7, 0xf5, 0xf2, 0xed, 0x5a, 0x75, 0xbe, 0x9d, 0x83, 0xa1,
+	0xce, 0xe7, 0x42, 0xfb, 0xc9, 0x45, 0xc5, 0xdc, 0xa2, 0xef, 0x42, 0xa7, 0x60, 0x6a, 0xc9, 0x9b,
+	0x6c, 0x0e, 0x69, 0x52, 0x2a, 0xaa, 0x96, 0x4e, 0x54, 0xc6, 0xd6, 0x7a, 0x79, 0x56, 0x33, 0x71,
+	0xe1, 0x96, 0x6b, 0x81, 0xd6, 0xc4, 0x89, 0xa0, 0xa7, 0x05, 0x2b, 0x95, 0xd3, 0xfb, 0x0d, 0xc6,
+	0x9f, 0x82, 0x47, 0x6b, 0xb5, 0xdc, 0x5c, 0xa6, 0xd6, 0x16, 0x31, 0x5e, 0x7c, 0x0f, 0xba, 0x4b,
+	0xa3, 0x4e, 0x19, 0x76, 0x5f, 0x9f, 0xb6, 0x15, 0x2c, 0x69, 0x42, 0x7a, 0x68, 0xc1, 0x0a, 0xae,
+	0x1c, 0x6f, 0xbe, 0x1d, 0xda, 0xba, 0x0c, 0x6f, 0x18, 0xbc, 0x25, 0x97, 0x2a, 0xec, 0xd9, 0x56,
+	0xb5, 0x8d, 0x43, 0xe8, 0xd2, 0x53, 0x56, 0xaa, 0x69, 0x16, 0x82, 0x21, 0xba, 0x81, 0xf8, 0x4b,
+	0xd8, 0xb1, 0xba, 0x9b, 0xbb, 0xbd, 0x86, 0x81, 0xf9, 0x37, 0xb0, 0x5e, 0x77, 0xd6, 0x6f, 0x0a,
+	0xb0, 0xff, 0x1e, 0x01, 0xc6, 0x3f, 0x83, 0xa7, 0xf7, 0x8a, 0x7d, 0xf0, 0x1e, 0x26, 0xc9, 0x6c,
+	0xd8, 0xc2, 0x03, 0xe8, 0xfd, 0x7a, 0x78, 0xff, 0xf8, 0xc9, 0xe4, 0xd1, 0x61, 0x32, 0x44, 0xb8,
+	0x0b, 0xed, 0x64, 0x32, 0x1b, 0x6e, 0x69, 0xe3, 0xe9, 0x83, 0xd9, 0xb0, 0xad, 0x0d, 0x32, 0x9b,
+	0x0c, 0x3d, 0xfc, 0x11, 0x0c, 0xc6, 0x3f, 0x1d, 0x1e, 0x25, 0xf3, 0xc9, 0x93, 0xa3, 0xa3, 0xc3,
+	0x49, 0x32, 0xdc, 0x8e, 0x7f, 0x03, 0x9f, 0x30, 0x59, 0xf1, 0x52, 0x9a, 0x43, 0x65, 0x42, 0xf0,
+	0xe6, 0x16, 0x2d, 0xd0, 0x73, 0xa7, 0x3c, 0xb3, 0x77, 0xb3, 0x4d, 0x8c, 0xbd, 0xb9, 0xd2, 0xf6,
+	0x3b, 0x57, 0x7a, 0xff, 0xfb, 0xcb, 0xab, 0xa8, 0xf5, 0xf2, 0x2a, 0x6a, 0xbd, 0xba, 0x8a, 0xd0,
+	0xef, 0xeb, 0x08, 0xfd, 0xbd, 0x8e, 0xd0, 0x8b, 0x75, 0x84, 0x2e, 0xd7, 0x11, 0xfa, 0x67, 0x1d,
+	0xa1, 0xff, 0xd6, 0x51, 0xeb, 0xd5, 0x3a, 0x42, 0x7f, 0x5c, 0x47, 0xad, 0xcb, 0xeb, 0xa8, 0xf5,
+	0xf2, 0x3a, 0x6a, 0x2d, 0x3a, 0x46, 0x68, 0xdf, 0xfd, 0x3f, 0x00, 0xed, 0xa0, 0x39, 0x4a, 0x79,
+	0x06, 0x00, 0x00,
 }
 
 func (x Request_Type) String() string {
@@ -1086,6 +1094,9 @@ func (this *ConnectRequest) Equal(that interface{}) bool {
 	if !bytes.Equal(this.SourceAddr, that1.SourceAddr) {
 		return false
 	}
+	if !this.ServiceId.Equal(that1.ServiceId) {
+		return false
+	}
 	return true
 }
 func (this *ConnectAck) Equal(that interface{}) bool {
@@ -1321,7 +1332,7 @@ func (this *ConnectRequest) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 9)
+	s := make([]string, 0, 10)
 	s = append(s, "&pb.ConnectRequest{")
 	if this.Target != nil {
 		s = append(s, "Target: "+fmt.Sprintf("%#v", this.Target)+",\n")
@@ -1332,6 +1343,9 @@ func (this *ConnectRequest) GoString() string {
 	}
 	s = append(s, "ProtocolId: "+fmt.Sprintf("%#v", this.ProtocolId)+",\n")
 	s = append(s, "SourceAddr: "+fmt.Sprintf("%#v", this.SourceAddr)+",\n")
+	if this.ServiceId != nil {
+		s = append(s, "ServiceId: "+fmt.Sprintf("%#v", this.ServiceId)+",\n")
+	}
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -1721,6 +1735,18 @@ func (m *ConnectRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if m.ServiceId != nil {
+		{
+			size, err := m.ServiceId.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintWire(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x32
+	}
 	if len(m.SourceAddr) > 0 {
 		i -= len(m.SourceAddr)
 		copy(dAtA[i:], m.SourceAddr)
@@ -2186,6 +2212,10 @@ func (m *ConnectRequest) Size() (n int) {
 	if l > 0 {
 		n += 1 + l + sovWire(uint64(l))
 	}
+	if m.ServiceId != nil {
+		l = m.ServiceId.Size()
+		n += 1 + l + sovWire(uint64(l))
+	}
 	return n
 }
 
@@ -2396,6 +2426,7 @@ func (this *ConnectRequest) String() string {
 		`PivotAccount:` + strings.Replace(fmt.Sprintf("%v", this.PivotAccount), "Account", "Account", 1) + `,`,
 		`ProtocolId:` + fmt.Sprintf("%v", this.ProtocolId) + `,`,
 		`SourceAddr:` + fmt.Sprintf("%v", this.SourceAddr) + `,`,
+		`ServiceId:` + strings.Replace(fmt.Sprintf("%v", this.ServiceId), "ULID", "ULID", 1) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -3549,6 +3580,42 @@ func (m *ConnectRequest) Unmarshal(dAtA []byte) error {
 				m.SourceAddr = []byte{}
 			}
 			iNdEx = postIndex
+		case 6:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ServiceId", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowWire
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthWire
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthWire
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.ServiceId == nil {
+				m.ServiceId = &ULID{}
+			}
+			if err := m.ServiceId.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipWire(dAtA[iNdEx:])
@@ -0,0 +1,59 @@
+package transfer
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var ErrInvalidCode = errors.New("invalid transfer code")
+
+// The number of digit groups in a code. The first group is the nameplate,
+// which is used to find the other side, and the whole code is the password
+// the key is derived from. The nameplate is published in the service labels,
+// so the secret part of the code is the other 12 digits.
+const codeGroups = 4
+
+// GenerateCode returns a new random code, such as 4817-0392-7715-2264.
+func GenerateCode() (string, error) {
+	var groups []string
+
+	max := big.NewInt(10000)
+
+	for i := 0; i < codeGroups; i++ {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+
+		groups = append(groups, fmt.Sprintf("%04d", n.Int64()))
+	}
+
+	return strings.Join(groups, "-"), nil
+}
+
+// ParseCode validates code and returns it in canonical form along with it's
+// nameplate.
+func ParseCode(code string) (string, string, error) {
+	groups := strings.Split(strings.TrimSpace(code), "-")
+	if len(groups) != codeGroups {
+		return "", "", ErrInvalidCode
+	}
+
+	for _, g := range groups {
+		if len(g) != 4 {
+			return "", "", ErrInvalidCode
+		}
+
+		for _, c := range g {
+			if c < '0' || c > '9' {
+				return "", "", ErrInvalidCode
+			}
+		}
+	}
+
+	return strings.Join(groups, "-"), groups[0], nil
+}
@@ -0,0 +1,219 @@
+package transfer
+
+import (
+	"crypto/cipher"
+	"crypto/hmac"
+	"encoding/binary"
+	"io"
+
+	"github.com/hashicorp/horizon/pkg/wire"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+const (
+	tagExchange byte = 1
+	tagConfirm  byte = 2
+
+	// Encrypted records
+	tagManifest byte = 10
+	tagAccept   byte = 11
+	tagRefuse   byte = 12
+	tagData     byte = 13
+	tagEndFile  byte = 14
+	tagDone     byte = 15
+)
+
+// The largest amount of file data in a single record.
+const chunkSize = 32 * 1024
+
+// The largest frame we'll accept, which leaves room for a manifest with many
+// files.
+const maxFrameSize = 1024 * 1024
+
+var (
+	ErrBadCode       = errors.New("transfer codes did not match")
+	ErrFrameTooLarge = errors.New("frame too large")
+)
+
+// conn exchanges records encrypted with a key derived from the code. Each
+// record uses the next nonce in sequence and authenticates it's tag, so
+// records can't be altered, reordered, or dropped without detection.
+type conn struct {
+	fr *wire.FramingReader
+	fw *wire.FramingWriter
+
+	send, recv       cipher.AEAD
+	sendSeq, recvSeq uint64
+}
+
+func newConn(rw io.ReadWriter) (*conn, error) {
+	fr, err := wire.NewFramingReader(rw)
+	if err != nil {
+		return nil, err
+	}
+
+	fw, err := wire.NewFramingWriter(rw)
+	if err != nil {
+		return nil, err
+	}
+
+	return &conn{fr: fr, fw: fw}, nil
+}
+
+func (c *conn) writeFrame(tag byte, data []byte) error {
+	err := c.fw.WriteFrame(tag, len(data))
+	if err != nil || len(data) == 0 {
+		return err
+	}
+
+	_, err = c.fw.Write(data)
+	return err
+}
+
+func (c *conn) readFrame() (byte, []byte, error) {
+	tag, sz, err := c.fr.Next()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if sz > maxFrameSize {
+		return 0, nil, ErrFrameTooLarge
+	}
+
+	buf := make([]byte, sz)
+
+	_, err = io.ReadFull(c.fr, buf)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return tag, buf, nil
+}
+
+// Performs the key exchange and confirms both sides used the same code.
+func (c *conn) handshake(code string, sender bool) error {
+	s, err := newSPAKE(code, sender)
+	if err != nil {
+		return err
+	}
+
+	// The sender speaks first in each round so neither side has to rely on
+	// the stream buffering a write before the other side reads.
+	var peer []byte
+
+	if sender {
+		err = c.writeFrame(tagExchange, s.msg)
+		if err == nil {
+			peer, err = c.expect(tagExchange)
+		}
+	} else {
+		peer, err = c.expect(tagExchange)
+		if err == nil {
+			err = c.writeFrame(tagExchange, s.msg)
+		}
+	}
+
+	if err != nil {
+		return err
+	}
+
+	keys, err := s.finish(peer)
+	if err != nil {
+		return err
+	}
+
+	mine, theirs := keys.senderConfirm, keys.receiverConfirm
+	if !sender {
+		mine, theirs = theirs, mine
+	}
+
+	var confirm []byte
+
+	if sender {
+		err = c.writeFrame(tagConfirm, confirmation(mine))
+		if err == nil {
+			confirm, err = c.expect(tagConfirm)
+
+			// The receiver hangs up rather than confirming when the codes
+			// don't match.
+			if err == io.EOF {
+				return ErrBadCode
+			}
+		}
+	} else {
+		confirm, err = c.expect(tagConfirm)
+		if err == nil && !hmac.Equal(confirm, confirmation(theirs)) {
+			return ErrBadCode
+		}
+
+		if err == nil {
+			err = c.writeFrame(tagConfirm, confirmation(mine))
+		}
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if !hmac.Equal(confirm, confirmation(theirs)) {
+		return ErrBadCode
+	}
+
+	sendKey, recvKey := keys.senderKey, keys.receiverKey
+	if !sender {
+		sendKey, recvKey = recvKey, sendKey
+	}
+
+	c.send, err = chacha20poly1305.New(sendKey)
+	if err != nil {
+		return err
+	}
+
+	c.recv, err = chacha20poly1305.New(recvKey)
+	return err
+}
+
+func (c *conn) expect(tag byte) ([]byte, error) {
+	rtag, data, err := c.readFrame()
+	if err != nil {
+		return nil, err
+	}
+
+	if rtag != tag {
+		return nil, wire.ErrProtocolError
+	}
+
+	return data, nil
+}
+
+func nonce(seq uint64) []byte {
+	n := make([]byte, chacha20poly1305.NonceSize)
+	binary.BigEndian.PutUint64(n[chacha20poly1305.NonceSize-8:], seq)
+	return n
+}
+
+// Encrypts data and sends it as a record with the given tag.
+func (c *conn) writeRecord(tag byte, data []byte) error {
+	sealed := c.send.Seal(nil, nonce(c.sendSeq), data, []byte{tag})
+	c.sendSeq++
+
+	return c.writeFrame(tag, sealed)
+}
+
+// Reads and decrypts the next record.
+func (c *conn) readRecord() (byte, []byte, error) {
+	tag, sealed, err := c.readFrame()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	data, err := c.recv.Open(nil, nonce(c.recvSeq), sealed, []byte{tag})
+	if err != nil {
+		return 0, nil, errors.Wrapf(err, "record failed authentication")
+	}
+
+	c.recvSeq++
+
+	return tag, data, nil
+}
@@ -0,0 +1,185 @@
+package transfer
+
+import (
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"math/big"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/hkdf"
+)
+
+// The key exchange is SPAKE2 from RFC 9382 over P-256, whose scalar
+// multiplications in crypto/elliptic run in constant time. Both sides blind
+// an ephemeral Diffie-Hellman share with the code, so an attacker who doesn't
+// know the code learns nothing from the exchange and gets a single guess per
+// connection.
+
+var curve = elliptic.P256()
+
+// The size in bytes of a scalar.
+const scalarSize = 32
+
+var (
+	// The blinding points for the sending (M) and receiving (N) sides,
+	// from RFC 9382. They were generated by hashing so that no one knows
+	// their discrete log.
+	groupM = mustPoint("04886e2f97ace46e55ba9dd7242579f2993b64e16ef3dcab95afd497333d8fa12f" +
+		"5ff355163e43ce224e0b0e65ff02ac8e5c7be09419c785e0ca547d55a12e2d20")
+	groupN = mustPoint("04d8bbd6c639c62937b04d997f38c3770719c629d7014d49a24b4f98baa1292b49" +
+		"07d60aa6bfade45008a636337f5168c64d9bd36034808cd564490b1e656edbe7")
+)
+
+type point struct {
+	x, y *big.Int
+}
+
+func mustPoint(str string) point {
+	b, err := hex.DecodeString(str)
+	if err != nil {
+		panic(err)
+	}
+
+	p, err := decodePoint(b)
+	if err != nil {
+		panic(err)
+	}
+
+	return p
+}
+
+func (p point) encode() []byte {
+	return elliptic.Marshal(curve, p.x, p.y)
+}
+
+var ErrInvalidElement = errors.New("invalid key exchange element")
+
+// Decodes a point sent by the other side. Unmarshal checks that it's on the
+// curve, and P-256 has no small subgroups to worry about beyond that.
+func decodePoint(b []byte) (point, error) {
+	x, y := elliptic.Unmarshal(curve, b)
+	if x == nil {
+		return point{}, ErrInvalidElement
+	}
+
+	return point{x, y}, nil
+}
+
+// Encodes k as a fixed size big endian value.
+func encodeScalar(k *big.Int) []byte {
+	b := k.Bytes()
+	out := make([]byte, scalarSize)
+	copy(out[scalarSize-len(b):], b)
+	return out
+}
+
+type spake struct {
+	sender bool
+	w      []byte
+	x      []byte
+	msg    []byte
+}
+
+// Starts an exchange using code, returning the message to send to the other
+// side.
+func newSPAKE(code string, sender bool) (*spake, error) {
+	h := sha256.Sum256([]byte("hzn-transfer password:" + code))
+
+	w := new(big.Int).SetBytes(h[:])
+	w.Mod(w, curve.Params().N)
+
+	s := &spake{
+		sender: sender,
+		w:      encodeScalar(w),
+	}
+
+	x, xx, xy, err := elliptic.GenerateKey(curve, rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	s.x = x
+
+	blind := groupN
+	if sender {
+		blind = groupM
+	}
+
+	// T = x*G + w*blind
+	bx, by := curve.ScalarMult(blind.x, blind.y, s.w)
+	tx, ty := curve.Add(xx, xy, bx, by)
+
+	s.msg = point{tx, ty}.encode()
+
+	return s, nil
+}
+
+type sessionKeys struct {
+	// Used to confirm that both sides derived the same key.
+	senderConfirm, receiverConfirm []byte
+
+	// Used to encrypt data in each direction.
+	senderKey, receiverKey []byte
+}
+
+// Completes the exchange using the other side's message.
+func (s *spake) finish(peer []byte) (*sessionKeys, error) {
+	t, err := decodePoint(peer)
+	if err != nil {
+		return nil, err
+	}
+
+	blind := groupM
+	if s.sender {
+		blind = groupN
+	}
+
+	// K = x*(T - w*blind)
+	ux, uy := curve.ScalarMult(blind.x, blind.y, s.w)
+	uy.Sub(curve.Params().P, uy)
+
+	kx, ky := curve.Add(t.x, t.y, ux, uy)
+	if kx.Sign() == 0 && ky.Sign() == 0 {
+		return nil, ErrInvalidElement
+	}
+
+	kx, ky = curve.ScalarMult(kx, ky, s.x)
+
+	senderMsg, receiverMsg := s.msg, peer
+	if !s.sender {
+		senderMsg, receiverMsg = peer, s.msg
+	}
+
+	h := sha256.New()
+	h.Write([]byte("hzn-transfer-v2"))
+	h.Write(senderMsg)
+	h.Write(receiverMsg)
+	h.Write(point{kx, ky}.encode())
+	h.Write(s.w)
+
+	r := hkdf.New(sha256.New, h.Sum(nil), nil, []byte("hzn-transfer keys"))
+
+	var keys sessionKeys
+
+	for _, b := range []*[]byte{&keys.senderConfirm, &keys.receiverConfirm, &keys.senderKey, &keys.receiverKey} {
+		*b = make([]byte, 32)
+
+		_, err := io.ReadFull(r, *b)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &keys, nil
+}
+
+// Returns the value each side sends to prove it derived the same keys.
+func confirmation(key []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte("hzn-transfer confirm"))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,329 @@
+package transfer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/horizon/pkg/wire"
+	"github.com/pkg/errors"
+)
+
+// File describes a file being transferred.
+type File struct {
+	Name string      `json:"name"`
+	Size int64       `json:"size"`
+	Mode os.FileMode `json:"mode"`
+}
+
+// Progress is called as a file is transferred with the number of bytes
+// transferred so far.
+type Progress func(f *File, done int64)
+
+type refusal struct {
+	Error string `json:"error"`
+}
+
+var (
+	ErrRefused     = errors.New("receiver refused the transfer")
+	ErrInvalidName = errors.New("invalid file name")
+)
+
+// Checks that name is a plain file name that can't escape the directory
+// files are received into.
+func validName(name string) bool {
+	if name == "" || name == "." || name == ".." {
+		return false
+	}
+
+	return !strings.ContainsAny(name, `/\`)
+}
+
+// Send performs the key exchange using code and then sends the files at
+// paths over rw.
+func Send(rw io.ReadWriter, code string, paths []string, progress Progress) error {
+	var files []*File
+
+	for _, path := range paths {
+		fi, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+
+		if !fi.Mode().IsRegular() {
+			return fmt.Errorf("not a regular file: %s", path)
+		}
+
+		files = append(files, &File{
+			Name: filepath.Base(path),
+			Size: fi.Size(),
+			Mode: fi.Mode().Perm(),
+		})
+	}
+
+	c, err := newConn(rw)
+	if err != nil {
+		return err
+	}
+
+	err = c.handshake(code, true)
+	if err != nil {
+		return err
+	}
+
+	manifest, err := json.Marshal(files)
+	if err != nil {
+		return err
+	}
+
+	err = c.writeRecord(tagManifest, manifest)
+	if err != nil {
+		return err
+	}
+
+	tag, data, err := c.readRecord()
+	if err != nil {
+		return err
+	}
+
+	switch tag {
+	case tagAccept:
+	case tagRefuse:
+		var ref refusal
+		json.Unmarshal(data, &ref)
+		return errors.Wrapf(ErrRefused, "%s", ref.Error)
+	default:
+		return errors.Wrapf(wire.ErrProtocolError, "unexpected record: %d", tag)
+	}
+
+	for i, f := range files {
+		err = c.sendFile(paths[i], f, progress)
+		if err != nil {
+			return errors.Wrapf(err, "error sending %s", f.Name)
+		}
+	}
+
+	// Wait for the receiver to confirm it's written everything.
+	tag, _, err = c.readRecord()
+	if err != nil {
+		return err
+	}
+
+	if tag != tagDone {
+		return errors.Wrapf(wire.ErrProtocolError, "unexpected record: %d", tag)
+	}
+
+	return nil
+}
+
+func (c *conn) sendFile(path string, f *File, progress Progress) error {
+	r, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+
+	defer r.Close()
+
+	buf := make([]byte, chunkSize)
+
+	var done int64
+
+	if progress != nil {
+		progress(f, 0)
+	}
+
+	for done < f.Size {
+		n, err := r.Read(buf)
+		if n > 0 {
+			if done+int64(n) > f.Size {
+				return fmt.Errorf("file grew while sending")
+			}
+
+			werr := c.writeRecord(tagData, buf[:n])
+			if werr != nil {
+				return werr
+			}
+
+			done += int64(n)
+
+			if progress != nil {
+				progress(f, done)
+			}
+		}
+
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	if done != f.Size {
+		return fmt.Errorf("file shrank while sending")
+	}
+
+	return c.writeRecord(tagEndFile, nil)
+}
+
+// Receive performs the key exchange using code and then writes the files
+// sent over rw into dir. Existing files are never overwritten and files are
+// only moved into place once they've been completely received.
+func Receive(rw io.ReadWriter, code, dir string, progress Progress) ([]*File, error) {
+	c, err := newConn(rw)
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.handshake(code, false)
+	if err != nil {
+		return nil, err
+	}
+
+	tag, data, err := c.readRecord()
+	if err != nil {
+		return nil, err
+	}
+
+	if tag != tagManifest {
+		return nil, errors.Wrapf(wire.ErrProtocolError, "unexpected record: %d", tag)
+	}
+
+	var files []*File
+
+	err = json.Unmarshal(data, &files)
+	if err != nil {
+		return nil, err
+	}
+
+	err = checkManifest(dir, files)
+	if err != nil {
+		data, _ := json.Marshal(&refusal{Error: err.Error()})
+		c.writeRecord(tagRefuse, data)
+		return nil, err
+	}
+
+	err = c.writeRecord(tagAccept, []byte("{}"))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, f := range files {
+		err = c.receiveFile(dir, f, progress)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error receiving %s", f.Name)
+		}
+	}
+
+	err = c.writeRecord(tagDone, []byte("{}"))
+	if err != nil {
+		return nil, err
+	}
+
+	return files, nil
+}
+
+func checkManifest(dir string, files []*File) error {
+	seen := map[string]bool{}
+
+	for _, f := range files {
+		if !validName(f.Name) {
+			return errors.Wrapf(ErrInvalidName, "%q", f.Name)
+		}
+
+		if seen[f.Name] {
+			return fmt.Errorf("duplicate file: %s", f.Name)
+		}
+
+		seen[f.Name] = true
+
+		if f.Size < 0 {
+			return fmt.Errorf("invalid size for %s", f.Name)
+		}
+
+		_, err := os.Lstat(filepath.Join(dir, f.Name))
+		if err == nil {
+			return fmt.Errorf("file already exists: %s", f.Name)
+		}
+
+		if !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *conn) receiveFile(dir string, f *File, progress Progress) error {
+	tmp, err := ioutil.TempFile(dir, ".hzn-transfer-")
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	var done int64
+
+	if progress != nil {
+		progress(f, 0)
+	}
+
+	for {
+		tag, data, err := c.readRecord()
+		if err != nil {
+			return err
+		}
+
+		if tag == tagEndFile {
+			break
+		}
+
+		if tag != tagData {
+			return errors.Wrapf(wire.ErrProtocolError, "unexpected record: %d", tag)
+		}
+
+		if done+int64(len(data)) > f.Size {
+			return fmt.Errorf("more data sent than declared")
+		}
+
+		_, err = tmp.Write(data)
+		if err != nil {
+			return err
+		}
+
+		done += int64(len(data))
+
+		if progress != nil {
+			progress(f, done)
+		}
+	}
+
+	if done != f.Size {
+		return fmt.Errorf("expected %d bytes, received %d", f.Size, done)
+	}
+
+	err = tmp.Chmod(f.Mode.Perm())
+	if err != nil {
+		return err
+	}
+
+	err = tmp.Close()
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(dir, f.Name)
+
+	_, err = os.Lstat(path)
+	if err == nil {
+		return fmt.Errorf("file already exists: %s", f.Name)
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
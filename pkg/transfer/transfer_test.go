@@ -0,0 +1,153 @@
+package transfer
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransfer(t *testing.T) {
+	setup := func(t *testing.T) (string, string, func()) {
+		src, err := ioutil.TempDir("", "hzn-src")
+		require.NoError(t, err)
+
+		dst, err := ioutil.TempDir("", "hzn-dst")
+		require.NoError(t, err)
+
+		return src, dst, func() {
+			os.RemoveAll(src)
+			os.RemoveAll(dst)
+		}
+	}
+
+	t.Run("sends files end to end", func(t *testing.T) {
+		src, dst, cleanup := setup(t)
+		defer cleanup()
+
+		big := make([]byte, 3*chunkSize+17)
+		for i := range big {
+			big[i] = byte(i)
+		}
+
+		require.NoError(t, ioutil.WriteFile(filepath.Join(src, "big.bin"), big, 0640))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(src, "empty.txt"), nil, 0600))
+
+		code, err := GenerateCode()
+		require.NoError(t, err)
+
+		a, b := net.Pipe()
+		defer a.Close()
+		defer b.Close()
+
+		errs := make(chan error, 1)
+
+		go func() {
+			errs <- Send(a, code, []string{
+				filepath.Join(src, "big.bin"),
+				filepath.Join(src, "empty.txt"),
+			}, nil)
+		}()
+
+		var last int64
+
+		files, err := Receive(b, code, dst, func(f *File, done int64) {
+			if f.Name == "big.bin" {
+				last = done
+			}
+		})
+		require.NoError(t, err)
+		require.NoError(t, <-errs)
+
+		require.Len(t, files, 2)
+		assert.Equal(t, int64(len(big)), last)
+
+		data, err := ioutil.ReadFile(filepath.Join(dst, "big.bin"))
+		require.NoError(t, err)
+		assert.Equal(t, big, data)
+
+		fi, err := os.Stat(filepath.Join(dst, "empty.txt"))
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), fi.Size())
+		assert.Equal(t, os.FileMode(0600), fi.Mode().Perm())
+	})
+
+	t.Run("fails when the codes don't match", func(t *testing.T) {
+		src, dst, cleanup := setup(t)
+		defer cleanup()
+
+		require.NoError(t, ioutil.WriteFile(filepath.Join(src, "secret.txt"), []byte("secret"), 0600))
+
+		a, b := net.Pipe()
+
+		errs := make(chan error, 1)
+
+		go func() {
+			errs <- Send(a, "1234-5678-9012-3456", []string{filepath.Join(src, "secret.txt")}, nil)
+		}()
+
+		_, err := Receive(b, "1234-5678-9012-3457", dst, nil)
+		assert.Equal(t, ErrBadCode, err)
+
+		b.Close()
+
+		assert.Equal(t, ErrBadCode, <-errs)
+
+		_, err = os.Stat(filepath.Join(dst, "secret.txt"))
+		assert.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("refuses to overwrite existing files", func(t *testing.T) {
+		src, dst, cleanup := setup(t)
+		defer cleanup()
+
+		require.NoError(t, ioutil.WriteFile(filepath.Join(src, "a.txt"), []byte("new"), 0600))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dst, "a.txt"), []byte("old"), 0600))
+
+		a, b := net.Pipe()
+		defer a.Close()
+		defer b.Close()
+
+		errs := make(chan error, 1)
+
+		go func() {
+			errs <- Send(a, "1234-5678-9012-3456", []string{filepath.Join(src, "a.txt")}, nil)
+		}()
+
+		_, err := Receive(b, "1234-5678-9012-3456", dst, nil)
+		require.Error(t, err)
+
+		err = <-errs
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "already exists")
+
+		data, err := ioutil.ReadFile(filepath.Join(dst, "a.txt"))
+		require.NoError(t, err)
+		assert.Equal(t, "old", string(data))
+	})
+
+	t.Run("parses codes", func(t *testing.T) {
+		code, err := GenerateCode()
+		require.NoError(t, err)
+
+		parsed, nameplate, err := ParseCode(" " + code + "\n")
+		require.NoError(t, err)
+
+		assert.Equal(t, code, parsed)
+		assert.Equal(t, code[:4], nameplate)
+
+		_, _, err = ParseCode("12-3456-7890-1234")
+		assert.Error(t, err)
+
+		_, _, err = ParseCode("abcd-3456-7890-1234")
+		assert.Error(t, err)
+
+		// The nameplate is public, so a code must have more than it.
+		_, _, err = ParseCode("1234-3456-7890")
+		assert.Error(t, err)
+	})
+}
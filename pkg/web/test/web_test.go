@@ -1,11 +1,17 @@
 package web_test
 
 import (
+	"bufio"
 	"context"
+	"crypto/tls"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -19,6 +25,8 @@ import (
 	"github.com/hashicorp/horizon/pkg/web"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 )
 
 type fakeHTTPService struct {
@@ -88,6 +96,56 @@ func TestWeb(t *testing.T) {
 		})
 		require.NoError(t, err)
 
+		upstream := httptest.NewServer(http.HandlerFunc(echoUpgrade))
+		defer upstream.Close()
+
+		_, err = a.AddService(&agent.Service{
+			Type:    "http",
+			Labels:  pb.ParseLabelSet("env=test2"),
+			Handler: agent.HTTPHandler(upstream.URL),
+		})
+		require.NoError(t, err)
+
+		dir, err := ioutil.TempDir("", "hzn")
+		require.NoError(t, err)
+
+		defer os.RemoveAll(dir)
+
+		sock, err := net.Listen("unix", filepath.Join(dir, "upstream.sock"))
+		require.NoError(t, err)
+
+		defer sock.Close()
+
+		go http.Serve(sock, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, "%s %s", r.Host, r.URL.Path)
+		}))
+
+		unixHandler, err := agent.NewHTTPHandler("unix://"+sock.Addr().String(), &agent.HTTPOptions{
+			Host:       "internal.test",
+			PathPrefix: "/api",
+		})
+		require.NoError(t, err)
+
+		_, err = a.AddService(&agent.Service{
+			Type:    "http",
+			Labels:  pb.ParseLabelSet("env=test3"),
+			Handler: unixHandler,
+		})
+		require.NoError(t, err)
+
+		h2cUpstream := httptest.NewServer(h2c.NewHandler(http.HandlerFunc(echoStream), &http2.Server{}))
+		defer h2cUpstream.Close()
+
+		h2cHandler, err := agent.NewHTTPHandler("h2c://"+h2cUpstream.Listener.Addr().String(), nil)
+		require.NoError(t, err)
+
+		_, err = a.AddService(&agent.Service{
+			Type:    "http",
+			Labels:  pb.ParseLabelSet("env=test4"),
+			Handler: h2cHandler,
+		})
+		require.NoError(t, err)
+
 		err = a.Start(ctx, discovery.HubConfigs(discovery.HubConfig{
 			Addr:     setup.HubAddr,
 			Insecure: true,
@@ -109,6 +167,33 @@ func TestWeb(t *testing.T) {
 
 		require.NoError(t, err)
 
+		_, err = setup.ControlServer.AddLabelLink(setup.MgmtCtx,
+			&pb.AddLabelLinkRequest{
+				Labels:  pb.ParseLabelSet(":hostname=ws.localdomain"),
+				Account: setup.Account,
+				Target:  pb.ParseLabelSet("env=test2"),
+			})
+
+		require.NoError(t, err)
+
+		_, err = setup.ControlServer.AddLabelLink(setup.MgmtCtx,
+			&pb.AddLabelLinkRequest{
+				Labels:  pb.ParseLabelSet(":hostname=unix.localdomain"),
+				Account: setup.Account,
+				Target:  pb.ParseLabelSet("env=test3"),
+			})
+
+		require.NoError(t, err)
+
+		_, err = setup.ControlServer.AddLabelLink(setup.MgmtCtx,
+			&pb.AddLabelLinkRequest{
+				Labels:  pb.ParseLabelSet(":hostname=grpc.localdomain"),
+				Account: setup.Account,
+				Target:  pb.ParseLabelSet("env=test4"),
+			})
+
+		require.NoError(t, err)
+
 		time.Sleep(time.Second)
 
 		require.NoError(t, setup.ControlClient.ForceLabelLinkUpdate(ctx, L))
@@ -149,5 +234,146 @@ func TestWeb(t *testing.T) {
 			expected := "this is from the fake service: this is a request"
 			assert.Equal(t, expected, w.Body.String())
 		})
+
+		t.Run("rewrites requests to unix socket upstreams", func(t *testing.T) {
+			f, err := web.NewFrontend(L, hub, setup.ControlClient, setup.HubServToken)
+			require.NoError(t, err)
+
+			req, err := http.NewRequest("GET", "http://unix.localdomain/users", nil)
+			require.NoError(t, err)
+
+			w := httptest.NewRecorder()
+
+			f.ServeHTTP(w, req)
+
+			assert.Equal(t, 200, w.Code)
+			assert.Equal(t, "internal.test /api/users", w.Body.String())
+		})
+
+		t.Run("bridges websocket upgrades", func(t *testing.T) {
+			f, err := web.NewFrontend(L, hub, setup.ControlClient, setup.HubServToken)
+			require.NoError(t, err)
+
+			s := httptest.NewServer(f)
+			defer s.Close()
+
+			conn, err := net.Dial("tcp", s.Listener.Addr().String())
+			require.NoError(t, err)
+
+			defer conn.Close()
+
+			fmt.Fprintf(conn, "GET /chat HTTP/1.1\r\nHost: ws.localdomain\r\nConnection: Upgrade\r\nUpgrade: echo\r\n\r\n")
+
+			br := bufio.NewReader(conn)
+
+			resp, err := http.ReadResponse(br, nil)
+			require.NoError(t, err)
+
+			assert.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+			assert.Equal(t, "echo", resp.Header.Get("Upgrade"))
+
+			fmt.Fprintf(conn, "hello hzn\n")
+
+			line, err := br.ReadString('\n')
+			require.NoError(t, err)
+
+			assert.Equal(t, "hello hzn\n", line)
+		})
+
+		t.Run("streams http2 bodies and trailers to h2c upstreams", func(t *testing.T) {
+			f, err := web.NewFrontend(L, hub, setup.ControlClient, setup.HubServToken)
+			require.NoError(t, err)
+
+			s := httptest.NewUnstartedServer(f)
+
+			err = http2.ConfigureServer(s.Config, nil)
+			require.NoError(t, err)
+
+			s.TLS = s.Config.TLSConfig
+			s.StartTLS()
+			defer s.Close()
+
+			client := &http.Client{
+				Transport: &http2.Transport{
+					TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+				},
+			}
+
+			pr, pw := io.Pipe()
+
+			req, err := http.NewRequest("POST", s.URL+"/echo.Echo/Stream", pr)
+			require.NoError(t, err)
+
+			req.Host = "grpc.localdomain"
+
+			resp, err := client.Do(req)
+			require.NoError(t, err)
+
+			defer resp.Body.Close()
+
+			assert.Equal(t, 200, resp.StatusCode)
+			assert.Equal(t, "HTTP/2.0", resp.Header.Get("X-Proto"))
+
+			br := bufio.NewReader(resp.Body)
+
+			// Each line is echoed before the request is finished.
+			for _, msg := range []string{"hello\n", "hzn\n"} {
+				fmt.Fprint(pw, msg)
+
+				line, err := br.ReadString('\n')
+				require.NoError(t, err)
+
+				assert.Equal(t, msg, line)
+			}
+
+			pw.Close()
+
+			_, err = ioutil.ReadAll(br)
+			require.NoError(t, err)
+
+			assert.Equal(t, "0", resp.Trailer.Get("Grpc-Status"))
+		})
 	})
 }
+
+// Echoes each line of the request body as it arrives, then sets a trailer
+// the way a gRPC server sets its status.
+func echoStream(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("X-Proto", r.Proto)
+	w.WriteHeader(http.StatusOK)
+	w.(http.Flusher).Flush()
+
+	br := bufio.NewReader(r.Body)
+
+	for {
+		line, err := br.ReadString('\n')
+		if err != nil {
+			break
+		}
+
+		io.WriteString(w, line)
+		w.(http.Flusher).Flush()
+	}
+
+	w.Header().Set(http.TrailerPrefix+"Grpc-Status", "0")
+}
+
+// Switches to a simple line echo protocol when asked to upgrade.
+func echoUpgrade(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Upgrade") != "echo" {
+		http.Error(w, "expected upgrade", http.StatusBadRequest)
+		return
+	}
+
+	conn, brw, err := w.(http.Hijacker).Hijack()
+	if err != nil {
+		return
+	}
+
+	defer conn.Close()
+
+	fmt.Fprintf(brw, "HTTP/1.1 101 Switching Protocols\r\nConnection: Upgrade\r\nUpgrade: echo\r\n\r\n")
+	brw.Flush()
+
+	io.Copy(conn, brw)
+}
@@ -19,6 +19,8 @@ import (
 	"github.com/hashicorp/horizon/pkg/timing"
 	"github.com/hashicorp/horizon/pkg/wire"
 	servertiming "github.com/mitchellh/go-server-timing"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 	"golang.org/x/time/rate"
 )
 
@@ -65,6 +67,7 @@ type Frontend struct {
 	client     *control.Client
 	hub        Connector
 	Checker    HostnameChecker
+	Selector   control.RouteSelector
 	token      string
 	endpointId string
 
@@ -85,11 +88,14 @@ func NewFrontend(L hclog.Logger, h Connector, cl *control.Client, token string)
 		token:      token,
 		rates:      lr,
 		endpointId: cl.Id().SpecString(),
+		Selector:   control.NewScoringSelector(cl),
 	}, nil
 }
 
+// Serve handles requests on l, which doesn't use TLS, accepting HTTP/2 from
+// clients that use it with prior knowledge, as gRPC clients do.
 func (f *Frontend) Serve(l net.Listener) error {
-	return http.Serve(l, f)
+	return http.Serve(l, h2c.NewHandler(f, &http2.Server{}))
 }
 
 func (f *Frontend) extractHost(host string) (string, string, bool) {
@@ -284,7 +290,7 @@ func (f *Frontend) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 
 	var wctx wire.Context
 
-	services := calc.Services()
+	services := f.Selector.SelectRoutes(ctx, calc)
 
 	for _, rs := range services {
 		if rs.Type != "http" {
@@ -292,11 +298,16 @@ func (f *Frontend) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 			continue
 		}
 
+		connStart := time.Now()
+
 		wctx, err = f.hub.ConnectToService(ctx, rs, account, "http", f.token)
 		if err == nil {
+			defer f.Selector.Connected(rs, time.Since(connStart))()
 			break
 		}
 
+		f.Selector.ConnectFailed(rs, err)
+
 		f.L.Warn("error connecting to service", "error", err, "labels", target, "service", rs.Id, "hub", rs.Hub)
 		continue
 	}
@@ -313,6 +324,8 @@ func (f *Frontend) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 
 	defer wctx.Close()
 
+	upgrade := isUpgrade(req)
+
 	var wreq pb.Request
 	wreq.Host = req.Host
 	wreq.Method = req.Method
@@ -333,6 +346,10 @@ func (f *Frontend) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 		})
 	}
 
+	if upgrade {
+		wreq.Type = pb.WEBSOCKET
+	}
+
 	err = wctx.WriteMarshal(1, &wreq)
 	if err != nil {
 		f.L.Error("error connecting to service", "error", err, "labels", target)
@@ -342,9 +359,35 @@ func (f *Frontend) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	adapter := wctx.Writer()
-	io.Copy(adapter, req.Body)
-	adapter.Close()
+	// An upgrade request has no body; the request stream stays open and
+	// carries the client's data once the upgrade is complete.
+	if !upgrade {
+		sendBody := func() {
+			adapter := wctx.Writer()
+			io.Copy(adapter, req.Body)
+			adapter.Close()
+		}
+
+		// HTTP/2 bodies are sent while the response is read so that streaming
+		// requests, such as bidirectional gRPC calls, can make progress. An
+		// HTTP/1 body can't be read once the response has started, so it's
+		// sent first.
+		if req.ProtoMajor >= 2 {
+			done := make(chan struct{})
+
+			go func() {
+				defer close(done)
+				sendBody()
+			}()
+
+			defer func() {
+				req.Body.Close()
+				<-done
+			}()
+		} else {
+			sendBody()
+		}
+	}
 
 	bt.Stop()
 
@@ -360,6 +403,19 @@ func (f *Frontend) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
+	if upgrade && wresp.Code == http.StatusSwitchingProtocols {
+		rt.Stop()
+
+		f.L.Trace("bridging upgraded connection", "id", reqId)
+
+		err = f.bridgeUpgrade(w, &wresp, wctx, rates)
+		if err != nil {
+			f.L.Error("error bridging upgraded connection", "error", err, "id", reqId)
+		}
+
+		return
+	}
+
 	hdr := w.Header()
 
 	for _, h := range wresp.Headers {
@@ -387,8 +443,62 @@ func (f *Frontend) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 
 	w.WriteHeader(int(wresp.Code))
 
+	var out io.Writer = w
+
+	if streamedResponse(req, hdr) {
+		fw := &flushWriter{w: w}
+		fw.flush()
+
+		out = fw
+	}
+
 	f.L.Trace("copying request body", "id", reqId)
-	io.Copy(w, &ratedReader{f: f, r: wctx.Reader(), acc: rates})
+	_, err = io.Copy(out, &ratedReader{f: f, r: wctx.Reader(), acc: rates})
+	if err != nil {
+		return
+	}
+
+	// Services that support trailers send them after the body, others just
+	// end the stream.
+	var trailers pb.Response
+
+	tag, err = wctx.ReadMarshal(&trailers)
+	if err != nil || tag != wire.TagTrailers {
+		return
+	}
+
+	for _, h := range trailers.Headers {
+		for _, v := range h.Value {
+			hdr.Add(http.TrailerPrefix+h.Name, v)
+		}
+	}
+}
+
+// Reports if the response to req may be streamed, and so should reach the
+// client as it arrives rather than when the server's buffers fill. That's
+// HTTP/2 and gRPC responses, and any response without a Content-Length.
+func streamedResponse(req *http.Request, hdr http.Header) bool {
+	return req.ProtoMajor >= 2 ||
+		strings.HasPrefix(hdr.Get("Content-Type"), "application/grpc") ||
+		hdr.Get("Content-Length") == ""
+}
+
+// Flushes each write to the client so that streamed responses aren't held
+// in the server's buffers.
+type flushWriter struct {
+	w io.Writer
+}
+
+func (f *flushWriter) Write(b []byte) (int, error) {
+	n, err := f.w.Write(b)
+	f.flush()
+	return n, err
+}
+
+func (f *flushWriter) flush() {
+	if fl, ok := f.w.(http.Flusher); ok {
+		fl.Flush()
+	}
 }
 
 func renderError(w http.ResponseWriter, fallback string, code int) {
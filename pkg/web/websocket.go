@@ -0,0 +1,103 @@
+package web
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/horizon/pkg/pb"
+	"github.com/hashicorp/horizon/pkg/wire"
+	"github.com/pkg/errors"
+)
+
+var ErrHijackUnsupported = errors.New("connection does not support hijacking")
+
+// Indicates if the request is asking to switch protocols, which is how
+// websockets (and other upgrades) are initiated.
+func isUpgrade(req *http.Request) bool {
+	if req.Header.Get("Upgrade") == "" {
+		return false
+	}
+
+	for _, v := range req.Header["Connection"] {
+		for _, tok := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(tok), "upgrade") {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Takes over the client connection after the service has agreed to switch
+// protocols. The 101 response is written by hand and then data is copied in
+// both directions between the client and the service until either side
+// closes.
+func (f *Frontend) bridgeUpgrade(w http.ResponseWriter, wresp *pb.Response, wctx wire.Context, rates *ratesPerAccount) error {
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		renderError(w, "connection does not support upgrades", http.StatusInternalServerError)
+		return ErrHijackUnsupported
+	}
+
+	conn, brw, err := hj.Hijack()
+	if err != nil {
+		return err
+	}
+
+	defer conn.Close()
+
+	hdr := make(http.Header)
+
+	for _, h := range wresp.Headers {
+		for _, v := range h.Value {
+			hdr.Add(h.Name, v)
+		}
+	}
+
+	hdr.Set("X-Horizon-Endpoint", f.endpointId)
+
+	fmt.Fprintf(brw, "HTTP/1.1 %d %s\r\n", wresp.Code, http.StatusText(int(wresp.Code)))
+
+	err = hdr.Write(brw)
+	if err != nil {
+		return err
+	}
+
+	_, err = brw.WriteString("\r\n")
+	if err != nil {
+		return err
+	}
+
+	err = brw.Flush()
+	if err != nil {
+		return err
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+
+		w := wctx.Writer()
+		defer w.Close()
+
+		// Read through brw so that any data the client sent immediately after
+		// the request and that was already buffered by the server is forwarded.
+		io.Copy(w, brw)
+	}()
+
+	_, err = io.Copy(conn, &ratedReader{f: f, r: wctx.Reader(), acc: rates})
+
+	// The service is finished, so close the client side to unblock the
+	// other copy.
+	conn.Close()
+
+	wg.Wait()
+
+	return err
+}
@@ -2,6 +2,7 @@ package wire
 
 import (
 	"io"
+	"sync/atomic"
 
 	"github.com/pkg/errors"
 )
@@ -13,6 +14,10 @@ const (
 
 type WriteAdapter struct {
 	FW *FramingWriter
+
+	// When set, the messages and bytes written are added to these.
+	messages *int64
+	bytes    *int64
 }
 
 func (f *WriteAdapter) Write(b []byte) (int, error) {
@@ -21,7 +26,14 @@ func (f *WriteAdapter) Write(b []byte) (int, error) {
 		return 0, err
 	}
 
-	return f.FW.Write(b)
+	n, err := f.FW.Write(b)
+
+	if f.bytes != nil {
+		atomic.AddInt64(f.messages, 1)
+		atomic.AddInt64(f.bytes, int64(n))
+	}
+
+	return n, err
 }
 
 func (f *WriteAdapter) Close() error {
@@ -32,11 +44,25 @@ type ReadAdapter struct {
 	FR     *FramingReader
 	rest   int
 	closed bool
+
+	// When set, the messages and bytes read are added to these.
+	messages *int64
+	bytes    *int64
 }
 
 var ErrProtocolError = errors.New("protocol error detected")
 
 func (f *ReadAdapter) Read(b []byte) (int, error) {
+	n, err := f.read(b)
+
+	if f.bytes != nil && n > 0 {
+		atomic.AddInt64(f.bytes, int64(n))
+	}
+
+	return n, err
+}
+
+func (f *ReadAdapter) read(b []byte) (int, error) {
 	if f.closed {
 		return 0, io.EOF
 	}
@@ -67,6 +93,10 @@ func (f *ReadAdapter) Read(b []byte) (int, error) {
 		return 0, errors.Wrapf(ErrProtocolError, "wrong tag detected: %d (wanted %d)", tag, adaptTagData)
 	}
 
+	if f.messages != nil {
+		atomic.AddInt64(f.messages, 1)
+	}
+
 	if sz < len(b) {
 		return io.ReadFull(f.FR, b[:sz])
 	}
@@ -137,6 +137,43 @@ func (c *ctx) Reader() io.Reader {
 	return c.fr.ReadAdapter()
 }
 
+// Returns the ctx underneath c, if there is one.
+func baseContext(c Context) (*ctx, bool) {
+	for {
+		switch v := c.(type) {
+		case *ctx:
+			return v, true
+		case *closeCtx:
+			c = v.Context
+		default:
+			return nil, false
+		}
+	}
+}
+
+// AccountedWriter is like c.Writer, but the data written is included in
+// c.Accounting. Used for raw streams, where the framed messages are the
+// whole of the traffic.
+func AccountedWriter(c Context) io.WriteCloser {
+	base, ok := baseContext(c)
+	if !ok {
+		return c.Writer()
+	}
+
+	return &WriteAdapter{FW: base.fw, messages: base.messages, bytes: base.bytes}
+}
+
+// AccountedReader is like c.Reader, but the data read is included in
+// c.Accounting.
+func AccountedReader(c Context) io.Reader {
+	base, ok := baseContext(c)
+	if !ok {
+		return c.Reader()
+	}
+
+	return &ReadAdapter{FR: base.fr, messages: base.messages, bytes: base.bytes}
+}
+
 var ErrInvalidContext = errors.New("invalid context type")
 
 func (c *ctx) copyTo(octx *ctx) error {
@@ -157,6 +194,11 @@ func (c *ctx) copyTo(octx *ctx) error {
 		if err != nil {
 			return err
 		}
+
+		// Each frame is a message, which means datagram oriented traffic
+		// (like UDP) is accounted per datagram.
+		atomic.AddInt64(octx.messages, 1)
+		atomic.AddInt64(octx.bytes, int64(sz))
 	}
 }
 
@@ -84,14 +84,16 @@ func (f *FramingReader) Read(b []byte) (int, error) {
 		return 0, io.EOF
 	}
 
-	if f.readLeft > len(b) {
-		f.readLeft -= len(b)
-	} else {
+	if len(b) > f.readLeft {
 		b = b[:f.readLeft]
-		f.readLeft = 0
 	}
 
-	return f.br.Read(b)
+	// The underlying reader can return less than asked for, so only
+	// account for what was actually read.
+	n, err := f.br.Read(b)
+	f.readLeft -= n
+
+	return n, err
 }
 
 var frameBufPool = sync.Pool{}
@@ -102,6 +104,20 @@ type Unmarshaller interface {
 
 var ErrRemoteError = errors.New("remote error detected")
 
+// RemoteError is the error text the other side sent back in a pb.Response
+// with tag 255. It matches ErrRemoteError with errors.Is.
+type RemoteError struct {
+	Message string
+}
+
+func (e *RemoteError) Error() string {
+	return e.Message
+}
+
+func (e *RemoteError) Is(err error) bool {
+	return err == ErrRemoteError
+}
+
 func (f *FramingReader) ReadMarshal(v Unmarshaller) (byte, int, error) {
 	tag, sz, err := f.Next()
 	if err != nil {
@@ -122,8 +138,13 @@ func (f *FramingReader) ReadMarshal(v Unmarshaller) (byte, int, error) {
 
 	if tag == 255 {
 		var resp pb.Response
-		resp.Unmarshal(buf[:sz])
-		return 0, 0, errors.Wrapf(ErrRemoteError, resp.Error)
+
+		err = resp.Unmarshal(buf[:sz])
+		if err != nil {
+			return 0, 0, err
+		}
+
+		return 0, 0, &RemoteError{Message: resp.Error}
 	}
 
 	err = v.Unmarshal(buf[:sz])
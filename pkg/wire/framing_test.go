@@ -48,4 +48,107 @@ func TestFraming(t *testing.T) {
 
 		assert.Equal(t, byte(30), tag)
 	})
+
+	t.Run("reads frames larger than the buffer", func(t *testing.T) {
+		var out bytes.Buffer
+
+		fw, err := NewFramingWriter(&out)
+		require.NoError(t, err)
+
+		data := bytes.Repeat([]byte("hzn!"), 10000)
+
+		mb := MarshalBytes(data)
+		_, err = fw.WriteMarshal(30, &mb)
+		require.NoError(t, err)
+
+		mb = MarshalBytes("next")
+		_, err = fw.WriteMarshal(31, &mb)
+		require.NoError(t, err)
+
+		fr, err := NewFramingReader(&out)
+		require.NoError(t, err)
+
+		var got MarshalBytes
+
+		tag, _, err := fr.ReadMarshal(&got)
+		require.NoError(t, err)
+
+		assert.Equal(t, byte(30), tag)
+		assert.Equal(t, data, []byte(got))
+
+		tag, _, err = fr.ReadMarshal(&got)
+		require.NoError(t, err)
+
+		assert.Equal(t, byte(31), tag)
+		assert.Equal(t, "next", string(got))
+	})
+
+	t.Run("accounts data carried by the accounted adapters", func(t *testing.T) {
+		var out bytes.Buffer
+
+		fw, err := NewFramingWriter(&out)
+		require.NoError(t, err)
+
+		wctx := NewContext(nil, nil, fw)
+
+		w := AccountedWriter(wctx)
+
+		_, err = w.Write([]byte("hello"))
+		require.NoError(t, err)
+
+		_, err = w.Write([]byte(" hzn!"))
+		require.NoError(t, err)
+
+		require.NoError(t, w.Close())
+
+		msgs, sz := wctx.Accounting()
+		assert.Equal(t, int64(2), msgs)
+		assert.Equal(t, int64(10), sz)
+
+		fr, err := NewFramingReader(bytes.NewReader(out.Bytes()))
+		require.NoError(t, err)
+
+		rctx := NewContext(nil, fr, nil)
+
+		data, err := ioutil.ReadAll(AccountedReader(rctx))
+		require.NoError(t, err)
+
+		assert.Equal(t, "hello hzn!", string(data))
+
+		msgs, sz = rctx.Accounting()
+		assert.Equal(t, int64(2), msgs)
+		assert.Equal(t, int64(10), sz)
+	})
+
+	t.Run("leaves data carried by the plain adapters out of accounting", func(t *testing.T) {
+		var out bytes.Buffer
+
+		fw, err := NewFramingWriter(&out)
+		require.NoError(t, err)
+
+		wctx := NewContext(nil, nil, fw)
+
+		w := wctx.Writer()
+
+		_, err = w.Write([]byte("hello"))
+		require.NoError(t, err)
+
+		require.NoError(t, w.Close())
+
+		msgs, sz := wctx.Accounting()
+		assert.Equal(t, int64(0), msgs)
+		assert.Equal(t, int64(0), sz)
+
+		fr, err := NewFramingReader(bytes.NewReader(out.Bytes()))
+		require.NoError(t, err)
+
+		rctx := NewContext(nil, fr, nil)
+
+		_, err = ioutil.ReadAll(rctx.Reader())
+		require.NoError(t, err)
+
+		msgs, sz = rctx.Accounting()
+		assert.Equal(t, int64(0), msgs)
+		assert.Equal(t, int64(0), sz)
+	})
 }
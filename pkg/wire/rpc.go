@@ -2,105 +2,92 @@ package wire
 
 import (
 	"context"
+	"io"
 	"sync"
 
 	"github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/horizon/pkg/pb"
-	"github.com/hashicorp/yamux"
 	"github.com/pkg/errors"
 )
 
+// RPCClient makes a single call to an RPCServer over a stream.
 type RPCClient struct {
-	stream *yamux.Stream
+	r      io.Reader
+	w      io.Writer
+	closer io.Closer
 }
 
-const rpcTag = 20
+// TagRPC is the tag of the frames in an RPC. The first frame on the stream is
+// a pb.Request of type RPC naming the method, followed by the request, and
+// the server then replies with the response.
+const TagRPC = 20
 
-func (r *RPCClient) Begin(host, path string, req Marshaller) (RPCContext, error) {
+func (r *RPCClient) begin(host, path string, req Marshaller) (*rpcCtx, error) {
 	var wreq pb.Request
 	wreq.Type = pb.RPC
 	wreq.Path = path
 	wreq.Host = host
 
-	fw, err := NewFramingWriter(r.stream)
+	fw, err := NewFramingWriter(r.w)
 	if err != nil {
 		return nil, err
 	}
 
-	_, err = fw.WriteMarshal(1, &wreq)
+	_, err = fw.WriteMarshal(TagRPC, &wreq)
 	if err != nil {
 		return nil, err
 	}
 
-	_, err = fw.WriteMarshal(rpcTag, req)
+	_, err = fw.WriteMarshal(TagRPC, req)
 	if err != nil {
 		return nil, err
 	}
 
-	fr, err := NewFramingReader(r.stream)
+	fr, err := NewFramingReader(r.r)
 	if err != nil {
 		return nil, err
 	}
 
 	return &rpcCtx{
-		Context: &ctx{
-			fr: fr,
-			fw: fw,
-		},
+		Context: WithCloser(NewContext(nil, fr, fw), r.closer.Close),
 	}, nil
 }
 
-func (r *RPCClient) Call(host, path string, req Marshaller, resp Unmarshaller) error {
-	var wreq pb.Request
-	wreq.Type = pb.RPC
-	wreq.Path = "/query/peers"
-	wreq.Host = "agents.edge"
-
-	fw, err := NewFramingWriter(r.stream)
-	if err != nil {
-		return err
-	}
-
-	defer fw.Recycle()
-
-	_, err = fw.WriteMarshal(1, &wreq)
-	if err != nil {
-		return err
-	}
-
-	_, err = fw.WriteMarshal(rpcTag, req)
-	if err != nil {
-		return err
-	}
-
-	fr, err := NewFramingReader(r.stream)
-	if err != nil {
-		return err
-	}
-
-	defer fr.Recycle()
+// Begin sends req to the method at path and returns the context to continue
+// the call with, for methods that reply with more than a single response.
+// Closing the context closes the stream.
+func (r *RPCClient) Begin(host, path string, req Marshaller) (RPCContext, error) {
+	return r.begin(host, path, req)
+}
 
-	tag, _, err := fr.ReadMarshal(resp)
+// Call sends req to the method at path, reads the reply into resp, and closes
+// the stream.
+func (r *RPCClient) Call(host, path string, req Marshaller, resp Unmarshaller) error {
+	rctx, err := r.begin(host, path, req)
 	if err != nil {
+		r.closer.Close()
 		return err
 	}
 
-	if tag != rpcTag {
-		return errors.Wrapf(ErrProtocolError, "wrong tag recieved: %d", tag)
-	}
+	defer rctx.Close()
 
-	return nil
+	return rctx.ReadResponse(resp)
 }
 
-func NewRPCClient(stream *yamux.Stream) *RPCClient {
+// NewRPCClient returns a client that makes it's call by reading from r and
+// writing to w. closer is closed when the call is done.
+func NewRPCClient(r io.Reader, w io.Writer, closer io.Closer) *RPCClient {
 	return &RPCClient{
-		stream: stream,
+		r:      r,
+		w:      w,
+		closer: closer,
 	}
 }
 
 type RPCContext interface {
 	Context
 	ReadRequest(v Unmarshaller) error
+	ReadResponse(v Unmarshaller) error
 	WriteResponse(v Marshaller) error
 }
 
@@ -108,6 +95,13 @@ type RPCHandler interface {
 	HandleRPC(ctx context.Context, wctx RPCContext) error
 }
 
+// RPCHandlerFunc allows an ordinary function to be used as an RPCHandler.
+type RPCHandlerFunc func(ctx context.Context, wctx RPCContext) error
+
+func (f RPCHandlerFunc) HandleRPC(ctx context.Context, wctx RPCContext) error {
+	return f(ctx, wctx)
+}
+
 type RPCServer struct {
 	mu      sync.RWMutex
 	methods map[string]RPCHandler
@@ -139,27 +133,38 @@ type rpcCtx struct {
 }
 
 func (r *rpcCtx) ReadRequest(v Unmarshaller) error {
+	return r.read(v)
+}
+
+func (r *rpcCtx) ReadResponse(v Unmarshaller) error {
+	return r.read(v)
+}
+
+// Reads the next frame of the call into v. A server that fails the call
+// replies with a pb.Response on tag 255 instead, which is returned as a
+// *RemoteError carrying the server's error text.
+func (r *rpcCtx) read(v Unmarshaller) error {
 	tag, err := r.Context.ReadMarshal(v)
 	if err != nil {
 		return err
 	}
 
-	if tag != rpcTag {
-		return errors.Wrapf(ErrProtocolError, "incorrect tag: %d (expected %d)", tag, rpcTag)
+	if tag != TagRPC {
+		return errors.Wrapf(ErrProtocolError, "incorrect tag: %d (expected %d)", tag, TagRPC)
 	}
 
 	return nil
 }
 
 func (r *rpcCtx) WriteResponse(v Marshaller) error {
-	return r.Context.WriteMarshal(rpcTag, v)
+	return r.Context.WriteMarshal(TagRPC, v)
 }
 
 func (r *RPCServer) HandleRequest(ctx context.Context, L hclog.Logger, wctx Context, wreq *pb.Request) error {
 	r.mu.RLock()
-	defer r.mu.RUnlock()
-
 	handler, ok := r.methods[wreq.Path]
+	r.mu.RUnlock()
+
 	if !ok {
 		return errors.Wrapf(ErrUnknownMethod, "no handler for method: %s", wreq.Path)
 	}
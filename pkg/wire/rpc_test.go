@@ -0,0 +1,102 @@
+package wire
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/horizon/pkg/pb"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRPC(t *testing.T) {
+	var srv RPCServer
+
+	srv.AddMethod("/echo", RPCHandlerFunc(func(ctx context.Context, wctx RPCContext) error {
+		var req MarshalBytes
+
+		err := wctx.ReadRequest(&req)
+		if err != nil {
+			return err
+		}
+
+		resp := MarshalBytes("echo: " + string(req))
+		return wctx.WriteResponse(&resp)
+	}))
+
+	serve := func(conn net.Conn) {
+		defer conn.Close()
+
+		fr, err := NewFramingReader(conn)
+		require.NoError(t, err)
+
+		fw, err := NewFramingWriter(conn)
+		require.NoError(t, err)
+
+		var wreq pb.Request
+
+		tag, _, err := fr.ReadMarshal(&wreq)
+		require.NoError(t, err)
+
+		assert.Equal(t, byte(TagRPC), tag)
+
+		wctx := NewContext(nil, fr, fw)
+
+		err = srv.HandleRequest(context.Background(), hclog.NewNullLogger(), wctx, &wreq)
+		if err != nil {
+			resp := pb.Response{Error: err.Error()}
+			wctx.WriteMarshal(255, &resp)
+		}
+	}
+
+	// A real connection rather than net.Pipe, which doesn't buffer, so
+	// the server can reply before reading the whole request.
+	dial := func() net.Conn {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+
+		go func() {
+			defer l.Close()
+
+			server, err := l.Accept()
+			if err == nil {
+				serve(server)
+			}
+		}()
+
+		client, err := net.Dial("tcp", l.Addr().String())
+		require.NoError(t, err)
+
+		return client
+	}
+
+	t.Run("calls the requested method", func(t *testing.T) {
+		client := dial()
+
+		req := MarshalBytes("hello")
+
+		var resp MarshalBytes
+
+		err := NewRPCClient(client, client, client).Call("hub", "/echo", &req, &resp)
+		require.NoError(t, err)
+
+		assert.Equal(t, "echo: hello", string(resp))
+	})
+
+	t.Run("returns errors from the server", func(t *testing.T) {
+		client := dial()
+
+		req := MarshalBytes("hello")
+
+		var resp MarshalBytes
+
+		err := NewRPCClient(client, client, client).Call("hub", "/nope", &req, &resp)
+		require.Error(t, err)
+
+		assert.True(t, errors.Is(err, ErrRemoteError))
+		assert.Equal(t, "no handler for method: /nope: unknown method requested", err.Error())
+	})
+}
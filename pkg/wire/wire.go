@@ -14,3 +14,35 @@ func Now() *pb.Timestamp {
 		Nsec: uint64(t.Nanosecond()),
 	}
 }
+
+// Tags for the messages an agent sends on a dedicated stream to change the
+// services it's advertising on a hub. Both carry a pb.ServiceInfo and the hub
+// replies with a pb.Response.
+const (
+	TagServiceAdd    = 12
+	TagServiceRemove = 13
+)
+
+// Sent by a draining hub on a stream it opens on each agent session, carrying
+// a pb.Timestamp of when the hub will close the session. The hub refuses new
+// streams from then on, so the agent should connect to another hub and move
+// its traffic there before the deadline.
+const TagGoAway = 14
+
+// Sent by an http service after the response body, carrying the response's
+// trailers as the Headers of a pb.Response. Services that don't send it end
+// the stream after the body instead.
+const TagTrailers = 2
+
+// The RPC methods hubs serve on agent sessions.
+const (
+	// Takes a pb.ConnectRequest, using Target as a label selector and Type to
+	// optionally filter by service type, and returns a pb.ListServicesResponse
+	// of the matching services in the agent's account.
+	RPCQueryPeers = "/query/peers"
+
+	// Takes a pb.SessionIdentification naming the service to connect to. The
+	// hub replies with a pb.ConnectAck, tagged 1 as with a ConnectRequest,
+	// and the stream is then connected to the service.
+	RPCConnectPeer = "/connect/peer"
+)